@@ -1,11 +1,17 @@
 package v1alpha1
 
 import (
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const (
 	CNOEURIScheme = "cnoe://"
+
+	// LFSModePush uploads Git LFS objects to the LFS store of the in-cluster git server.
+	LFSModePush = "Push"
+	// LFSModeMaterialize replaces Git LFS pointer files with the content they point to.
+	LFSModeMaterialize = "Materialize"
 )
 
 // +kubebuilder:object:root=true
@@ -28,17 +34,42 @@ type CustomPackageList struct {
 // CustomPackageSpec controls the installation of the custom applications.
 type CustomPackageSpec struct {
 	ArgoCD ArgoCDPackageSpec `json:"argoCD,omitempty"`
+	// Flux is set instead of ArgoCD for packages synced by Flux.
+	// +kubebuilder:validation:Optional
+	Flux *FluxPackageSpec `json:"flux,omitempty"`
 	// GitServerURL specifies the base URL for the git server for API calls.
 	// for example, https://gitea.cnoe.localtest.me:8443
 	GitServerURL           string          `json:"gitServerURL"`
 	GitServerAuthSecretRef SecretReference `json:"gitServerAuthSecretRef"`
+	// GitServerProvider is the provider of the git server. Defaults to gitea.
+	// +kubebuilder:validation:Optional
+	GitServerProvider string `json:"gitServerProvider,omitempty"`
+	// GitServerOrganization is the organization repositories are created in. Defaults to the Gitea admin user for gitea.
+	// +kubebuilder:validation:Optional
+	GitServerOrganization string `json:"gitServerOrganization,omitempty"`
+	// GitServerRepository configures the repositories created on the git server.
+	// +kubebuilder:validation:Optional
+	GitServerRepository GitRepositorySettings `json:"gitServerRepository,omitempty"`
 	// InternalGitServeURL specifies the base URL for the git server accessible within the cluster.
 	// for example, http://my-gitea-http.gitea.svc.cluster.local:3000
 	InternalGitServeURL string               `json:"internalGitServeURL"`
 	RemoteRepository    RemoteRepositorySpec `json:"remoteRepository"`
+	// Archive specifies the tar archive containing the ArgoCD application file.
+	// +kubebuilder:validation:Optional
+	Archive ArchiveSpec `json:"archive,omitempty"`
 	// Replicate specifies whether to replicate remote or local contents to the local gitea server.
+	// When false, cnoe:// sources of remote packages point Argo CD to the remote repository directly.
+	// Local and archive contents are always replicated.
 	// +kubebuilder:default:=false
 	Replicate bool `json:"replicate"`
+	// Values are available to templated files of the package as .Values and are merged into the helm valuesObject
+	// of Application sources. They are the default values of the package merged with the values supplied by the user.
+	// +kubebuilder:validation:Optional
+	Values *apiextensionsv1.JSON `json:"values,omitempty"`
+	// DependsOn lists the names of the ArgoCD applications that must be Healthy before the application of this package is
+	// created or updated. It is read from the cnoe.io/depends-on annotation of the application.
+	// +kubebuilder:validation:Optional
+	DependsOn []string `json:"dependsOn,omitempty"`
 }
 
 // RemoteRepositorySpec specifies information about remote repositories.
@@ -47,24 +78,72 @@ type RemoteRepositorySpec struct {
 	Path            string `json:"path"`
 	// Url specifies the url to the repository containing the ArgoCD application file
 	Url string `json:"url"`
-	// Ref specifies the specific ref supported by git fetch
+	// Ref specifies the specific ref supported by git fetch.
+	// It can also be set to latest or semver:<constraint>, e.g. semver:^1.4, to use the highest matching tag.
 	Ref string `json:"ref"`
+	// LFS specifies how Git LFS objects are handled when the repository is replicated to the in-cluster git server.
+	// When not set, LFS pointer files are copied as is.
+	// +kubebuilder:validation:Enum:=Push;Materialize
+	// +kubebuilder:validation:Optional
+	LFS string `json:"lfs,omitempty"`
+}
+
+// ArchiveSpec specifies a tar archive, optionally gzip compressed, containing packages.
+type ArchiveSpec struct {
+	// Url is the http(s) url or the absolute local path of the archive.
+	Url string `json:"url"`
+	// SHA256 is the expected hex encoded sha256 checksum of the archive. Required for http(s) urls.
+	// +kubebuilder:validation:Optional
+	SHA256 string `json:"sha256,omitempty"`
 }
 
 type ArgoCDPackageSpec struct {
-	// ApplicationFile specifies the absolute path to the ArgoCD application file
+	// ApplicationFile specifies the absolute path to the ArgoCD application file.
+	// When Archive is set, it is the path relative to the archive root.
 	ApplicationFile string `json:"applicationFile"`
 	Name            string `json:"name"`
 	Namespace       string `json:"namespace"`
-	// +kubebuilder:validation:Enum:=Application;ApplicationSet
+	// Type is Application or ApplicationSet. It is empty for Flux packages.
 	Type string `json:"type"`
 }
 
+// FluxPackageSpec is the Flux object that syncs a package: a Kustomization or a HelmRelease.
+// Its source, when defined in the same file, is applied with it and cnoe:// urls are replaced with the in-cluster repository.
+type FluxPackageSpec struct {
+	// File specifies the absolute path to the file with the Flux objects.
+	// When Archive is set, it is the path relative to the archive root.
+	File      string `json:"file"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	// +kubebuilder:validation:Enum:=Kustomization;HelmRelease
+	Kind string `json:"kind"`
+}
+
+// ApplicationName returns the name of the ArgoCD application or Flux object of the package.
+func (s CustomPackageSpec) ApplicationName() string {
+	if s.Flux != nil {
+		return s.Flux.Name
+	}
+	return s.ArgoCD.Name
+}
+
+// PackageFile returns the file defining the ArgoCD application or Flux object of the package.
+func (s CustomPackageSpec) PackageFile() string {
+	if s.Flux != nil {
+		return s.Flux.File
+	}
+	return s.ArgoCD.ApplicationFile
+}
+
 type CustomPackageStatus struct {
 	// A Custom package is considered synced when the in-cluster repository url is set as the repository URL
 	// This only applies for a package that references local directories
 	Synced            bool        `json:"synced,omitempty"`
 	GitRepositoryRefs []ObjectRef `json:"gitRepositoryRefs,omitempty"`
+	// ResolvedRef is the tag the remote repository ref resolved to when it is set to latest or a semver constraint.
+	ResolvedRef string `json:"resolvedRef,omitempty"`
+	// BlockedBy lists the dependencies that are not Healthy yet or that form a dependency cycle with this package.
+	BlockedBy []string `json:"blockedBy,omitempty"`
 }
 
 type ObjectRef struct {
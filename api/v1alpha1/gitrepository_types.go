@@ -1,16 +1,20 @@
 package v1alpha1
 
 import (
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const (
 	GitProviderGitea   = "gitea"
 	GitProviderGitHub  = "github"
+	GitProviderGitHTTP = "githttp"
+	GitProviderGitLab  = "gitlab"
 	GiteaAdminUserName = "giteaAdmin"
 	SourceTypeLocal    = "local"
 	SourceTypeRemote   = "remote"
 	SourceTypeEmbedded = "embedded"
+	SourceTypeArchive  = "archive"
 )
 
 type GitRepositorySpec struct {
@@ -21,25 +25,31 @@ type GitRepositorySpec struct {
 	SecretRef SecretReference     `json:"secretRef"`
 	Source    GitRepositorySource `json:"source,omitempty"`
 	Provider  Provider            `json:"provider"`
+	// Values are available to templated files of local and archive sources as .Values.
+	// +kubebuilder:validation:Optional
+	Values *apiextensionsv1.JSON `json:"values,omitempty"`
 }
 
 type GitRepositorySource struct {
-	// +kubebuilder:validation:Enum:=argocd;gitea;nginx
+	// +kubebuilder:validation:Enum:=argocd;gitea;nginx;gateway;flux;githttp
 	// +kubebuilder:validation:Optional
 	EmbeddedAppName string `json:"embeddedAppName,omitempty"`
 	// Path is the absolute path to directory that contains Kustomize structure or raw manifests.
-	// This is required when Type is set to local.
+	// This is required when Type is set to local. When Type is set to archive, it is relative to the archive root.
 	// +kubebuilder:validation:Optional
 	Path             string               `json:"path"`
 	RemoteRepository RemoteRepositorySpec `json:"remoteRepository"`
+	// Archive is the tar archive to extract. This is required when Type is set to archive.
+	// +kubebuilder:validation:Optional
+	Archive ArchiveSpec `json:"archive,omitempty"`
 	// Type is the source type.
-	// +kubebuilder:validation:Enum:=local;embedded;remote
+	// +kubebuilder:validation:Enum:=local;embedded;remote;archive
 	// +kubebuilder:default:=embedded
 	Type string `json:"type"`
 }
 
 type Provider struct {
-	// +kubebuilder:validation:Enum:=gitea;github
+	// +kubebuilder:validation:Enum:=gitea;github;githttp;gitlab
 	// +kubebuilder:validation:Required
 	Name string `json:"name"`
 	// GitURL is the base URL of Git server used for API calls.
@@ -49,6 +59,23 @@ type Provider struct {
 	// InternalGitURL is the base URL of Git server accessible within the cluster only.
 	InternalGitURL   string `json:"internalGitURL"`
 	OrganizationName string `json:"organizationName"`
+	// Repository configures repositories created by the provider. Used by the github and gitlab providers.
+	// +kubebuilder:validation:Optional
+	Repository GitRepositorySettings `json:"repository,omitempty"`
+}
+
+// GitRepositorySettings are applied to repositories when they are created on the git server.
+type GitRepositorySettings struct {
+	// Visibility of the repository. Defaults to private. internal is only available to organizations on GitHub Enterprise.
+	// +kubebuilder:validation:Enum:=public;private;internal
+	// +kubebuilder:validation:Optional
+	Visibility string `json:"visibility,omitempty"`
+	// Description of the repository. Defaults to the name and namespace of the GitRepository.
+	// +kubebuilder:validation:Optional
+	Description string `json:"description,omitempty"`
+	// Topics of the repository.
+	// +kubebuilder:validation:Optional
+	Topics []string `json:"topics,omitempty"`
 }
 
 type SecretReference struct {
@@ -74,8 +101,14 @@ type GitRepositoryStatus struct {
 	InternalGitRepositoryUrl string `json:"internalGitRepositoryUrl"`
 	// Path is the path within the repository that contains the files.
 	// +kubebuilder:validation:Optional
-	Path   string `json:"path"`
-	Synced bool   `json:"synced"`
+	Path string `json:"path"`
+	// ResolvedRef is the tag the remote repository ref resolved to when it is set to latest or a semver constraint.
+	// +kubebuilder:validation:Optional
+	ResolvedRef string `json:"resolvedRef,omitempty"`
+	// ArchiveDigest is the sha256 checksum of the archive that was last synced.
+	// +kubebuilder:validation:Optional
+	ArchiveDigest string `json:"archiveDigest,omitempty"`
+	Synced        bool   `json:"synced"`
 }
 
 // +kubebuilder:object:root=true
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/cnoe-io/idpbuilder/globals"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -16,7 +17,9 @@ const (
 	PackagePriorityAnnotation = "cnoe.io/package-priority"
 	// PackageSourcePathAnnotation indicates the source path of a package.
 	PackageSourcePathAnnotation = "cnoe.io/package-source-path"
-	FieldManager                = "idpbuilder"
+	// PackageDependsOnAnnotation lists the comma separated names of the applications a package depends on.
+	PackageDependsOnAnnotation = "cnoe.io/depends-on"
+	FieldManager               = "idpbuilder"
 	// If GetSecretLabelKey is set to GetSecretLabelValue on a kubernetes secret, secret key and values can be used by the get command.
 	CLISecretLabelKey      = "cnoe.io/cli-secret"
 	CLISecretLabelValue    = "true"
@@ -28,12 +31,31 @@ const (
 	ArgoCDPackageName       = "argocd"
 	GiteaPackageName        = "gitea"
 	IngressNginxPackageName = "nginx"
+	GatewayPackageName      = "gateway"
+	FluxPackageName         = "flux"
+	GitHTTPPackageName      = "githttp"
+
+	// IngressControllerNginx serves ingresses of the core packages with ingress-nginx.
+	IngressControllerNginx = "nginx"
+	// IngressControllerGateway serves the core packages with Gateway API routes and Envoy Gateway.
+	IngressControllerGateway = "gateway"
+
+	// GitOpsEngineArgoCD syncs packages with Argo CD applications.
+	GitOpsEngineArgoCD = "argocd"
+	// GitOpsEngineFlux syncs packages with Flux Kustomizations and HelmReleases.
+	GitOpsEngineFlux = "flux"
+
+	// GitServerGitea hosts the package repositories in Gitea.
+	GitServerGitea = "gitea"
+	// GitServerGitHTTP hosts the package repositories in the built-in git smart HTTP server.
+	GitServerGitHTTP = "githttp"
 )
 
 // ArgoPackageConfigSpec Allows for configuration of the ArgoCD Installation.
 // If no fields are specified then the binary embedded resources will be used to install ArgoCD.
+// With the flux GitOps engine, it configures the Flux installation instead.
 type ArgoPackageConfigSpec struct {
-	// Enabled controls whether to install ArgoCD.
+	// Enabled controls whether to install the GitOps engine, ArgoCD or Flux.
 	Enabled bool `json:"enabled,omitempty"`
 }
 
@@ -43,16 +65,77 @@ type EmbeddedArgoApplicationsPackageConfigSpec struct {
 	Enabled bool `json:"enabled,omitempty"`
 }
 
+// NginxPackageConfigSpec controls the installation of the ingress controller, ingress-nginx or Envoy Gateway.
+type NginxPackageConfigSpec struct {
+	// Enabled controls whether to install the ingress controller.
+	Enabled bool `json:"enabled,omitempty"`
+}
+
+// GiteaPackageConfigSpec controls the installation of Gitea.
+// With the githttp git server, it controls the installation of the built-in git server instead.
+type GiteaPackageConfigSpec struct {
+	// Enabled controls whether to install the git server, Gitea or githttp. When it is not installed, repositories are pushed to the GitServer.
+	Enabled bool `json:"enabled,omitempty"`
+}
+
+// GitServerSpec is a git server that is not installed by idpbuilder.
+type GitServerSpec struct {
+	Provider Provider `json:"provider"`
+	// SecretRef is the reference to the secret with the credentials of the git server.
+	SecretRef SecretReference `json:"secretRef"`
+}
+
 type PackageConfigsSpec struct {
 	Argo                     ArgoPackageConfigSpec                     `json:"argoPackageConfigs,omitempty"`
 	EmbeddedArgoApplications EmbeddedArgoApplicationsPackageConfigSpec `json:"embeddedArgoApplicationsPackageConfigs,omitempty"`
-	CustomPackageFiles       []string                                  `json:"customPackageFiles,omitempty"`
-	CustomPackageDirs        []string                                  `json:"customPackageDirs,omitempty"`
-	CustomPackageUrls        []string                                  `json:"customPackageUrls,omitempty"`
+	// +kubebuilder:validation:Optional
+	Nginx NginxPackageConfigSpec `json:"nginxPackageConfigs,omitempty"`
+	// +kubebuilder:validation:Optional
+	Gitea GiteaPackageConfigSpec `json:"giteaPackageConfigs,omitempty"`
+	// GitServer is the git server repositories are pushed to when Gitea is not installed.
+	// +kubebuilder:validation:Optional
+	GitServer          *GitServerSpec `json:"gitServer,omitempty"`
+	CustomPackageFiles []string       `json:"customPackageFiles,omitempty"`
+	CustomPackageDirs  []string       `json:"customPackageDirs,omitempty"`
+	CustomPackageUrls  []string       `json:"customPackageUrls,omitempty"`
+	// CustomPackageOCIArtifacts are references to OCI artifacts containing packages, e.g. oci://ghcr.io/org/pkg:v1.0.0
+	CustomPackageOCIArtifacts []string `json:"customPackageOCIArtifacts,omitempty"`
+	// CustomPackageHelmCharts are charts in Helm chart repositories, e.g. helm://charts.example.com/stable/nginx@1.2.3
+	CustomPackageHelmCharts []string `json:"customPackageHelmCharts,omitempty"`
+	// CustomPackageArchives are tar archives containing packages, e.g. https://example.com/pkg.tgz?sha256=<checksum>
+	CustomPackageArchives []string `json:"customPackageArchives,omitempty"`
+	// CustomPackageValues are values of all custom packages. They take precedence over the default values of a package.
+	// +kubebuilder:validation:Optional
+	CustomPackageValues *apiextensionsv1.JSON `json:"customPackageValues,omitempty"`
+	// CustomPackageLocationValues are values of individual custom packages keyed by the package location.
+	// They take precedence over CustomPackageValues.
+	// +kubebuilder:validation:Optional
+	CustomPackageLocationValues map[string]apiextensionsv1.JSON `json:"customPackageLocationValues,omitempty"`
+	// CustomPackagePriorities are explicit priorities of custom packages keyed by the package location.
+	// Packages without an explicit priority use their index in the list of packages of the same type.
+	// When packages define the same ArgoCD application, the package with the highest priority is installed.
+	// +kubebuilder:validation:Optional
+	CustomPackagePriorities map[string]int `json:"customPackagePriorities,omitempty"`
+	// StrictConflicts stops the build when packages define the same ArgoCD application.
+	// +kubebuilder:validation:Optional
+	StrictConflicts bool `json:"strictConflicts,omitempty"`
 	// +kubebuilder:validation:Optional
 	CorePackageCustomization map[string]PackageCustomization `json:"packageCustomization,omitempty"`
 }
 
+// CorePackageEnabled returns true if the core package with the given name is installed.
+func (p PackageConfigsSpec) CorePackageEnabled(name string) bool {
+	switch name {
+	case ArgoCDPackageName, FluxPackageName:
+		return p.Argo.Enabled
+	case GiteaPackageName, GitHTTPPackageName:
+		return p.Gitea.Enabled
+	case IngressNginxPackageName, GatewayPackageName:
+		return p.Nginx.Enabled
+	}
+	return false
+}
+
 // BuildCustomizationSpec fields cannot change once a cluster is created
 type BuildCustomizationSpec struct {
 	Protocol       string `json:"protocol,omitempty"`
@@ -62,6 +145,46 @@ type BuildCustomizationSpec struct {
 	UsePathRouting bool   `json:"usePathRouting,omitempty"`
 	SelfSignedCert string `json:"selfSignedCert,omitempty"`
 	StaticPassword bool   `json:"staticPassword,omitempty"`
+	// IngressController is the implementation that routes traffic to the core packages: nginx or gateway.
+	// Defaults to nginx.
+	// +kubebuilder:validation:Enum:=nginx;gateway
+	// +kubebuilder:validation:Optional
+	IngressController string `json:"ingressController,omitempty"`
+	// GitOpsEngine is the CD technology that syncs packages to the cluster: argocd or flux. Defaults to argocd.
+	// +kubebuilder:validation:Enum:=argocd;flux
+	// +kubebuilder:validation:Optional
+	GitOpsEngine string `json:"gitOpsEngine,omitempty"`
+	// GitServer is the git server installed to host the package repositories: gitea or githttp. Defaults to gitea.
+	// +kubebuilder:validation:Enum:=gitea;githttp
+	// +kubebuilder:validation:Optional
+	GitServer string `json:"gitServer,omitempty"`
+	// GitHTTPImage is the container image of the githttp git server.
+	// +kubebuilder:validation:Optional
+	GitHTTPImage string `json:"gitHTTPImage,omitempty"`
+}
+
+// GitOpsEnginePackageName returns the name of the core package of the GitOps engine.
+func (b BuildCustomizationSpec) GitOpsEnginePackageName() string {
+	if b.GitOpsEngine == GitOpsEngineFlux {
+		return FluxPackageName
+	}
+	return ArgoCDPackageName
+}
+
+// GitServerPackageName returns the name of the core package of the git server.
+func (b BuildCustomizationSpec) GitServerPackageName() string {
+	if b.GitServer == GitServerGitHTTP {
+		return GitHTTPPackageName
+	}
+	return GiteaPackageName
+}
+
+// IngressPackageName returns the name of the core package of the ingress controller.
+func (b BuildCustomizationSpec) IngressPackageName() string {
+	if b.IngressController == IngressControllerGateway {
+		return GatewayPackageName
+	}
+	return IngressNginxPackageName
 }
 
 type LocalbuildSpec struct {
@@ -75,15 +198,62 @@ type PackageCustomization struct {
 	Name string `json:"name,omitempty'"`
 	// FilePath is the absolute file path to a YAML file that contains Kubernetes manifests.
 	FilePath string `json:"filePath,omitempty"`
+	// FilePaths are the absolute file paths to more customization files. They are applied in order after FilePath.
+	FilePaths []string `json:"filePaths,omitempty"`
+}
+
+// Files returns the paths to the customization files in the order they are applied.
+func (p PackageCustomization) Files() []string {
+	var out []string
+	if p.FilePath != "" {
+		out = append(out, p.FilePath)
+	}
+	return append(out, p.FilePaths...)
 }
 
 type LocalbuildStatus struct {
 	// ObservedGeneration is the 'Generation' of the Service that was last processed by the controller.
 	// +optional
-	ObservedGeneration int64        `json:"observedGeneration,omitempty"`
-	ArgoCD             ArgoCDStatus `json:"ArgoCD,omitempty"`
-	Nginx              NginxStatus  `json:"nginx,omitempty"`
-	Gitea              GiteaStatus  `json:"gitea,omitempty"`
+	ObservedGeneration int64         `json:"observedGeneration,omitempty"`
+	ArgoCD             ArgoCDStatus  `json:"ArgoCD,omitempty"`
+	Flux               FluxStatus    `json:"flux,omitempty"`
+	Nginx              NginxStatus   `json:"nginx,omitempty"`
+	Gateway            GatewayStatus `json:"gateway,omitempty"`
+	Gitea              GiteaStatus   `json:"gitea,omitempty"`
+	GitHTTP            GitHTTPStatus `json:"gitHTTP,omitempty"`
+	// OCIArtifacts are the OCI artifacts pulled for custom packages.
+	OCIArtifacts []OCIArtifactStatus `json:"ociArtifacts,omitempty"`
+	// HelmCharts are the charts downloaded for custom packages.
+	HelmCharts []HelmChartStatus `json:"helmCharts,omitempty"`
+	// PackageConflicts are the ArgoCD applications defined by more than one custom package.
+	PackageConflicts []PackageConflict `json:"packageConflicts,omitempty"`
+}
+
+// PackageConflict lists the packages that define the same ArgoCD application.
+type PackageConflict struct {
+	AppName string `json:"appName"`
+	// Winner is the source path of the package that is installed.
+	// It is empty when more than one package has the highest priority.
+	Winner string `json:"winner,omitempty"`
+	// Candidates are the packages defining the application, ordered by priority.
+	Candidates []PackageCandidate `json:"candidates"`
+}
+
+type PackageCandidate struct {
+	SourcePath string `json:"sourcePath"`
+	Priority   int    `json:"priority"`
+}
+
+type HelmChartStatus struct {
+	Reference string `json:"reference"`
+	// Version is the chart version that was last downloaded.
+	Version string `json:"version"`
+}
+
+type OCIArtifactStatus struct {
+	Reference string `json:"reference"`
+	// Digest is the digest of the artifact manifest the reference resolved to when it was last pulled.
+	Digest string `json:"digest"`
 }
 
 type GiteaStatus struct {
@@ -94,15 +264,31 @@ type GiteaStatus struct {
 	AdminUserSecretNamespace string `json:"adminUserSecretNamespace,omitempty"`
 }
 
+type GitHTTPStatus struct {
+	Available                bool   `json:"available,omitempty"`
+	ExternalURL              string `json:"externalURL,omitempty"`
+	InternalURL              string `json:"internalURL,omitempty"`
+	AdminUserSecretName      string `json:"adminUserSecretName,omitempty"`
+	AdminUserSecretNamespace string `json:"adminUserSecretNamespace,omitempty"`
+}
+
 type ArgoCDStatus struct {
 	Available   bool `json:"available,omitempty"`
 	AppsCreated bool `json:"appsCreated,omitempty"`
 }
 
+type FluxStatus struct {
+	Available bool `json:"available,omitempty"`
+}
+
 type NginxStatus struct {
 	Available bool `json:"available,omitempty"`
 }
 
+type GatewayStatus struct {
+	Available bool `json:"available,omitempty"`
+}
+
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:path=localbuilds,scope=Cluster
@@ -21,9 +21,25 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArchiveSpec) DeepCopyInto(out *ArchiveSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArchiveSpec.
+func (in *ArchiveSpec) DeepCopy() *ArchiveSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ArchiveSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ArgoCDPackageSpec) DeepCopyInto(out *ArgoCDPackageSpec) {
 	*out = *in
@@ -104,7 +120,7 @@ func (in *CustomPackage) DeepCopyInto(out *CustomPackage) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
@@ -162,8 +178,25 @@ func (in *CustomPackageList) DeepCopyObject() runtime.Object {
 func (in *CustomPackageSpec) DeepCopyInto(out *CustomPackageSpec) {
 	*out = *in
 	out.ArgoCD = in.ArgoCD
+	if in.Flux != nil {
+		in, out := &in.Flux, &out.Flux
+		*out = new(FluxPackageSpec)
+		**out = **in
+	}
 	out.GitServerAuthSecretRef = in.GitServerAuthSecretRef
+	in.GitServerRepository.DeepCopyInto(&out.GitServerRepository)
 	out.RemoteRepository = in.RemoteRepository
+	out.Archive = in.Archive
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = new(v1.JSON)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DependsOn != nil {
+		in, out := &in.DependsOn, &out.DependsOn
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomPackageSpec.
@@ -184,6 +217,11 @@ func (in *CustomPackageStatus) DeepCopyInto(out *CustomPackageStatus) {
 		*out = make([]ObjectRef, len(*in))
 		copy(*out, *in)
 	}
+	if in.BlockedBy != nil {
+		in, out := &in.BlockedBy, &out.BlockedBy
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomPackageStatus.
@@ -211,12 +249,72 @@ func (in *EmbeddedArgoApplicationsPackageConfigSpec) DeepCopy() *EmbeddedArgoApp
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FluxPackageSpec) DeepCopyInto(out *FluxPackageSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FluxPackageSpec.
+func (in *FluxPackageSpec) DeepCopy() *FluxPackageSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FluxPackageSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FluxStatus) DeepCopyInto(out *FluxStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FluxStatus.
+func (in *FluxStatus) DeepCopy() *FluxStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(FluxStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatewayStatus) DeepCopyInto(out *GatewayStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayStatus.
+func (in *GatewayStatus) DeepCopy() *GatewayStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GatewayStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitHTTPStatus) DeepCopyInto(out *GitHTTPStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitHTTPStatus.
+func (in *GitHTTPStatus) DeepCopy() *GitHTTPStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GitHTTPStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GitRepository) DeepCopyInto(out *GitRepository) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	out.Status = in.Status
 }
 
@@ -270,10 +368,31 @@ func (in *GitRepositoryList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitRepositorySettings) DeepCopyInto(out *GitRepositorySettings) {
+	*out = *in
+	if in.Topics != nil {
+		in, out := &in.Topics, &out.Topics
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepositorySettings.
+func (in *GitRepositorySettings) DeepCopy() *GitRepositorySettings {
+	if in == nil {
+		return nil
+	}
+	out := new(GitRepositorySettings)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GitRepositorySource) DeepCopyInto(out *GitRepositorySource) {
 	*out = *in
 	out.RemoteRepository = in.RemoteRepository
+	out.Archive = in.Archive
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepositorySource.
@@ -289,10 +408,15 @@ func (in *GitRepositorySource) DeepCopy() *GitRepositorySource {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GitRepositorySpec) DeepCopyInto(out *GitRepositorySpec) {
 	*out = *in
-	out.Customization = in.Customization
+	in.Customization.DeepCopyInto(&out.Customization)
 	out.SecretRef = in.SecretRef
 	out.Source = in.Source
-	out.Provider = in.Provider
+	in.Provider.DeepCopyInto(&out.Provider)
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = new(v1.JSON)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepositorySpec.
@@ -321,6 +445,38 @@ func (in *GitRepositoryStatus) DeepCopy() *GitRepositoryStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitServerSpec) DeepCopyInto(out *GitServerSpec) {
+	*out = *in
+	in.Provider.DeepCopyInto(&out.Provider)
+	out.SecretRef = in.SecretRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitServerSpec.
+func (in *GitServerSpec) DeepCopy() *GitServerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GitServerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GiteaPackageConfigSpec) DeepCopyInto(out *GiteaPackageConfigSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GiteaPackageConfigSpec.
+func (in *GiteaPackageConfigSpec) DeepCopy() *GiteaPackageConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GiteaPackageConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GiteaStatus) DeepCopyInto(out *GiteaStatus) {
 	*out = *in
@@ -336,13 +492,28 @@ func (in *GiteaStatus) DeepCopy() *GiteaStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HelmChartStatus) DeepCopyInto(out *HelmChartStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HelmChartStatus.
+func (in *HelmChartStatus) DeepCopy() *HelmChartStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(HelmChartStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Localbuild) DeepCopyInto(out *Localbuild) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Localbuild.
@@ -416,8 +587,28 @@ func (in *LocalbuildSpec) DeepCopy() *LocalbuildSpec {
 func (in *LocalbuildStatus) DeepCopyInto(out *LocalbuildStatus) {
 	*out = *in
 	out.ArgoCD = in.ArgoCD
+	out.Flux = in.Flux
 	out.Nginx = in.Nginx
+	out.Gateway = in.Gateway
 	out.Gitea = in.Gitea
+	out.GitHTTP = in.GitHTTP
+	if in.OCIArtifacts != nil {
+		in, out := &in.OCIArtifacts, &out.OCIArtifacts
+		*out = make([]OCIArtifactStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.HelmCharts != nil {
+		in, out := &in.HelmCharts, &out.HelmCharts
+		*out = make([]HelmChartStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.PackageConflicts != nil {
+		in, out := &in.PackageConflicts, &out.PackageConflicts
+		*out = make([]PackageConflict, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocalbuildStatus.
@@ -430,6 +621,21 @@ func (in *LocalbuildStatus) DeepCopy() *LocalbuildStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NginxPackageConfigSpec) DeepCopyInto(out *NginxPackageConfigSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NginxPackageConfigSpec.
+func (in *NginxPackageConfigSpec) DeepCopy() *NginxPackageConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NginxPackageConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NginxStatus) DeepCopyInto(out *NginxStatus) {
 	*out = *in
@@ -445,6 +651,21 @@ func (in *NginxStatus) DeepCopy() *NginxStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OCIArtifactStatus) DeepCopyInto(out *OCIArtifactStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OCIArtifactStatus.
+func (in *OCIArtifactStatus) DeepCopy() *OCIArtifactStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(OCIArtifactStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ObjectRef) DeepCopyInto(out *ObjectRef) {
 	*out = *in
@@ -460,11 +681,33 @@ func (in *ObjectRef) DeepCopy() *ObjectRef {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PackageCandidate) DeepCopyInto(out *PackageCandidate) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PackageCandidate.
+func (in *PackageCandidate) DeepCopy() *PackageCandidate {
+	if in == nil {
+		return nil
+	}
+	out := new(PackageCandidate)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PackageConfigsSpec) DeepCopyInto(out *PackageConfigsSpec) {
 	*out = *in
 	out.Argo = in.Argo
 	out.EmbeddedArgoApplications = in.EmbeddedArgoApplications
+	out.Nginx = in.Nginx
+	out.Gitea = in.Gitea
+	if in.GitServer != nil {
+		in, out := &in.GitServer, &out.GitServer
+		*out = new(GitServerSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.CustomPackageFiles != nil {
 		in, out := &in.CustomPackageFiles, &out.CustomPackageFiles
 		*out = make([]string, len(*in))
@@ -480,11 +723,45 @@ func (in *PackageConfigsSpec) DeepCopyInto(out *PackageConfigsSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.CustomPackageOCIArtifacts != nil {
+		in, out := &in.CustomPackageOCIArtifacts, &out.CustomPackageOCIArtifacts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CustomPackageHelmCharts != nil {
+		in, out := &in.CustomPackageHelmCharts, &out.CustomPackageHelmCharts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CustomPackageArchives != nil {
+		in, out := &in.CustomPackageArchives, &out.CustomPackageArchives
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CustomPackageValues != nil {
+		in, out := &in.CustomPackageValues, &out.CustomPackageValues
+		*out = new(v1.JSON)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CustomPackageLocationValues != nil {
+		in, out := &in.CustomPackageLocationValues, &out.CustomPackageLocationValues
+		*out = make(map[string]v1.JSON, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.CustomPackagePriorities != nil {
+		in, out := &in.CustomPackagePriorities, &out.CustomPackagePriorities
+		*out = make(map[string]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	if in.CorePackageCustomization != nil {
 		in, out := &in.CorePackageCustomization, &out.CorePackageCustomization
 		*out = make(map[string]PackageCustomization, len(*in))
 		for key, val := range *in {
-			(*out)[key] = val
+			(*out)[key] = *val.DeepCopy()
 		}
 	}
 }
@@ -499,9 +776,34 @@ func (in *PackageConfigsSpec) DeepCopy() *PackageConfigsSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PackageConflict) DeepCopyInto(out *PackageConflict) {
+	*out = *in
+	if in.Candidates != nil {
+		in, out := &in.Candidates, &out.Candidates
+		*out = make([]PackageCandidate, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PackageConflict.
+func (in *PackageConflict) DeepCopy() *PackageConflict {
+	if in == nil {
+		return nil
+	}
+	out := new(PackageConflict)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PackageCustomization) DeepCopyInto(out *PackageCustomization) {
 	*out = *in
+	if in.FilePaths != nil {
+		in, out := &in.FilePaths, &out.FilePaths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PackageCustomization.
@@ -517,6 +819,7 @@ func (in *PackageCustomization) DeepCopy() *PackageCustomization {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Provider) DeepCopyInto(out *Provider) {
 	*out = *in
+	in.Repository.DeepCopyInto(&out.Repository)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Provider.
@@ -5,8 +5,10 @@ import "fmt"
 const (
 	ProjectName string = "idpbuilder"
 
-	NginxNamespace  string = "ingress-nginx"
-	ArgoCDNamespace string = "argocd"
+	NginxNamespace   string = "ingress-nginx"
+	GatewayNamespace string = "envoy-gateway-system"
+	ArgoCDNamespace  string = "argocd"
+	FluxNamespace    string = "flux-system"
 
 	SelfSignedCertSecretName = "idpbuilder-cert"
 	SelfSignedCertCMName     = "idpbuilder-cert"
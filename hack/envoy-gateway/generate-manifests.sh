@@ -0,0 +1,14 @@
+#!/bin/bash
+set -e
+
+INSTALL_YAML="pkg/controllers/localbuild/resources/gateway/k8s/install.yaml"
+GATEWAY_DIR="./hack/envoy-gateway"
+CHART_VERSION="v1.2.4"
+
+echo "# ENVOY GATEWAY INSTALL RESOURCES" >${INSTALL_YAML}
+echo "# This file is auto-generated with 'hack/envoy-gateway/generate-manifests.sh'" >>${INSTALL_YAML}
+
+helm template eg oci://docker.io/envoyproxy/gateway-helm --version ${CHART_VERSION} \
+  --namespace envoy-gateway-system --include-crds >>${INSTALL_YAML}
+
+cp ${GATEWAY_DIR}/gateway.yaml.tmpl pkg/controllers/localbuild/resources/gateway/k8s/gateway.yaml
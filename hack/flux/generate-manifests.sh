@@ -0,0 +1,8 @@
+#!/bin/bash
+set -e
+
+INSTALL_YAML="pkg/controllers/localbuild/resources/flux/install.yaml"
+
+echo "# FLUX INSTALL RESOURCES" >${INSTALL_YAML}
+echo "# This file is auto-generated with 'hack/flux/generate-manifests.sh'" >>${INSTALL_YAML}
+kustomize build ./hack/flux/ >>${INSTALL_YAML}
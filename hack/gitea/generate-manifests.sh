@@ -22,5 +22,6 @@ sed -i.bak '3d' ${INSTALL_YAML}
 sed -i.bak 's/namespace: default/namespace: gitea/g' ${INSTALL_YAML}
 
 cat ${GITEA_DIR}/ingress.yaml.tmpl >>${INSTALL_YAML}
+cat ${GITEA_DIR}/httproute.yaml.tmpl >>${INSTALL_YAML}
 
 rm -rf "${INSTALL_YAML}.bak"
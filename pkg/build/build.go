@@ -4,12 +4,16 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
 	"github.com/cnoe-io/idpbuilder/globals"
 	"github.com/cnoe-io/idpbuilder/pkg/controllers"
 	"github.com/cnoe-io/idpbuilder/pkg/kind"
+	"github.com/cnoe-io/idpbuilder/pkg/util"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -27,55 +31,88 @@ var (
 )
 
 type Build struct {
-	name                 string
-	cfg                  v1alpha1.BuildCustomizationSpec
-	kindConfigPath       string
-	kubeConfigPath       string
-	kubeVersion          string
-	extraPortsMapping    string
-	registryConfig       []string
-	customPackageFiles   []string
-	customPackageDirs    []string
-	customPackageUrls    []string
-	packageCustomization map[string]v1alpha1.PackageCustomization
-	exitOnSync           bool
-	scheme               *runtime.Scheme
-	CancelFunc           context.CancelFunc
+	name                        string
+	cfg                         v1alpha1.BuildCustomizationSpec
+	kindConfigPath              string
+	kubeConfigPath              string
+	kubeVersion                 string
+	extraPortsMapping           string
+	registryConfig              []string
+	customPackageFiles          []string
+	customPackageDirs           []string
+	customPackageUrls           []string
+	customPackageOCIArtifacts   []string
+	customPackageHelmCharts     []string
+	customPackageArchives       []string
+	customPackageValues         *apiextensionsv1.JSON
+	customPackageLocationValues map[string]apiextensionsv1.JSON
+	customPackagePriorities     map[string]int
+	strictConflicts             bool
+	packageCustomization        map[string]v1alpha1.PackageCustomization
+	disabledCorePackages        []string
+	gitServer                   *util.GitServerConfig
+	exitOnSync                  bool
+	gitAuth                     *util.GitAuthConfig
+	noCache                     bool
+	scheme                      *runtime.Scheme
+	CancelFunc                  context.CancelFunc
 }
 
 type NewBuildOptions struct {
-	Name                 string
-	TemplateData         v1alpha1.BuildCustomizationSpec
-	KindConfigPath       string
-	KubeConfigPath       string
-	KubeVersion          string
-	ExtraPortsMapping    string
-	RegistryConfig       []string
-	CustomPackageFiles   []string
-	CustomPackageDirs    []string
-	CustomPackageUrls    []string
-	PackageCustomization map[string]v1alpha1.PackageCustomization
-	ExitOnSync           bool
-	Scheme               *runtime.Scheme
-	CancelFunc           context.CancelFunc
+	Name                        string
+	TemplateData                v1alpha1.BuildCustomizationSpec
+	KindConfigPath              string
+	KubeConfigPath              string
+	KubeVersion                 string
+	ExtraPortsMapping           string
+	RegistryConfig              []string
+	CustomPackageFiles          []string
+	CustomPackageDirs           []string
+	CustomPackageUrls           []string
+	CustomPackageOCIArtifacts   []string
+	CustomPackageHelmCharts     []string
+	CustomPackageArchives       []string
+	CustomPackageValues         *apiextensionsv1.JSON
+	CustomPackageLocationValues map[string]apiextensionsv1.JSON
+	CustomPackagePriorities     map[string]int
+	StrictConflicts             bool
+	PackageCustomization        map[string]v1alpha1.PackageCustomization
+	DisabledCorePackages        []string
+	GitServer                   *util.GitServerConfig
+	ExitOnSync                  bool
+	GitAuth                     *util.GitAuthConfig
+	NoCache                     bool
+	Scheme                      *runtime.Scheme
+	CancelFunc                  context.CancelFunc
 }
 
 func NewBuild(opts NewBuildOptions) *Build {
 	return &Build{
-		name:                 opts.Name,
-		kindConfigPath:       opts.KindConfigPath,
-		kubeConfigPath:       opts.KubeConfigPath,
-		kubeVersion:          opts.KubeVersion,
-		extraPortsMapping:    opts.ExtraPortsMapping,
-		registryConfig:       opts.RegistryConfig,
-		customPackageFiles:   opts.CustomPackageFiles,
-		customPackageDirs:    opts.CustomPackageDirs,
-		customPackageUrls:    opts.CustomPackageUrls,
-		packageCustomization: opts.PackageCustomization,
-		exitOnSync:           opts.ExitOnSync,
-		scheme:               opts.Scheme,
-		cfg:                  opts.TemplateData,
-		CancelFunc:           opts.CancelFunc,
+		name:                        opts.Name,
+		kindConfigPath:              opts.KindConfigPath,
+		kubeConfigPath:              opts.KubeConfigPath,
+		kubeVersion:                 opts.KubeVersion,
+		extraPortsMapping:           opts.ExtraPortsMapping,
+		registryConfig:              opts.RegistryConfig,
+		customPackageFiles:          opts.CustomPackageFiles,
+		customPackageDirs:           opts.CustomPackageDirs,
+		customPackageUrls:           opts.CustomPackageUrls,
+		customPackageOCIArtifacts:   opts.CustomPackageOCIArtifacts,
+		customPackageHelmCharts:     opts.CustomPackageHelmCharts,
+		customPackageArchives:       opts.CustomPackageArchives,
+		customPackageValues:         opts.CustomPackageValues,
+		customPackageLocationValues: opts.CustomPackageLocationValues,
+		customPackagePriorities:     opts.CustomPackagePriorities,
+		strictConflicts:             opts.StrictConflicts,
+		packageCustomization:        opts.PackageCustomization,
+		disabledCorePackages:        opts.DisabledCorePackages,
+		gitServer:                   opts.GitServer,
+		exitOnSync:                  opts.ExitOnSync,
+		gitAuth:                     opts.GitAuth,
+		noCache:                     opts.NoCache,
+		scheme:                      opts.Scheme,
+		cfg:                         opts.TemplateData,
+		CancelFunc:                  opts.CancelFunc,
 	}
 }
 
@@ -129,7 +166,7 @@ func (b *Build) ReconcileCRDs(ctx context.Context, kubeClient client.Client) err
 }
 
 func (b *Build) RunControllers(ctx context.Context, mgr manager.Manager, exitCh chan error, tmpDir string) error {
-	return controllers.RunControllers(ctx, mgr, exitCh, b.CancelFunc, b.exitOnSync, b.cfg, tmpDir)
+	return controllers.RunControllers(ctx, mgr, exitCh, b.CancelFunc, b.exitOnSync, b.cfg, tmpDir, b.gitAuth, kind.FindRegistryConfig(b.registryConfig))
 }
 
 func (b *Build) isCompatible(ctx context.Context, kubeClient client.Client) (bool, error) {
@@ -198,13 +235,14 @@ func (b *Build) Run(ctx context.Context, recreateCluster bool) error {
 		return err
 	}
 
-	dir, err := os.MkdirTemp("", fmt.Sprintf("%s-%s-", globals.ProjectName, b.name))
+	dir, err := b.cloneDir()
 	if err != nil {
-		setupLog.Error(err, "creating temp dir")
 		return err
 	}
-	defer os.RemoveAll(dir)
-	setupLog.V(1).Info("Created temp directory for cloning repositories", "dir", dir)
+	if b.noCache {
+		defer os.RemoveAll(dir)
+	}
+	setupLog.V(1).Info("Using directory for cloning repositories", "dir", dir)
 
 	setupLog.Info("Setting up CoreDNS")
 	err = setupCoreDNS(ctx, kubeClient, b.scheme, b.cfg)
@@ -212,8 +250,10 @@ func (b *Build) Run(ctx context.Context, recreateCluster bool) error {
 		return err
 	}
 
+	pkgConfigs := b.packageConfigs()
+
 	setupLog.Info("Setting up TLS certificate")
-	cert, err := setupSelfSignedCertificate(ctx, setupLog, kubeClient, b.cfg)
+	cert, err := setupSelfSignedCertificate(ctx, setupLog, kubeClient, b.cfg, pkgConfigs)
 	if err != nil {
 		return err
 	}
@@ -229,6 +269,13 @@ func (b *Build) Run(ctx context.Context, recreateCluster bool) error {
 		return err
 	}
 
+	if b.gitServer != nil {
+		setupLog.V(1).Info("Creating git server secret")
+		if err = b.setupGitServerSecret(ctx, kubeClient); err != nil {
+			return err
+		}
+	}
+
 	managerExit := make(chan error)
 
 	setupLog.V(1).Info("Running controllers")
@@ -253,18 +300,7 @@ func (b *Build) Run(ctx context.Context, recreateCluster bool) error {
 		localBuild.ObjectMeta.Annotations[v1alpha1.CliStartTimeAnnotation] = cliStartTime
 		localBuild.Spec = v1alpha1.LocalbuildSpec{
 			BuildCustomization: b.cfg,
-			PackageConfigs: v1alpha1.PackageConfigsSpec{
-				Argo: v1alpha1.ArgoPackageConfigSpec{
-					Enabled: true,
-				},
-				EmbeddedArgoApplications: v1alpha1.EmbeddedArgoApplicationsPackageConfigSpec{
-					Enabled: true,
-				},
-				CustomPackageDirs:        b.customPackageDirs,
-				CustomPackageFiles:       b.customPackageFiles,
-				CustomPackageUrls:        b.customPackageUrls,
-				CorePackageCustomization: b.packageCustomization,
-			},
+			PackageConfigs:     pkgConfigs,
 		}
 
 		return nil
@@ -279,11 +315,110 @@ func (b *Build) Run(ctx context.Context, recreateCluster bool) error {
 			return mgrErr
 		}
 	case <-ctx.Done():
+	}
+
+	if b.strictConflicts {
+		return b.checkPackageConflicts(context.WithoutCancel(ctx), kubeClient)
+	}
+	return nil
+}
+
+func (b *Build) packageConfigs() v1alpha1.PackageConfigsSpec {
+	disabled := map[string]bool{}
+	for _, n := range b.disabledCorePackages {
+		disabled[n] = true
+	}
+	c := v1alpha1.PackageConfigsSpec{
+		Argo: v1alpha1.ArgoPackageConfigSpec{
+			Enabled: !disabled[v1alpha1.ArgoCDPackageName] && !disabled[v1alpha1.FluxPackageName],
+		},
+		EmbeddedArgoApplications: v1alpha1.EmbeddedArgoApplicationsPackageConfigSpec{
+			Enabled: true,
+		},
+		Nginx: v1alpha1.NginxPackageConfigSpec{
+			Enabled: !disabled[v1alpha1.IngressNginxPackageName] && !disabled[v1alpha1.GatewayPackageName],
+		},
+		Gitea: v1alpha1.GiteaPackageConfigSpec{
+			Enabled: !disabled[v1alpha1.GiteaPackageName] && !disabled[v1alpha1.GitHTTPPackageName],
+		},
+		CustomPackageDirs:           b.customPackageDirs,
+		CustomPackageFiles:          b.customPackageFiles,
+		CustomPackageUrls:           b.customPackageUrls,
+		CustomPackageOCIArtifacts:   b.customPackageOCIArtifacts,
+		CustomPackageHelmCharts:     b.customPackageHelmCharts,
+		CustomPackageArchives:       b.customPackageArchives,
+		CustomPackageValues:         b.customPackageValues,
+		CustomPackageLocationValues: b.customPackageLocationValues,
+		CustomPackagePriorities:     b.customPackagePriorities,
+		StrictConflicts:             b.strictConflicts,
+		CorePackageCustomization:    b.packageCustomization,
+	}
+	if b.gitServer != nil {
+		c.GitServer = b.gitServer.Spec()
+	}
+	return c
+}
+
+// setupGitServerSecret stores the credentials of the git server in the cluster.
+func (b *Build) setupGitServerSecret(ctx context.Context, kubeClient client.Client) error {
+	desired := b.gitServer.SecretObject()
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      desired.Name,
+			Namespace: desired.Namespace,
+		},
+	}
+	_, err := controllerutil.CreateOrUpdate(ctx, kubeClient, secret, func() error {
+		secret.StringData = desired.StringData
 		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("creating git server secret: %w", err)
 	}
 	return nil
 }
 
+// checkPackageConflicts returns an error listing the applications defined by more than one package.
+func (b *Build) checkPackageConflicts(ctx context.Context, kubeClient client.Client) error {
+	localBuild := v1alpha1.Localbuild{}
+	if err := kubeClient.Get(ctx, client.ObjectKey{Name: b.name}, &localBuild); err != nil {
+		return fmt.Errorf("getting localbuild resource: %w", err)
+	}
+	conflicts := localBuild.Status.PackageConflicts
+	if len(conflicts) == 0 {
+		return nil
+	}
+	msgs := make([]string, 0, len(conflicts))
+	for _, c := range conflicts {
+		sources := make([]string, 0, len(c.Candidates))
+		for _, cand := range c.Candidates {
+			sources = append(sources, fmt.Sprintf("%s (priority %d)", cand.SourcePath, cand.Priority))
+		}
+		msgs = append(msgs, fmt.Sprintf("%s: %s", c.AppName, strings.Join(sources, ", ")))
+	}
+	return fmt.Errorf("multiple packages define the same applications:\n%s", strings.Join(msgs, "\n"))
+}
+
+// cloneDir returns the directory repositories are cloned to. Unless caching is disabled, clones are kept
+// across runs so that later runs only need to fetch new commits.
+func (b *Build) cloneDir() (string, error) {
+	if b.noCache {
+		dir, err := os.MkdirTemp("", fmt.Sprintf("%s-%s-", globals.ProjectName, b.name))
+		if err != nil {
+			setupLog.Error(err, "creating temp dir")
+			return "", err
+		}
+		return dir, nil
+	}
+
+	dir, err := util.RepoCacheDir()
+	if err != nil {
+		setupLog.Error(err, "creating cache dir")
+		return "", err
+	}
+	return dir, nil
+}
+
 func isBuildCustomizationSpecEqual(s1, s2 v1alpha1.BuildCustomizationSpec) bool {
 	// probably ok to use cmp.Equal but keeping it simple for now
 	return s1.Protocol == s2.Protocol &&
@@ -5,9 +5,12 @@ import (
 	"testing"
 
 	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
+	"github.com/cnoe-io/idpbuilder/globals"
+	"github.com/cnoe-io/idpbuilder/pkg/util"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -65,3 +68,33 @@ func TestIsCompatible(t *testing.T) {
 	fClient.AssertExpectations(t)
 	require.True(t, ok)
 }
+
+func TestPackageConfigs(t *testing.T) {
+	b := Build{}
+	c := b.packageConfigs()
+	for _, n := range []string{v1alpha1.ArgoCDPackageName, v1alpha1.GiteaPackageName, v1alpha1.IngressNginxPackageName} {
+		assert.True(t, c.CorePackageEnabled(n), n)
+	}
+	assert.Nil(t, c.GitServer)
+	assert.Equal(t, globals.NginxNamespace, certificateNamespace(b.cfg, c))
+	assert.Equal(t, globals.GatewayNamespace, certificateNamespace(v1alpha1.BuildCustomizationSpec{IngressController: v1alpha1.IngressControllerGateway}, c))
+
+	b = Build{
+		disabledCorePackages: []string{v1alpha1.GiteaPackageName, v1alpha1.IngressNginxPackageName},
+		gitServer: &util.GitServerConfig{
+			Provider:     v1alpha1.GitProviderGitHub,
+			URL:          "https://github.com",
+			Organization: "platform",
+			Token:        "token",
+		},
+	}
+	c = b.packageConfigs()
+	assert.True(t, c.Argo.Enabled)
+	assert.False(t, c.Gitea.Enabled)
+	assert.False(t, c.Nginx.Enabled)
+	assert.Equal(t, "platform", c.GitServer.Provider.OrganizationName)
+	assert.Equal(t, corev1.NamespaceDefault, certificateNamespace(b.cfg, c))
+
+	b = Build{disabledCorePackages: []string{v1alpha1.GatewayPackageName}}
+	assert.False(t, b.packageConfigs().Nginx.Enabled)
+}
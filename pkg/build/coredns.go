@@ -34,7 +34,7 @@ func setupCoreDNS(ctx context.Context, kubeClient client.Client, scheme *runtime
 		return nil
 	}
 
-	objs, err := k8s.BuildCustomizedObjects("", coreDNSTemplatePath, templates, scheme, templateData)
+	objs, err := k8s.BuildCustomizedObjects(nil, coreDNSTemplatePath, templates, scheme, templateData)
 	if err != nil {
 		return fmt.Errorf("rendering embedded coredns files: %w", err)
 	}
@@ -182,12 +182,9 @@ func createSelfSignedCertificate(sans []string) ([]byte, []byte, error) {
 	return certOut, privateKeyOut, nil
 }
 
-func setupSelfSignedCertificate(ctx context.Context, logger logr.Logger, kubeclient client.Client, config v1alpha1.BuildCustomizationSpec) ([]byte, error) {
-	if err := k8s.EnsureNamespace(ctx, kubeclient, globals.NginxNamespace); err != nil {
-		return nil, err
-	}
-
-	if err := k8s.EnsureNamespace(ctx, kubeclient, globals.ArgoCDNamespace); err != nil {
+func setupSelfSignedCertificate(ctx context.Context, logger logr.Logger, kubeclient client.Client, config v1alpha1.BuildCustomizationSpec, pkgConfigs v1alpha1.PackageConfigsSpec) ([]byte, error) {
+	certNamespace := certificateNamespace(config, pkgConfigs)
+	if err := k8s.EnsureNamespace(ctx, kubeclient, certNamespace); err != nil {
 		return nil, err
 	}
 
@@ -206,7 +203,7 @@ func setupSelfSignedCertificate(ctx context.Context, logger logr.Logger, kubecli
 	}
 
 	logger.V(1).Info("Creating/getting certificate", "host", config.Host, "sans", sans)
-	cert, privateKey, err := getOrCreateIngressCertificateAndKey(ctx, kubeclient, globals.SelfSignedCertSecretName, globals.NginxNamespace, sans)
+	cert, privateKey, err := getOrCreateIngressCertificateAndKey(ctx, kubeclient, globals.SelfSignedCertSecretName, certNamespace, sans)
 	if err != nil {
 		return nil, err
 	}
@@ -217,6 +214,15 @@ func setupSelfSignedCertificate(ctx context.Context, logger logr.Logger, kubecli
 		return nil, err
 	}
 
+	// an argocd installation that is not managed by idpbuilder keeps its own certificate. flux does not serve https
+	if !pkgConfigs.Argo.Enabled || config.GitOpsEnginePackageName() != v1alpha1.ArgoCDPackageName {
+		return cert, nil
+	}
+
+	if err = k8s.EnsureNamespace(ctx, kubeclient, globals.ArgoCDNamespace); err != nil {
+		return nil, err
+	}
+
 	logger.V(1).Info("Creating secret for ArgoCD server", "host", config.Host)
 	err = createCertificateAndKeySecret(ctx, kubeclient, argocdTLSSecretName, globals.ArgoCDNamespace, cert, privateKey)
 	if err != nil {
@@ -224,3 +230,15 @@ func setupSelfSignedCertificate(ctx context.Context, logger logr.Logger, kubecli
 	}
 	return cert, nil
 }
+
+// certificateNamespace returns the namespace of the secret with the certificate of the ingress.
+// ingress-nginx uses it as its default certificate and the listeners of the gateway terminate TLS with it.
+func certificateNamespace(config v1alpha1.BuildCustomizationSpec, pkgConfigs v1alpha1.PackageConfigsSpec) string {
+	if !pkgConfigs.Nginx.Enabled {
+		return corev1.NamespaceDefault
+	}
+	if config.IngressController == v1alpha1.IngressControllerGateway {
+		return globals.GatewayNamespace
+	}
+	return globals.NginxNamespace
+}
@@ -0,0 +1,82 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cnoe-io/idpbuilder/pkg/cmd/helpers"
+	"github.com/cnoe-io/idpbuilder/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Flags
+	maxAge time.Duration
+)
+
+var CacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the local cache of cloned repositories",
+	Long:  ``,
+	RunE:  cacheE,
+}
+
+var PruneCmd = &cobra.Command{
+	Use:          "prune",
+	Short:        "Remove cached repositories that have not been used recently",
+	Long:         ``,
+	RunE:         pruneE,
+	PreRunE:      preCacheE,
+	SilenceUsage: true,
+}
+
+var ClearCmd = &cobra.Command{
+	Use:          "clear",
+	Short:        "Remove all cached repositories",
+	Long:         ``,
+	RunE:         clearE,
+	PreRunE:      preCacheE,
+	SilenceUsage: true,
+}
+
+func init() {
+	CacheCmd.AddCommand(PruneCmd)
+	CacheCmd.AddCommand(ClearCmd)
+	PruneCmd.Flags().DurationVar(&maxAge, "max-age", 7*24*time.Hour, "Remove repositories not used within this duration.")
+}
+
+func cacheE(cmd *cobra.Command, args []string) error {
+	return fmt.Errorf("specify subcommand")
+}
+
+func preCacheE(cmd *cobra.Command, args []string) error {
+	return helpers.SetLogger()
+}
+
+func pruneE(cmd *cobra.Command, args []string) error {
+	dir, err := util.RepoCacheDir()
+	if err != nil {
+		return err
+	}
+	removed, err := util.PruneRepoCache(dir, maxAge)
+	logRemoved(removed)
+	return err
+}
+
+func clearE(cmd *cobra.Command, args []string) error {
+	dir, err := util.RepoCacheDir()
+	if err != nil {
+		return err
+	}
+	removed, err := util.ClearRepoCache(dir)
+	logRemoved(removed)
+	return err
+}
+
+func logRemoved(removed []string) {
+	logger := helpers.CmdLogger
+	for i := range removed {
+		logger.V(1).Info("removed cached repository", "dir", removed[i])
+	}
+	logger.Info("removed cached repositories", "count", len(removed))
+}
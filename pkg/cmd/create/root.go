@@ -6,14 +6,21 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
 	"github.com/cnoe-io/idpbuilder/globals"
 	"github.com/cnoe-io/idpbuilder/pkg/build"
 	"github.com/cnoe-io/idpbuilder/pkg/cmd/helpers"
+	"github.com/cnoe-io/idpbuilder/pkg/cmd/version"
+	"github.com/cnoe-io/idpbuilder/pkg/controllers/localbuild"
 	"github.com/cnoe-io/idpbuilder/pkg/k8s"
+	"github.com/cnoe-io/idpbuilder/pkg/lint"
+	"github.com/cnoe-io/idpbuilder/pkg/packages"
+	"github.com/cnoe-io/idpbuilder/pkg/util"
 	"github.com/spf13/cobra"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/client-go/util/homedir"
 )
 
@@ -32,12 +39,50 @@ const (
 	portUsage           = "Port number to use to access web UIs."
 	pathRoutingUsage    = "When set to true, web UIs are exposed under single domain name. " +
 		"e.g. \"https://cnoe.localtest.me/argocd\" instead of \"https://argocd.cnoe.localtest.me\""
-	extraPackagesUsage             = "Paths to locations containing custom packages"
+	extraPackagesUsage = "Paths to locations containing custom packages. " +
+		"Local directories, local files, git repository urls, OCI artifacts (e.g. oci://ghcr.io/org/pkg:v1.0.0), " +
+		"Helm charts (e.g. helm://charts.example.com/stable/nginx@1.2.3) " +
+		"and tar archives (e.g. https://example.com/pkg.tgz?sha256=<checksum>) are supported."
 	packageCustomizationFilesUsage = "Name of the package and the path to file to customize the core packages with. " +
-		"valid package names are: argocd, nginx, and gitea. e.g. argocd:/tmp/argocd.yaml"
-	noExitUsage = "When set, idpbuilder will not exit after all packages are synced. Useful for continuously syncing local directories."
+		"valid package names are: argocd, flux, nginx, gateway, gitea, and githttp. e.g. argocd:/tmp/argocd.yaml. " +
+		"Files may contain manifests that replace the core package manifests and Kustomizations with patches. " +
+		"Several files for the same package are applied in order."
+	noExitUsage        = "When set, idpbuilder will not exit after all packages are synced. Useful for continuously syncing local directories."
+	gitAuthConfigUsage = "Path to a YAML file with per host credentials for remote package repositories. " +
+		"When not set, ssh-agent, default ssh keys, ~/.git-credentials and ~/.netrc are used."
+	noCacheUsage  = "Clone repositories into a temporary directory instead of the cache at $XDG_CACHE_HOME/idpbuilder."
+	lockfileUsage = "Path to a lock file that pins latest and semver:<constraint> refs of package urls to tags. " +
+		"Refs missing from the file are resolved and added to it."
+	updateLockfileUsage = "Resolve all latest and semver:<constraint> refs again and update the lock file."
+	valuesUsage         = "Paths to values files for all custom packages. Values of later files take precedence. " +
+		"Values of a single package are set with -p <package>@values=<path>."
+	strictConflictsUsage = "Fail when more than one package defines the same Argo CD application. " +
+		"Package priorities are set with -p <package>@priority=<number>."
+	skipPackageLintUsage = "Create the cluster even if idpbuilder package lint finds problems in the custom packages."
+	disableCoreUsage     = "Core packages not to install. valid package names are: argocd, flux, nginx, gateway, gitea, and githttp. " +
+		"argocd and flux both disable the gitops engine, nginx and gateway both disable the ingress controller, " +
+		"gitea and githttp both disable the git server. Disabling the git server requires --git-server-config."
+	gitServerConfigUsage   = "Path to a YAML file describing a git server to push package repositories to instead of gitea."
+	ingressControllerUsage = "Ingress controller that routes traffic to the core packages. nginx or gateway. " +
+		"gateway installs Envoy Gateway and exposes Argo CD and Gitea with Gateway API routes."
+	gitServerUsage = "Git server that hosts the package repositories. gitea or githttp. " +
+		"githttp is a lightweight git server built into idpbuilder without a web UI."
+	gitHTTPImageUsage = "Container image of the githttp git server. Defaults to the idpbuilder image of this version."
+	gitOpsEngineUsage = "GitOps engine that syncs the core and custom packages. argocd or flux. " +
+		"flux installs Flux and syncs packages defined as Flux Kustomizations and HelmReleases."
 )
 
+const gitHTTPImageRepository = "ghcr.io/cnoe-io/idpbuilder"
+
+var corePackages = map[string]struct{}{
+	v1alpha1.ArgoCDPackageName:       {},
+	v1alpha1.GiteaPackageName:        {},
+	v1alpha1.GitHTTPPackageName:      {},
+	v1alpha1.IngressNginxPackageName: {},
+	v1alpha1.GatewayPackageName:      {},
+	v1alpha1.FluxPackageName:         {},
+}
+
 var (
 	// Flags
 	recreateCluster           bool
@@ -55,6 +100,19 @@ var (
 	ingressHost               string
 	port                      string
 	pathRouting               bool
+	gitAuthConfigPath         string
+	noCache                   bool
+	lockfilePath              string
+	updateLockfile            bool
+	valuesFiles               []string
+	strictConflicts           bool
+	skipPackageLint           bool
+	disabledCorePackages      []string
+	gitServerConfigPath       string
+	ingressController         string
+	gitOpsEngine              string
+	gitServerName             string
+	gitHTTPImage              string
 )
 
 var CreateCmd = &cobra.Command{
@@ -85,10 +143,23 @@ func init() {
 	CreateCmd.PersistentFlags().StringVar(&protocol, "protocol", "https", protocolUsage)
 	CreateCmd.PersistentFlags().StringVar(&port, "port", "8443", portUsage)
 	CreateCmd.PersistentFlags().BoolVar(&pathRouting, "use-path-routing", false, pathRoutingUsage)
+	CreateCmd.PersistentFlags().StringVar(&ingressController, "ingress-controller", v1alpha1.IngressControllerNginx, ingressControllerUsage)
+	CreateCmd.PersistentFlags().StringVar(&gitOpsEngine, "gitops-engine", v1alpha1.GitOpsEngineArgoCD, gitOpsEngineUsage)
+	CreateCmd.PersistentFlags().StringVar(&gitServerName, "git-server", v1alpha1.GitServerGitea, gitServerUsage)
+	CreateCmd.PersistentFlags().StringVar(&gitHTTPImage, "git-http-image", "", gitHTTPImageUsage)
 	CreateCmd.Flags().StringSliceVarP(&extraPackages, "package", "p", []string{}, extraPackagesUsage)
 	CreateCmd.Flags().StringSliceVarP(&packageCustomizationFiles, "package-custom-file", "c", []string{}, packageCustomizationFilesUsage)
+	CreateCmd.Flags().StringVar(&gitAuthConfigPath, "git-auth-config", "", gitAuthConfigUsage)
+	CreateCmd.Flags().StringSliceVar(&disabledCorePackages, "disable-core", []string{}, disableCoreUsage)
+	CreateCmd.Flags().StringVar(&gitServerConfigPath, "git-server-config", "", gitServerConfigUsage)
 	// idpbuilder related flags
 	CreateCmd.Flags().BoolVarP(&noExit, "no-exit", "n", true, noExitUsage)
+	CreateCmd.Flags().BoolVar(&noCache, "no-cache", false, noCacheUsage)
+	CreateCmd.Flags().StringVar(&lockfilePath, "lockfile", "", lockfileUsage)
+	CreateCmd.Flags().BoolVar(&updateLockfile, "update-lockfile", false, updateLockfileUsage)
+	CreateCmd.Flags().StringSliceVar(&valuesFiles, "values", []string{}, valuesUsage)
+	CreateCmd.Flags().BoolVar(&strictConflicts, "strict-conflicts", false, strictConflictsUsage)
+	CreateCmd.Flags().BoolVar(&skipPackageLint, "skip-package-lint", false, skipPackageLintUsage)
 }
 
 func preCreateE(cmd *cobra.Command, args []string) error {
@@ -104,6 +175,12 @@ func create(cmd *cobra.Command, args []string) error {
 
 	protocol = strings.ToLower(protocol)
 	host = strings.ToLower(host)
+	ingressController = strings.ToLower(ingressController)
+	gitOpsEngine = strings.ToLower(gitOpsEngine)
+	gitServerName = strings.ToLower(gitServerName)
+	if gitHTTPImage == "" {
+		gitHTTPImage = defaultGitHTTPImage()
+	}
 	if ingressHost == "" {
 		ingressHost = host
 	}
@@ -113,19 +190,11 @@ func create(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	var localFiles []string
-	var localDirs []string
-	var remotePaths []string
-
-	if len(extraPackages) > 0 {
-		r, f, d, pErr := helpers.ParsePackageStrings(extraPackages)
-		if pErr != nil {
-			return pErr
-		}
-		localFiles = f
-		localDirs = d
-		remotePaths = r
+	pkgs, err := packages.ParsePackageStrings(extraPackages)
+	if err != nil {
+		return err
 	}
+	remotePaths := pkgs.Remote
 
 	o := make(map[string]v1alpha1.PackageCustomization)
 	for i := range packageCustomizationFiles {
@@ -133,9 +202,69 @@ func create(cmd *cobra.Command, args []string) error {
 		if pErr != nil {
 			return pErr
 		}
+		// more files for the same package are applied in the order they are given
+		if existing, ok := o[c.Name]; ok {
+			existing.FilePaths = append(existing.FilePaths, c.FilePath)
+			c = existing
+		}
 		o[c.Name] = c
 	}
 
+	gitAuth, err := util.NewGitAuthConfig(gitAuthConfigPath)
+	if err != nil {
+		return err
+	}
+
+	var gitServer *util.GitServerConfig
+	if gitServerConfigPath != "" {
+		gitServer, err = util.NewGitServerConfig(gitServerConfigPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	if lockfilePath != "" {
+		remotePaths, err = pinPackageUrls(ctx, remotePaths, gitAuth)
+		if err != nil {
+			return err
+		}
+		// values files and priorities are keyed by the package location, which changes when the ref is pinned
+		for i := range pkgs.Remote {
+			if f, ok := pkgs.ValuesFiles[pkgs.Remote[i]]; ok {
+				delete(pkgs.ValuesFiles, pkgs.Remote[i])
+				pkgs.ValuesFiles[remotePaths[i]] = f
+			}
+			if p, ok := pkgs.Priorities[pkgs.Remote[i]]; ok {
+				delete(pkgs.Priorities, pkgs.Remote[i])
+				pkgs.Priorities[remotePaths[i]] = p
+			}
+		}
+	}
+
+	globalValues, locationValues, err := readPackageValues(pkgs.ValuesFiles)
+	if err != nil {
+		return err
+	}
+
+	templateData := v1alpha1.BuildCustomizationSpec{
+		Protocol:          protocol,
+		Host:              host,
+		IngressHost:       ingressHost,
+		Port:              port,
+		UsePathRouting:    pathRouting,
+		StaticPassword:    devPassword,
+		IngressController: ingressController,
+		GitOpsEngine:      gitOpsEngine,
+		GitServer:         gitServerName,
+		GitHTTPImage:      gitHTTPImage,
+	}
+
+	if !skipPackageLint {
+		if err = lintPackages(globalValues, templateData); err != nil {
+			return err
+		}
+	}
+
 	exitOnSync := true
 	if cmd.Flags().Changed("no-exit") {
 		exitOnSync = !noExit
@@ -157,20 +286,24 @@ func create(cmd *cobra.Command, args []string) error {
 		ExtraPortsMapping: extraPortsMapping,
 		RegistryConfig:    maybeRegistryConfig,
 
-		TemplateData: v1alpha1.BuildCustomizationSpec{
-			Protocol:       protocol,
-			Host:           host,
-			IngressHost:    ingressHost,
-			Port:           port,
-			UsePathRouting: pathRouting,
-			StaticPassword: devPassword,
-		},
-
-		CustomPackageFiles:   localFiles,
-		CustomPackageDirs:    localDirs,
-		CustomPackageUrls:    remotePaths,
-		ExitOnSync:           exitOnSync,
-		PackageCustomization: o,
+		TemplateData: templateData,
+
+		CustomPackageFiles:          pkgs.Files,
+		CustomPackageDirs:           pkgs.Dirs,
+		CustomPackageUrls:           remotePaths,
+		CustomPackageOCIArtifacts:   pkgs.OCIArtifacts,
+		CustomPackageHelmCharts:     pkgs.HelmCharts,
+		CustomPackageArchives:       pkgs.Archives,
+		CustomPackageValues:         globalValues,
+		CustomPackageLocationValues: locationValues,
+		CustomPackagePriorities:     pkgs.Priorities,
+		StrictConflicts:             strictConflicts,
+		ExitOnSync:                  exitOnSync,
+		PackageCustomization:        o,
+		DisabledCorePackages:        disabledCorePackages,
+		GitServer:                   gitServer,
+		GitAuth:                     gitAuth,
+		NoCache:                     noCache,
 
 		Scheme:     k8s.GetScheme(),
 		CancelFunc: ctxCancel,
@@ -207,10 +340,102 @@ func validate() error {
 		}
 	}
 
-	_, _, _, err = helpers.ParsePackageStrings(extraPackages)
+	if ingressController != v1alpha1.IngressControllerNginx && ingressController != v1alpha1.IngressControllerGateway {
+		return fmt.Errorf("invalid ingress controller %s. must be %s or %s",
+			ingressController, v1alpha1.IngressControllerNginx, v1alpha1.IngressControllerGateway)
+	}
+
+	if ingressController == v1alpha1.IngressControllerGateway && !slices.Contains(disabledCorePackages, v1alpha1.GatewayPackageName) {
+		if err = localbuild.CheckGatewayManifests(); err != nil {
+			return fmt.Errorf("--ingress-controller %s: %w", ingressController, err)
+		}
+	}
+
+	if gitOpsEngine != v1alpha1.GitOpsEngineArgoCD && gitOpsEngine != v1alpha1.GitOpsEngineFlux {
+		return fmt.Errorf("invalid gitops engine %s. must be %s or %s",
+			gitOpsEngine, v1alpha1.GitOpsEngineArgoCD, v1alpha1.GitOpsEngineFlux)
+	}
+
+	if gitOpsEngine == v1alpha1.GitOpsEngineFlux && !slices.Contains(disabledCorePackages, v1alpha1.FluxPackageName) {
+		if err = localbuild.CheckFluxManifests(); err != nil {
+			return fmt.Errorf("--gitops-engine %s: %w", gitOpsEngine, err)
+		}
+	}
+
+	if gitServerName != v1alpha1.GitServerGitea && gitServerName != v1alpha1.GitServerGitHTTP {
+		return fmt.Errorf("invalid git server %s. must be %s or %s",
+			gitServerName, v1alpha1.GitServerGitea, v1alpha1.GitServerGitHTTP)
+	}
+
+	if updateLockfile && lockfilePath == "" {
+		return fmt.Errorf("--update-lockfile requires --lockfile")
+	}
+
+	for _, n := range disabledCorePackages {
+		if _, ok := corePackages[n]; !ok {
+			return fmt.Errorf("invalid core package %s in --disable-core", n)
+		}
+		if (n == v1alpha1.GiteaPackageName || n == v1alpha1.GitHTTPPackageName) && gitServerConfigPath == "" {
+			return fmt.Errorf("--disable-core %s requires --git-server-config", n)
+		}
+	}
+
+	_, err = packages.ParsePackageStrings(extraPackages)
 	return err
 }
 
+func readPackageValues(pkgValuesFiles map[string]string) (*apiextensionsv1.JSON, map[string]apiextensionsv1.JSON, error) {
+	values, err := util.ReadValuesFiles(valuesFiles...)
+	if err != nil {
+		return nil, nil, err
+	}
+	global, err := util.ValuesToJSON(values)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	locationValues := make(map[string]apiextensionsv1.JSON, len(pkgValuesFiles))
+	for loc, f := range pkgValuesFiles {
+		v, rErr := util.ReadValuesFiles(f)
+		if rErr != nil {
+			return nil, nil, rErr
+		}
+		j, jErr := util.ValuesToJSON(v)
+		if jErr != nil {
+			return nil, nil, jErr
+		}
+		if j != nil {
+			locationValues[loc] = *j
+		}
+	}
+	return global, locationValues, nil
+}
+
+// lintPackages runs the checks of idpbuilder package lint before the cluster is created.
+func lintPackages(values *apiextensionsv1.JSON, templateData v1alpha1.BuildCustomizationSpec) error {
+	problems := lint.Lint(lint.Options{Packages: extraPackages, Values: values, TemplateData: templateData})
+	if len(problems) == 0 {
+		return nil
+	}
+	msgs := make([]string, len(problems))
+	for i := range problems {
+		msgs[i] = problems[i].String()
+	}
+	return fmt.Errorf("found %d problems in packages, use --skip-package-lint to ignore them:\n%s", len(problems), strings.Join(msgs, "\n"))
+}
+
+func pinPackageUrls(ctx context.Context, pkgUrls []string, gitAuth *util.GitAuthConfig) ([]string, error) {
+	l, err := util.ReadRefLockFile(lockfilePath)
+	if err != nil {
+		return nil, err
+	}
+	pinned, err := util.PinPackageUrls(ctx, pkgUrls, l, updateLockfile, gitAuth)
+	if err != nil {
+		return nil, err
+	}
+	return pinned, l.Write(lockfilePath)
+}
+
 func getPackageCustomFile(input string) (v1alpha1.PackageCustomization, error) {
 	// the format should be `<package-name>:<path-to-file>`
 	s := strings.Split(input, ":")
@@ -223,14 +448,13 @@ func getPackageCustomFile(input string) (v1alpha1.PackageCustomization, error) {
 		return v1alpha1.PackageCustomization{}, err
 	}
 
-	err = helpers.ValidateKubernetesYamlFile(paths[0])
+	err = packages.ValidateKubernetesYamlFile(paths[0])
 	if err != nil {
 		return v1alpha1.PackageCustomization{}, err
 	}
 
-	corePkgs := map[string]struct{}{v1alpha1.ArgoCDPackageName: {}, v1alpha1.GiteaPackageName: {}, v1alpha1.IngressNginxPackageName: {}}
 	name := s[0]
-	_, ok := corePkgs[name]
+	_, ok := corePackages[name]
 	if !ok {
 		return v1alpha1.PackageCustomization{}, fmt.Errorf("customization for %s not supported", name)
 	}
@@ -259,10 +483,22 @@ func printSuccessMsg() {
 	}
 
 	fmt.Print("\n\n########################### Finished Creating IDP Successfully! ############################\n\n\n")
+	if slices.Contains(disabledCorePackages, v1alpha1.ArgoCDPackageName) || gitOpsEngine == v1alpha1.GitOpsEngineFlux {
+		return
+	}
 	fmt.Printf("Can Access ArgoCD at %s\nUsername: admin\n", argoURL)
 	fmt.Print(`Password can be retrieved by running: idpbuilder get secrets -p argocd`, "\n")
 }
 
+// defaultGitHTTPImage returns the idpbuilder image matching the running version. githttp is served by the idpbuilder binary.
+func defaultGitHTTPImage() string {
+	v := version.Version()
+	if v == "unknown" {
+		v = "latest"
+	}
+	return fmt.Sprintf("%s:%s", gitHTTPImageRepository, v)
+}
+
 func behindProxy() bool {
 	// check if we are in codespaces: https://docs.github.com/en/codespaces/developing-in-a-codespace/default-environment-variables-for-your-codespace
 	_, ok := os.LookupEnv("CODESPACES")
@@ -21,6 +21,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/kind/pkg/cluster"
 	"slices"
+	"strconv"
 	"strings"
 )
 
@@ -212,26 +213,35 @@ func printAllocatedResources(ctx context.Context, k8sClient client.Client, nodeN
 }
 
 func findExternalHTTPSPort(cli client.Client, clusterName string) (int32, error) {
-	service := corev1.Service{}
-	namespacedName := types.NamespacedName{
-		Name:      "ingress-nginx-controller",
-		Namespace: "ingress-nginx",
-	}
-	err := cli.Get(context.TODO(), namespacedName, &service)
-	if err != nil {
-		return 0, fmt.Errorf("failed to get the ingress service on the cluster. %w", err)
-	}
-
 	localBuild := v1alpha1.Localbuild{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: clusterName,
 		},
 	}
-	err = cli.Get(context.TODO(), client.ObjectKeyFromObject(&localBuild), &localBuild)
+	err := cli.Get(context.TODO(), client.ObjectKeyFromObject(&localBuild), &localBuild)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get the localbuild on the cluster. %w", err)
 	}
 
+	// the gateway has a listener on the external port
+	if localBuild.Spec.BuildCustomization.IngressController == v1alpha1.IngressControllerGateway {
+		p, pErr := strconv.ParseInt(localBuild.Spec.BuildCustomization.Port, 10, 32)
+		if pErr != nil {
+			return 0, fmt.Errorf("parsing port of the localbuild. %w", pErr)
+		}
+		return int32(p), nil
+	}
+
+	service := corev1.Service{}
+	namespacedName := types.NamespacedName{
+		Name:      "ingress-nginx-controller",
+		Namespace: "ingress-nginx",
+	}
+	err = cli.Get(context.TODO(), namespacedName, &service)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get the ingress service on the cluster. %w", err)
+	}
+
 	var targetPort corev1.ServicePort
 	protocol := localBuild.Spec.BuildCustomization.Protocol + "-"
 	for _, port := range service.Spec.Ports {
@@ -22,6 +22,12 @@ var PackagesCmd = &cobra.Command{
 	SilenceUsage: true,
 }
 
+var conflicts bool
+
+func init() {
+	PackagesCmd.Flags().BoolVar(&conflicts, "conflicts", false, "List the Argo CD applications defined by more than one package, the installed package and the skipped packages.")
+}
+
 func getPackagesE(cmd *cobra.Command, args []string) error {
 	ctx, ctxCancel := context.WithCancel(cmd.Context())
 	defer ctxCancel()
@@ -36,9 +42,42 @@ func getPackagesE(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("getting kube client: %w", err)
 	}
 
+	if conflicts {
+		return printPackageConflicts(ctx, os.Stdout, kubeClient, outputFormat)
+	}
 	return printPackages(ctx, os.Stdout, kubeClient, outputFormat)
 }
 
+// printPackageConflicts prints the ArgoCD applications defined by more than one package.
+func printPackageConflicts(ctx context.Context, outWriter io.Writer, kubeClient client.Client, format string) error {
+	builds, err := getLocalBuild(ctx, kubeClient)
+	if err != nil {
+		return fmt.Errorf("getting localbuild: %w", err)
+	}
+	if len(builds.Items) == 0 {
+		return fmt.Errorf("no localbuild found")
+	}
+
+	conflicts := []types.PackageConflict{}
+	for _, c := range builds.Items[0].Status.PackageConflicts {
+		conflict := types.PackageConflict{AppName: c.AppName, Winner: c.Winner, Losers: []string{}}
+		for _, cand := range c.Candidates {
+			if cand.SourcePath == c.Winner {
+				conflict.Winner = fmt.Sprintf("%s (priority %d)", cand.SourcePath, cand.Priority)
+				continue
+			}
+			conflict.Losers = append(conflict.Losers, fmt.Sprintf("%s (priority %d)", cand.SourcePath, cand.Priority))
+		}
+		conflicts = append(conflicts, conflict)
+	}
+
+	conflictPrinter := printer.PackageConflictPrinter{
+		Conflicts: conflicts,
+		OutWriter: outWriter,
+	}
+	return conflictPrinter.PrintOutput(format)
+}
+
 // Print all the custom packages or based on package arguments passed using flag: -p
 func printPackages(ctx context.Context, outWriter io.Writer, kubeClient client.Client, format string) error {
 	packageList := []types.Package{}
@@ -78,19 +117,14 @@ func printPackages(ctx context.Context, outWriter io.Writer, kubeClient client.C
 		newPackage := types.Package{}
 		newPackage.Name = cp.Name
 		newPackage.Namespace = cp.Namespace
-		newPackage.ArgocdRepository = argocdBaseUrl + "/applications/" + cp.Spec.ArgoCD.Namespace + "/" + cp.Spec.ArgoCD.Name
-		// There is a GitRepositoryRefs when the project has been cloned to the internal git repository
-		if cp.Status.GitRepositoryRefs != nil {
-			newPackage.GitRepository = cp.Spec.InternalGitServeURL + "/" + v1alpha1.GiteaAdminUserName + "/" + idpbuilderNamespace + "-" + cp.Status.GitRepositoryRefs[0].Name
-		} else {
-			// Default branch reference
-			ref := "main"
-			if cp.Spec.RemoteRepository.Ref != "" {
-				ref = cp.Spec.RemoteRepository.Ref
-			}
-			newPackage.GitRepository = cp.Spec.RemoteRepository.Url + "/tree/" + ref + "/" + cp.Spec.RemoteRepository.Path
+		// packages synced by flux have no argocd application
+		if cp.Spec.Flux == nil {
+			newPackage.ArgocdRepository = argocdBaseUrl + "/applications/" + cp.Spec.ArgoCD.Namespace + "/" + cp.Spec.ArgoCD.Name
+		}
+		newPackage.GitRepository, err = packageGitRepository(ctx, kubeClient, cp)
+		if err != nil {
+			return err
 		}
-
 		newPackage.Status = strconv.FormatBool(cp.Status.Synced)
 
 		packageList = append(packageList, newPackage)
@@ -103,6 +137,26 @@ func printPackages(ctx context.Context, outWriter io.Writer, kubeClient client.C
 	return packagePrinter.PrintOutput(format)
 }
 
+// packageGitRepository returns the url of the repository the package is synced from.
+func packageGitRepository(ctx context.Context, kubeClient client.Client, cp v1alpha1.CustomPackage) (string, error) {
+	// There is a GitRepositoryRefs when the project has been cloned to the internal git repository
+	if cp.Status.GitRepositoryRefs != nil {
+		ref := cp.Status.GitRepositoryRefs[0]
+		repo := v1alpha1.GitRepository{}
+		if err := kubeClient.Get(ctx, client.ObjectKey{Name: ref.Name, Namespace: ref.Namespace}, &repo); err != nil {
+			return "", fmt.Errorf("getting git repository %s of package %s: %w", ref.Name, cp.Name, err)
+		}
+		return repo.Status.InternalGitRepositoryUrl, nil
+	}
+
+	// Default branch reference
+	ref := "main"
+	if cp.Spec.RemoteRepository.Ref != "" {
+		ref = cp.Spec.RemoteRepository.Ref
+	}
+	return cp.Spec.RemoteRepository.Url + "/tree/" + ref + "/" + cp.Spec.RemoteRepository.Path, nil
+}
+
 func getPackageByName(ctx context.Context, kubeClient client.Client, ns, name string) (v1alpha1.CustomPackage, error) {
 	p := v1alpha1.CustomPackage{}
 	return p, kubeClient.Get(ctx, client.ObjectKey{Name: name, Namespace: ns}, &p)
@@ -0,0 +1,76 @@
+package get
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestPrintPackageConflicts(t *testing.T) {
+	ctx := context.Background()
+	fClient := new(fakeKubeClient)
+	fClient.On("List", ctx, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		list := args.Get(1).(*v1alpha1.LocalbuildList)
+		list.Items = []v1alpha1.Localbuild{{
+			Status: v1alpha1.LocalbuildStatus{
+				PackageConflicts: []v1alpha1.PackageConflict{
+					{
+						AppName: "app",
+						Winner:  "/pkgs/overlay",
+						Candidates: []v1alpha1.PackageCandidate{
+							{SourcePath: "/pkgs/overlay", Priority: 10},
+							{SourcePath: "/pkgs/base", Priority: 0},
+						},
+					},
+					{
+						AppName:    "tied",
+						Candidates: []v1alpha1.PackageCandidate{{SourcePath: "/a", Priority: 1}, {SourcePath: "/b", Priority: 1}},
+					},
+				},
+			},
+		}}
+	}).Return(nil)
+
+	var b bytes.Buffer
+	assert.Nil(t, printPackageConflicts(ctx, &b, fClient, "yaml"))
+	assert.YAMLEq(t, `
+- appName: app
+  winner: /pkgs/overlay (priority 10)
+  losers: ["/pkgs/base (priority 0)"]
+- appName: tied
+  losers: ["/a (priority 1)", "/b (priority 1)"]
+`, b.String())
+
+	b.Reset()
+	assert.Nil(t, printPackageConflicts(ctx, &b, fClient, "table"))
+	assert.Contains(t, b.String(), "tied priorities")
+}
+
+func TestPackageGitRepository(t *testing.T) {
+	ctx := context.Background()
+	fClient := new(fakeKubeClient)
+	fClient.On("Get", ctx, client.ObjectKey{Name: "app-repo", Namespace: "idpbuilder-localdev"}, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		repo := args.Get(2).(*v1alpha1.GitRepository)
+		repo.Status.InternalGitRepositoryUrl = "http://githttp.githttp.svc.cluster.local/idpbuilder-localdev-app-repo.git"
+	}).Return(nil)
+
+	cp := v1alpha1.CustomPackage{Status: v1alpha1.CustomPackageStatus{
+		GitRepositoryRefs: []v1alpha1.ObjectRef{{Name: "app-repo", Namespace: "idpbuilder-localdev"}},
+	}}
+	url, err := packageGitRepository(ctx, fClient, cp)
+	assert.NoError(t, err)
+	assert.Equal(t, "http://githttp.githttp.svc.cluster.local/idpbuilder-localdev-app-repo.git", url)
+	fClient.AssertExpectations(t)
+
+	cp = v1alpha1.CustomPackage{Spec: v1alpha1.CustomPackageSpec{
+		RemoteRepository: v1alpha1.RemoteRepositorySpec{Url: "https://github.com/org/repo", Path: "packages/app", Ref: "v1.0.0"},
+	}}
+	url, err = packageGitRepository(ctx, fClient, cp)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://github.com/org/repo/tree/v1.0.0/packages/app", url)
+}
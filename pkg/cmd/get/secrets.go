@@ -21,6 +21,7 @@ const (
 	argoCDAdminUsername          = "admin"
 	argoCDInitialAdminSecretName = "argocd-initial-admin-secret"
 	giteaAdminSecretName         = "gitea-credential"
+	gitHTTPAdminSecretName       = "githttp-credential"
 )
 
 var SecretsCmd = &cobra.Command{
@@ -34,8 +35,9 @@ var SecretsCmd = &cobra.Command{
 // well known secrets that are part of the core packages
 var (
 	corePkgSecrets = map[string][]string{
-		"argocd": []string{argoCDInitialAdminSecretName},
-		"gitea":  []string{giteaAdminSecretName},
+		"argocd":  []string{argoCDInitialAdminSecretName},
+		"gitea":   []string{giteaAdminSecretName},
+		"githttp": []string{gitHTTPAdminSecretName},
 	}
 )
 
@@ -12,6 +12,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/selection"
@@ -57,11 +58,12 @@ func TestPrintPackageSecrets(t *testing.T) {
 		},
 		{
 			err:               nil,
-			packages:          []string{"argocd", "gitea", "abc"},
+			packages:          []string{"argocd", "gitea", "githttp", "abc"},
 			listLabelSelector: []labels.Selector{selector("abc")},
 			getKeys: []client.ObjectKey{
 				{Name: argoCDInitialAdminSecretName, Namespace: "argocd"},
 				{Name: giteaAdminSecretName, Namespace: "gitea"},
+				{Name: gitHTTPAdminSecretName, Namespace: "githttp"},
 			},
 		},
 		{
@@ -116,6 +118,7 @@ func TestPrintAllPackageSecrets(t *testing.T) {
 			getKeys: []client.ObjectKey{
 				{Name: argoCDInitialAdminSecretName, Namespace: "argocd"},
 				{Name: giteaAdminSecretName, Namespace: "gitea"},
+				{Name: gitHTTPAdminSecretName, Namespace: "githttp"},
 			},
 		},
 	}
@@ -197,6 +200,9 @@ func TestOutput(t *testing.T) {
 		sec := secretDataToSecret(corePkgData[giteaAdminSecretName])
 		*arg = sec
 	}).Return(nil)
+	// githttp is not installed when gitea is the git server
+	fClient.On("Get", ctx, client.ObjectKey{Name: gitHTTPAdminSecretName, Namespace: "githttp"}, mock.Anything, mock.Anything).
+		Return(errors.NewNotFound(v1.Resource("secrets"), gitHTTPAdminSecretName))
 
 	fClient.On("List", ctx, mock.Anything, []client.ListOption{&opts}).Run(func(args mock.Arguments) {
 		arg := args.Get(1).(*v1.SecretList)
@@ -0,0 +1,52 @@
+package githttp
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/cnoe-io/idpbuilder/pkg/cmd/helpers"
+	"github.com/cnoe-io/idpbuilder/pkg/githttp"
+	"github.com/spf13/cobra"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+var (
+	// Flags
+	dataDir string
+	address string
+)
+
+// GitHTTPCmd runs the githttp git server. It is the entrypoint of the container deployed by the githttp core package.
+var GitHTTPCmd = &cobra.Command{
+	Use:          "githttp",
+	Short:        "Serve git repositories with the git smart HTTP protocol",
+	Long:         fmt.Sprintf("Serve git repositories with the git smart HTTP protocol. Pushes and the API require basic auth with the credentials in the %s and %s environment variables.", githttp.UsernameEnv, githttp.PasswordEnv),
+	Hidden:       true,
+	PreRunE:      preGitHTTPE,
+	RunE:         gitHTTPE,
+	SilenceUsage: true,
+}
+
+func init() {
+	GitHTTPCmd.Flags().StringVar(&dataDir, "data-dir", "/data", "Directory the repositories are stored in.")
+	GitHTTPCmd.Flags().StringVar(&address, "address", ":8080", "Address to listen on.")
+}
+
+func preGitHTTPE(cmd *cobra.Command, args []string) error {
+	return helpers.SetLogger()
+}
+
+func gitHTTPE(cmd *cobra.Command, args []string) error {
+	username := os.Getenv(githttp.UsernameEnv)
+	password := os.Getenv(githttp.PasswordEnv)
+	if username == "" || password == "" {
+		return fmt.Errorf("%s and %s must be set", githttp.UsernameEnv, githttp.PasswordEnv)
+	}
+
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return fmt.Errorf("creating data directory: %w", err)
+	}
+
+	ctrl.Log.Info("serving git repositories", "address", address, "dir", dataDir)
+	return githttp.ListenAndServe(cmd.Context(), address, githttp.NewServer(dataDir, username, password))
+}
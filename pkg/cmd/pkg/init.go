@@ -0,0 +1,51 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cnoe-io/idpbuilder/pkg/scaffold"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Flags
+	initType      string
+	initNamespace string
+	initIngress   bool
+	initOutputDir string
+)
+
+var InitCmd = &cobra.Command{
+	Use:   "init <name>",
+	Short: "Generate a new custom package",
+	Long: "Generate a new custom package with an Argo CD Application or ApplicationSet and the directory " +
+		"its cnoe:// source refers to.",
+	Args:         cobra.ExactArgs(1),
+	RunE:         initE,
+	PreRunE:      prePackageE,
+	SilenceUsage: true,
+}
+
+func init() {
+	InitCmd.Flags().StringVarP(&initType, "type", "t", scaffold.TypeManifests,
+		fmt.Sprintf("Type of the package. One of: %s.", strings.Join(scaffold.Types, ", ")))
+	InitCmd.Flags().StringVarP(&initNamespace, "namespace", "n", "",
+		"Namespace to install the package in. Defaults to the package name, or to the application name for appset packages.")
+	InitCmd.Flags().BoolVar(&initIngress, "ingress", false, "Add an ingress under the host of the cluster, e.g. <name>.cnoe.localtest.me.")
+	InitCmd.Flags().StringVarP(&initOutputDir, "output-dir", "o", ".", "Directory to create the package directory in.")
+}
+
+func initE(cmd *cobra.Command, args []string) error {
+	dir, err := scaffold.Write(initOutputDir, scaffold.Options{
+		Name:      args[0],
+		Type:      initType,
+		Namespace: initNamespace,
+		Ingress:   initIngress,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Created package %s\nInstall it with: idpbuilder create -p %s\n", dir, dir)
+	return nil
+}
@@ -0,0 +1,79 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
+	"github.com/cnoe-io/idpbuilder/globals"
+	"github.com/cnoe-io/idpbuilder/pkg/lint"
+	"github.com/cnoe-io/idpbuilder/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Flags
+	lintValuesFiles []string
+	lintHost        string
+	lintPort        string
+	lintProtocol    string
+	lintPathRouting bool
+	lintEngine      string
+)
+
+var LintCmd = &cobra.Command{
+	Use:   "lint <package>...",
+	Short: "Check custom packages for mistakes without creating a cluster",
+	Long: "Check local custom packages for mistakes without creating a cluster. " +
+		"Packages are given like they are passed to idpbuilder create with -p. " +
+		"Remote packages are only checked for a valid package string.",
+	Args:         cobra.MinimumNArgs(1),
+	RunE:         lintE,
+	PreRunE:      prePackageE,
+	SilenceUsage: true,
+}
+
+func init() {
+	LintCmd.Flags().StringSliceVar(&lintValuesFiles, "values", []string{}, "Paths to values files for all packages. Values of later files take precedence.")
+	LintCmd.Flags().StringVar(&lintHost, "host", globals.DefaultHostName, "Host name templated package files are rendered with.")
+	LintCmd.Flags().StringVar(&lintPort, "port", "8443", "Port number templated package files are rendered with.")
+	LintCmd.Flags().StringVar(&lintProtocol, "protocol", "https", "Protocol templated package files are rendered with.")
+	LintCmd.Flags().BoolVar(&lintPathRouting, "use-path-routing", false, "Render templated package files for path routing.")
+	LintCmd.Flags().StringVar(&lintEngine, "gitops-engine", v1alpha1.GitOpsEngineArgoCD, "GitOps engine the packages are synced with. argocd or flux.")
+}
+
+func lintE(cmd *cobra.Command, args []string) error {
+	engine := strings.ToLower(lintEngine)
+	if engine != v1alpha1.GitOpsEngineArgoCD && engine != v1alpha1.GitOpsEngineFlux {
+		return fmt.Errorf("invalid gitops engine %s. must be %s or %s", lintEngine, v1alpha1.GitOpsEngineArgoCD, v1alpha1.GitOpsEngineFlux)
+	}
+
+	values, err := util.ReadValuesFiles(lintValuesFiles...)
+	if err != nil {
+		return err
+	}
+	j, err := util.ValuesToJSON(values)
+	if err != nil {
+		return err
+	}
+
+	problems := lint.Lint(lint.Options{
+		Packages: args,
+		Values:   j,
+		TemplateData: v1alpha1.BuildCustomizationSpec{
+			Protocol:       strings.ToLower(lintProtocol),
+			Host:           strings.ToLower(lintHost),
+			IngressHost:    strings.ToLower(lintHost),
+			Port:           lintPort,
+			UsePathRouting: lintPathRouting,
+			GitOpsEngine:   engine,
+		},
+	})
+	for i := range problems {
+		fmt.Fprintln(cmd.OutOrStdout(), problems[i].String())
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("found %d problems in packages", len(problems))
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package pkg
+
+import (
+	"fmt"
+
+	"github.com/cnoe-io/idpbuilder/pkg/cmd/helpers"
+	"github.com/spf13/cobra"
+)
+
+var PackageCmd = &cobra.Command{
+	Use:   "package",
+	Short: "Work with custom packages",
+	Long:  ``,
+	RunE:  packageE,
+}
+
+func init() {
+	PackageCmd.AddCommand(InitCmd)
+	PackageCmd.AddCommand(LintCmd)
+}
+
+func packageE(cmd *cobra.Command, args []string) error {
+	return fmt.Errorf("specify subcommand")
+}
+
+func prePackageE(cmd *cobra.Command, args []string) error {
+	return helpers.SetLogger()
+}
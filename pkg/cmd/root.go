@@ -5,10 +5,13 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/cnoe-io/idpbuilder/pkg/cmd/cache"
 	"github.com/cnoe-io/idpbuilder/pkg/cmd/create"
 	"github.com/cnoe-io/idpbuilder/pkg/cmd/delete"
 	"github.com/cnoe-io/idpbuilder/pkg/cmd/get"
+	"github.com/cnoe-io/idpbuilder/pkg/cmd/githttp"
 	"github.com/cnoe-io/idpbuilder/pkg/cmd/helpers"
+	"github.com/cnoe-io/idpbuilder/pkg/cmd/pkg"
 	"github.com/cnoe-io/idpbuilder/pkg/cmd/version"
 	"github.com/spf13/cobra"
 )
@@ -26,6 +29,9 @@ func init() {
 	rootCmd.AddCommand(get.GetCmd)
 	rootCmd.AddCommand(delete.DeleteCmd)
 	rootCmd.AddCommand(version.VersionCmd)
+	rootCmd.AddCommand(cache.CacheCmd)
+	rootCmd.AddCommand(pkg.PackageCmd)
+	rootCmd.AddCommand(githttp.GitHTTPCmd)
 }
 
 func Execute(ctx context.Context) {
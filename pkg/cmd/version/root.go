@@ -43,6 +43,11 @@ type idpbuilderInfo struct {
 	BuildDate         string `json:"buildDate"`
 }
 
+// Version returns the idpbuilder version set at build time.
+func Version() string {
+	return idpbuilderVersion
+}
+
 func version(cmd *cobra.Command, args []string) error {
 	switch outputFormat {
 	case "wide":
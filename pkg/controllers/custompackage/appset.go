@@ -0,0 +1,27 @@
+package custompackage
+
+import (
+	"path/filepath"
+
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
+	"github.com/cnoe-io/idpbuilder/pkg/packages"
+)
+
+// setRemoteGitGenerator points a git generator with a cnoe:// url to the remote repository directory.
+func setRemoteGitGenerator(resource *v1alpha1.CustomPackage, g map[string]interface{}) {
+	repoURL, _ := g["repoURL"].(string)
+	if !packages.IsCNOEScheme(repoURL) {
+		return
+	}
+	remoteURL, dir, revision := remoteSource(resource, repoURL)
+	g["repoURL"], g["revision"] = remoteURL, revision
+	for _, key := range []string{"directories", "files"} {
+		items, _ := g[key].([]interface{})
+		for i := range items {
+			if item, ok := items[i].(map[string]interface{}); ok {
+				p, _ := item["path"].(string)
+				item["path"] = filepath.Join(dir, p)
+			}
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package custompackage
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetRemoteGitGenerator(t *testing.T) {
+	resource := &v1alpha1.CustomPackage{
+		Spec: v1alpha1.CustomPackageSpec{
+			RemoteRepository: v1alpha1.RemoteRepositorySpec{Url: "https://github.com/org/repo", Path: "packages/app", Ref: "main"},
+		},
+	}
+	g := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"repoURL":"cnoe://apps","files":[{"path":"config.json"}]}`), &g))
+	setRemoteGitGenerator(resource, g)
+	assert.Equal(t, "https://github.com/org/repo", g["repoURL"])
+	assert.Equal(t, "main", g["revision"])
+	assert.Equal(t, "packages/app/apps/config.json", g["files"].([]interface{})[0].(map[string]interface{})["path"])
+}
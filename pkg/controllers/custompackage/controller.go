@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
@@ -13,7 +14,9 @@ import (
 	argov1alpha1 "github.com/cnoe-io/argocd-api/api/argo/application/v1alpha1"
 	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
 	"github.com/cnoe-io/idpbuilder/pkg/k8s"
+	"github.com/cnoe-io/idpbuilder/pkg/packages"
 	"github.com/cnoe-io/idpbuilder/pkg/util"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -22,6 +25,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/yaml"
 )
 
 const (
@@ -35,6 +39,7 @@ type Reconciler struct {
 	Config   v1alpha1.BuildCustomizationSpec
 	TempDir  string
 	RepoMap  *util.RepoMap
+	GitAuth  *util.GitAuthConfig
 }
 
 func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -166,7 +171,7 @@ func (r *Reconciler) shouldReconcile(ctx context.Context, resource *v1alpha1.Cus
 		}
 
 		// Skip if different app name
-		if pkg.Spec.ArgoCD.Name != resource.Spec.ArgoCD.Name {
+		if pkg.Spec.ApplicationName() != resource.Spec.ApplicationName() {
 			continue
 		}
 
@@ -182,7 +187,7 @@ func (r *Reconciler) shouldReconcile(ctx context.Context, resource *v1alpha1.Cus
 			thisSource := resource.ObjectMeta.Annotations[v1alpha1.PackageSourcePathAnnotation]
 			otherSource := pkg.ObjectMeta.Annotations[v1alpha1.PackageSourcePathAnnotation]
 			logger.Info("Yielding to higher priority package - skipping all reconciliation",
-				"appName", resource.Spec.ArgoCD.Name,
+				"appName", resource.Spec.ApplicationName(),
 				"yieldingPackage", thisSource,
 				"yieldingPriority", thisPriority,
 				"activePackage", otherSource,
@@ -229,7 +234,7 @@ func (r *Reconciler) reconcileCustomPackage(ctx context.Context, resource *v1alp
 		// Mark as not synced and don't update resources, and don't requeue
 		logger.Info("package superseded by higher priority, skipping reconciliation",
 			"name", resource.Name,
-			"appName", resource.Spec.ArgoCD.Name,
+			"appName", resource.Spec.ApplicationName(),
 			"sourcePath", resource.ObjectMeta.Annotations[v1alpha1.PackageSourcePathAnnotation])
 		resource.Status.Synced = false
 		return ctrl.Result{}, nil
@@ -237,26 +242,43 @@ func (r *Reconciler) reconcileCustomPackage(ctx context.Context, resource *v1alp
 
 	logger.V(1).Info("proceeding with reconciliation as highest priority package",
 		"name", resource.Name,
-		"appName", resource.Spec.ArgoCD.Name)
+		"appName", resource.Spec.ApplicationName())
 
-	b, err := r.getArgoCDAppFile(ctx, resource)
+	blocked, err := r.blockingDependencies(ctx, resource)
+	resource.Status.BlockedBy = blocked
 	if err != nil {
-		return ctrl.Result{}, fmt.Errorf("reading file %s: %w", resource.Spec.ArgoCD.ApplicationFile, err)
+		resource.Status.Synced = false
+		return ctrl.Result{}, err
+	}
+	if len(blocked) > 0 {
+		// hold back the application until its dependencies are healthy
+		logger.Info("waiting for dependencies to become healthy",
+			"name", resource.Name,
+			"appName", resource.Spec.ApplicationName(),
+			"blockedBy", blocked)
+		resource.Status.Synced = false
+		return ctrl.Result{RequeueAfter: requeueTime}, nil
 	}
 
-	objs, err := k8s.ConvertYamlToObjects(r.Scheme, b)
+	if resource.Spec.Flux != nil {
+		return r.reconcileFluxPackage(ctx, resource)
+	}
+
+	b, err := r.getArgoCDAppFile(ctx, resource)
 	if err != nil {
-		return ctrl.Result{}, fmt.Errorf("converting yaml to object %w", err)
+		return ctrl.Result{}, fmt.Errorf("reading file %s: %w", resource.Spec.PackageFile(), err)
 	}
-	if len(objs) == 0 {
-		return ctrl.Result{}, fmt.Errorf("file contained 0 kubernetes objects %s", resource.Spec.ArgoCD.ApplicationFile)
+
+	obj, err := findArgoCDObject(r.Scheme, b, resource.Spec.ArgoCD.Type, resource.Spec.ApplicationName())
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("reading %s: %w", resource.Spec.PackageFile(), err)
 	}
 
 	switch resource.Spec.ArgoCD.Type {
 	case argocdapplication.ApplicationKind:
-		app, ok := objs[0].(*argov1alpha1.Application)
+		app, ok := obj.(*argov1alpha1.Application)
 		if !ok {
-			return ctrl.Result{}, fmt.Errorf("object is not an ArgoCD application %s", resource.Spec.ArgoCD.ApplicationFile)
+			return ctrl.Result{}, fmt.Errorf("object is not an ArgoCD application %s", resource.Spec.PackageFile())
 		}
 		util.SetPackageLabels(app)
 
@@ -290,9 +312,9 @@ func (r *Reconciler) reconcileCustomPackage(ctx context.Context, resource *v1alp
 
 	case argocdapplication.ApplicationSetKind:
 		// application set embeds application spec. extract it then handle git generator repoURLs.
-		appSet, ok := objs[0].(*argov1alpha1.ApplicationSet)
+		appSet, ok := obj.(*argov1alpha1.ApplicationSet)
 		if !ok {
-			return ctrl.Result{}, fmt.Errorf("object is not an ArgoCD application set %s", resource.Spec.ArgoCD.ApplicationFile)
+			return ctrl.Result{}, fmt.Errorf("object is not an ArgoCD application set %s", resource.Spec.PackageFile())
 		}
 
 		util.SetPackageLabels(appSet)
@@ -326,7 +348,7 @@ func (r *Reconciler) reconcileCustomPackage(ctx context.Context, resource *v1alp
 		return res, nil
 
 	default:
-		return ctrl.Result{}, fmt.Errorf("file is not a supported argocd kind %s", resource.Spec.ArgoCD.ApplicationFile)
+		return ctrl.Result{}, fmt.Errorf("file is not a supported argocd kind %s", resource.Spec.PackageFile())
 	}
 }
 
@@ -334,6 +356,13 @@ func (r *Reconciler) reconcileArgoCDApp(ctx context.Context, resource *v1alpha1.
 	appSourcesSynced := true
 	repoRefs := make([]v1alpha1.ObjectRef, 0, 1)
 	if app.Spec.HasMultipleSources() {
+		if err := validateValueFiles(resource, app.Spec.Sources); err != nil {
+			return ctrl.Result{}, fmt.Errorf("validating value files of %s: %w", app.Name, err)
+		}
+		if !isReplicated(resource) {
+			setRemoteValueFiles(resource, app.Spec.Sources)
+		}
+
 		notSyncedRepos := 0
 		for j := range app.Spec.Sources {
 			s := &app.Spec.Sources[j]
@@ -341,6 +370,10 @@ func (r *Reconciler) reconcileArgoCDApp(ctx context.Context, resource *v1alpha1.
 			if sErr != nil {
 				return res, sErr
 			}
+			if !isReplicated(resource) {
+				setRemoteSource(resource, s)
+				continue
+			}
 
 			res, repo, sErr := r.reconcileArgoCDSource(ctx, resource, s.RepoURL, app.Name)
 			if sErr != nil {
@@ -360,6 +393,12 @@ func (r *Reconciler) reconcileArgoCDApp(ctx context.Context, resource *v1alpha1.
 			}
 		}
 		appSourcesSynced = notSyncedRepos == 0
+	} else if !isReplicated(resource) {
+		res, sErr := r.reconcileHelmValueObject(ctx, app.Spec.Source, resource, app.Name)
+		if sErr != nil {
+			return res, sErr
+		}
+		setRemoteSource(resource, app.Spec.Source)
 	} else {
 		s := app.Spec.Source
 		res, sErr := r.reconcileHelmValueObject(ctx, s, resource, app.Name)
@@ -394,37 +433,51 @@ func (r *Reconciler) reconcileArgoCDApp(ctx context.Context, resource *v1alpha1.
 
 func (r *Reconciler) reconcileArgoCDAppSet(ctx context.Context, resource *v1alpha1.CustomPackage, appSet *argov1alpha1.ApplicationSet) (ctrl.Result, error) {
 	notSyncedRepos := 0
-	for i := range appSet.Spec.Generators {
-		g := appSet.Spec.Generators[i]
-		if g.Git != nil {
-			res, repo, gErr := r.reconcileArgoCDSource(ctx, resource, g.Git.RepoURL, appSet.GetName())
-			if gErr != nil {
-				return res, fmt.Errorf("reconciling git generator URL %s, %s: %w", g.Git.RepoURL, resource.Spec.ArgoCD.ApplicationFile, gErr)
+	repoURLs := map[string]string{}
+	var res ctrl.Result
+	w := &packages.ApplicationSetRewriter{
+		URL: func(repoURL string) (string, error) {
+			if u, ok := repoURLs[repoURL]; ok {
+				return u, nil
 			}
-			if repo != nil {
-				g.Git.RepoURL = repo.Status.InternalGitRepositoryUrl
-				if repo.Status.InternalGitRepositoryUrl == "" {
-					notSyncedRepos += 1
+			u := repoURL
+			if !isReplicated(resource) {
+				u, _, _ = remoteSource(resource, repoURL)
+			} else {
+				sRes, repo, sErr := r.reconcileArgoCDSource(ctx, resource, repoURL, appSet.GetName())
+				if sErr != nil {
+					res = sRes
+					return "", fmt.Errorf("reconciling generator URL %s, %s: %w", repoURL, resource.Spec.PackageFile(), sErr)
 				}
-			}
-		}
-		if g.Matrix != nil {
-			for j := range g.Matrix.Generators {
-				nestedGenerator := g.Matrix.Generators[j]
-				if nestedGenerator.Git != nil {
-					res, repo, gErr := r.reconcileArgoCDSource(ctx, resource, nestedGenerator.Git.RepoURL, appSet.GetName())
-					if gErr != nil {
-						return res, fmt.Errorf("reconciling git generator URL %s, %s: %w", nestedGenerator.Git.RepoURL, resource.Spec.ArgoCD.ApplicationFile, gErr)
-					}
-					if repo != nil {
-						nestedGenerator.Git.RepoURL = repo.Status.InternalGitRepositoryUrl
-						if repo.Status.InternalGitRepositoryUrl == "" {
-							notSyncedRepos += 1
-						}
+				if repo != nil {
+					u = repo.Status.InternalGitRepositoryUrl
+					if u == "" {
+						notSyncedRepos += 1
 					}
 				}
 			}
+			repoURLs[repoURL] = u
+			return u, nil
+		},
+	}
+	if !isReplicated(resource) {
+		w.GitGenerator = func(g map[string]interface{}) {
+			setRemoteGitGenerator(resource, g)
+		}
+	}
+
+	generators, err := w.RewriteGenerators(appSet.Spec.Generators)
+	if err != nil {
+		return res, err
+	}
+	appSet.Spec.Generators = generators
+
+	if appSet.Spec.TemplatePatch != nil {
+		patch, pErr := w.RewriteText(*appSet.Spec.TemplatePatch)
+		if pErr != nil {
+			return res, pErr
 		}
+		appSet.Spec.TemplatePatch = &patch
 	}
 
 	gitGeneratorsSynced := notSyncedRepos == 0
@@ -433,9 +486,9 @@ func (r *Reconciler) reconcileArgoCDAppSet(ctx context.Context, resource *v1alph
 	}
 	app.Spec = appSet.Spec.Template.Spec
 
-	_, err := r.reconcileArgoCDApp(ctx, resource, &app)
+	_, err = r.reconcileArgoCDApp(ctx, resource, &app)
 	if err != nil {
-		return ctrl.Result{}, fmt.Errorf("reconciling application set %s %w", resource.Spec.ArgoCD.ApplicationFile, err)
+		return ctrl.Result{}, fmt.Errorf("reconciling application set %s %w", resource.Spec.PackageFile(), err)
 	}
 
 	resource.Status.Synced = resource.Status.Synced && gitGeneratorsSynced
@@ -449,7 +502,10 @@ func (r *Reconciler) reconcileArgoCDAppSet(ctx context.Context, resource *v1alph
 
 // create a gitrepository custom resource, then let the git repository controller take care of the rest
 func (r *Reconciler) reconcileArgoCDSource(ctx context.Context, resource *v1alpha1.CustomPackage, repoUrl, appName string) (ctrl.Result, *v1alpha1.GitRepository, error) {
-	if isCNOEScheme(repoUrl) {
+	if packages.IsCNOEScheme(repoUrl) {
+		if resource.Spec.Archive.Url != "" {
+			return r.reconcileArgoCDSourceFromArchive(ctx, resource, appName, repoUrl)
+		}
 		if resource.Spec.RemoteRepository.Url == "" {
 			return r.reconcileArgoCDSourceFromLocal(ctx, resource, appName, repoUrl)
 		}
@@ -459,14 +515,39 @@ func (r *Reconciler) reconcileArgoCDSource(ctx context.Context, resource *v1alph
 }
 
 func (r *Reconciler) reconcileArgoCDSourceFromRemote(ctx context.Context, resource *v1alpha1.CustomPackage, appName, repoURL string) (ctrl.Result, *v1alpha1.GitRepository, error) {
-	logger := log.FromContext(ctx)
 	relativePath := strings.TrimPrefix(repoURL, v1alpha1.CNOEURIScheme)
 	// no guarantee that this path exists
 	dirPath := filepath.Join(resource.Spec.RemoteRepository.Path, relativePath)
 
+	return r.reconcileGitRepository(ctx, resource, remoteRepoName(appName, dirPath, resource.Spec.RemoteRepository), v1alpha1.GitRepositorySource{
+		Type:             v1alpha1.SourceTypeRemote,
+		RemoteRepository: resource.Spec.RemoteRepository,
+		Path:             dirPath,
+	})
+}
+
+func (r *Reconciler) reconcileArgoCDSourceFromArchive(ctx context.Context, resource *v1alpha1.CustomPackage, appName, repoURL string) (ctrl.Result, *v1alpha1.GitRepository, error) {
+	relativePath := strings.TrimPrefix(repoURL, v1alpha1.CNOEURIScheme)
+	// the application file path is relative to the archive root
+	dirPath := filepath.Join(filepath.Dir(resource.Spec.PackageFile()), relativePath)
+	if !filepath.IsLocal(dirPath) {
+		return ctrl.Result{}, nil, fmt.Errorf("path %s must be within archive %s", repoURL, resource.Spec.Archive.Url)
+	}
+
+	return r.reconcileGitRepository(ctx, resource, archiveRepoName(appName, dirPath, resource.Spec.Archive), v1alpha1.GitRepositorySource{
+		Type:    v1alpha1.SourceTypeArchive,
+		Archive: resource.Spec.Archive,
+		Path:    dirPath,
+	})
+}
+
+// reconcileGitRepository creates or updates the GitRepository replicating source unless it is owned by a package with higher priority.
+func (r *Reconciler) reconcileGitRepository(ctx context.Context, resource *v1alpha1.CustomPackage, name string, source v1alpha1.GitRepositorySource) (ctrl.Result, *v1alpha1.GitRepository, error) {
+	logger := log.FromContext(ctx)
+
 	repo := &v1alpha1.GitRepository{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      remoteRepoName(appName, dirPath, resource.Spec.RemoteRepository),
+			Name:      name,
 			Namespace: resource.Namespace,
 		},
 	}
@@ -509,18 +590,10 @@ func (r *Reconciler) reconcileArgoCDSourceFromRemote(ctx context.Context, resour
 		util.SetCLIStartTimeAnnotationValue(repo.ObjectMeta.Annotations, cliStartTime)
 
 		repo.Spec = v1alpha1.GitRepositorySpec{
-			Source: v1alpha1.GitRepositorySource{
-				Type:             v1alpha1.SourceTypeRemote,
-				RemoteRepository: resource.Spec.RemoteRepository,
-				Path:             dirPath,
-			},
-			Provider: v1alpha1.Provider{
-				Name:             v1alpha1.GitProviderGitea,
-				GitURL:           resource.Spec.GitServerURL,
-				InternalGitURL:   resource.Spec.InternalGitServeURL,
-				OrganizationName: v1alpha1.GiteaAdminUserName,
-			},
+			Source:    source,
+			Provider:  gitServerProvider(resource),
 			SecretRef: resource.Spec.GitServerAuthSecretRef,
+			Values:    resource.Spec.Values,
 		}
 
 		return nil
@@ -536,9 +609,9 @@ func (r *Reconciler) reconcileArgoCDSourceFromRemote(ctx context.Context, resour
 func (r *Reconciler) reconcileArgoCDSourceFromLocal(ctx context.Context, resource *v1alpha1.CustomPackage, appName, repoURL string) (ctrl.Result, *v1alpha1.GitRepository, error) {
 	logger := log.FromContext(ctx)
 
-	absPath, err := getCNOEAbsPath(resource.Spec.ArgoCD.ApplicationFile, repoURL)
+	absPath, err := packages.GetCNOEAbsPath(resource.Spec.PackageFile(), repoURL)
 	if err != nil {
-		logger.Error(err, "processing argocd app source", "dir", resource.Spec.ArgoCD.ApplicationFile, "repoURL", repoURL)
+		logger.Error(err, "processing argocd app source", "dir", resource.Spec.PackageFile(), "repoURL", repoURL)
 		return ctrl.Result{}, nil, err
 	}
 
@@ -590,13 +663,9 @@ func (r *Reconciler) reconcileArgoCDSourceFromLocal(ctx context.Context, resourc
 				Type: v1alpha1.SourceTypeLocal,
 				Path: absPath,
 			},
-			Provider: v1alpha1.Provider{
-				Name:             v1alpha1.GitProviderGitea,
-				GitURL:           resource.Spec.GitServerURL,
-				InternalGitURL:   resource.Spec.InternalGitServeURL,
-				OrganizationName: v1alpha1.GiteaAdminUserName,
-			},
+			Provider:  gitServerProvider(resource),
 			SecretRef: resource.Spec.GitServerAuthSecretRef,
+			Values:    resource.Spec.Values,
 		}
 
 		return nil
@@ -610,6 +679,25 @@ func (r *Reconciler) reconcileArgoCDSourceFromLocal(ctx context.Context, resourc
 	return ctrl.Result{}, repo, nil
 }
 
+// gitServerProvider returns the git server the repositories of the package are pushed to.
+func gitServerProvider(resource *v1alpha1.CustomPackage) v1alpha1.Provider {
+	p := v1alpha1.Provider{
+		Name:             resource.Spec.GitServerProvider,
+		GitURL:           resource.Spec.GitServerURL,
+		InternalGitURL:   resource.Spec.InternalGitServeURL,
+		OrganizationName: resource.Spec.GitServerOrganization,
+		Repository:       resource.Spec.GitServerRepository,
+	}
+	if p.Name == "" {
+		p.Name = v1alpha1.GitProviderGitea
+	}
+	// repositories of token based providers are created in the user's namespace without an organization.
+	if p.OrganizationName == "" && p.Name == v1alpha1.GitProviderGitea {
+		p.OrganizationName = v1alpha1.GiteaAdminUserName
+	}
+	return p
+}
+
 func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&v1alpha1.CustomPackage{}).
@@ -617,26 +705,66 @@ func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
 }
 
 func (r *Reconciler) getArgoCDAppFile(ctx context.Context, resource *v1alpha1.CustomPackage) ([]byte, error) {
-	filePath := resource.Spec.ArgoCD.ApplicationFile
+	filePath := resource.Spec.PackageFile()
+
+	if resource.Spec.Archive.Url != "" {
+		return r.readArchiveFile(ctx, resource.Spec.Archive, filePath)
+	}
 
 	if resource.Spec.RemoteRepository.Url == "" {
 		return os.ReadFile(filePath)
 	}
 
-	cloneDir := util.RepoDir(resource.Spec.RemoteRepository.Url, r.TempDir)
-	st := r.RepoMap.LoadOrStore(resource.Spec.RemoteRepository.Url, cloneDir)
-	st.MU.Lock()
-	wt, _, err := util.CloneRemoteRepoToDir(ctx, resource.Spec.RemoteRepository, 1, false, cloneDir, "")
-	defer st.MU.Unlock()
+	auth, err := r.GitAuth.AuthMethod(resource.Spec.RemoteRepository.Url)
+	if err != nil {
+		return nil, fmt.Errorf("getting credentials for %s: %w", resource.Spec.RemoteRepository.Url, err)
+	}
+
+	// dynamic refs are resolved on every reconcile so that new matching tags are picked up
+	remote, err := util.ResolveRemoteRef(ctx, resource.Spec.RemoteRepository, false, auth)
+	if err != nil {
+		return nil, err
+	}
+	if util.IsDynamicRef(resource.Spec.RemoteRepository.Ref) {
+		resource.Status.ResolvedRef = remote.Ref
+	}
+
+	cloneDir := util.RepoDir(remote.Url, r.TempDir)
+	st := r.RepoMap.LoadOrStore(remote.Url, cloneDir)
+	if err = st.Lock(); err != nil {
+		return nil, fmt.Errorf("locking %s: %w", cloneDir, err)
+	}
+	defer st.Unlock()
+	wt, _, err := util.CloneRemoteRepoToDir(ctx, remote, 1, false, cloneDir, "", auth)
 	if err != nil {
 		return nil, fmt.Errorf("cloning repo, %s: %w", resource.Spec.RemoteRepository.Url, err)
 	}
 	return util.ReadWorktreeFile(wt, filePath)
 }
 
+func (r *Reconciler) readArchiveFile(ctx context.Context, archive v1alpha1.ArchiveSpec, filePath string) ([]byte, error) {
+	if !filepath.IsLocal(filePath) {
+		return nil, fmt.Errorf("application file %s must be relative to the archive root", filePath)
+	}
+
+	extractDir := util.RepoDir(archive.Url, r.TempDir)
+	st := r.RepoMap.LoadOrStore(archive.Url, extractDir)
+	if err := st.Lock(); err != nil {
+		return nil, fmt.Errorf("locking %s: %w", extractDir, err)
+	}
+	defer st.Unlock()
+	if _, err := util.FetchArchive(ctx, util.GetHttpClient(), archive, extractDir); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(filepath.Join(extractDir, filePath))
+}
+
 func (r *Reconciler) reconcileHelmValueObject(ctx context.Context, source *argov1alpha1.ApplicationSource,
 	resource *v1alpha1.CustomPackage, appName string,
 ) (ctrl.Result, error) {
+	if err := mergeHelmValues(source, resource.Spec.Values); err != nil {
+		return ctrl.Result{}, err
+	}
 	if source.Helm == nil || source.Helm.ValuesObject == nil {
 		return ctrl.Result{}, nil
 	}
@@ -667,6 +795,12 @@ func (r *Reconciler) reconcileHelmValueObjectSource(ctx context.Context,
 
 	switch val := (*valueObject).(type) {
 	case string:
+		if !isReplicated(resource) {
+			if packages.IsCNOEScheme(val) {
+				*valueObject, _, _ = remoteSource(resource, val)
+			}
+			return ctrl.Result{}, nil
+		}
 		res, repo, err := r.reconcileArgoCDSource(ctx, resource, val, appName)
 		if err != nil {
 			return res, fmt.Errorf("processing %s in helmValueObject: %w", val, err)
@@ -697,32 +831,112 @@ func (r *Reconciler) reconcileHelmValueObjectSource(ctx context.Context,
 	return ctrl.Result{}, nil
 }
 
-func localRepoName(appName, dir string) string {
-	return fmt.Sprintf("%s-%s", appName, filepath.Base(dir))
+// mergeHelmValues merges the package values into the helm valuesObject of the source. Package values take precedence.
+func mergeHelmValues(source *argov1alpha1.ApplicationSource, values *apiextensionsv1.JSON) error {
+	if source == nil || source.Helm == nil || values == nil {
+		return nil
+	}
+	pkgValues, err := util.JSONToValues(values)
+	if err != nil {
+		return err
+	}
+	current := map[string]interface{}{}
+	if source.Helm.ValuesObject != nil && len(source.Helm.ValuesObject.Raw) > 0 {
+		if err = json.Unmarshal(source.Helm.ValuesObject.Raw, &current); err != nil {
+			return fmt.Errorf("processing helm valuesObject: %w", err)
+		}
+	}
+	raw, err := json.Marshal(util.MergeValues(current, pkgValues))
+	if err != nil {
+		return fmt.Errorf("converting helm valuesObject to json: %w", err)
+	}
+	source.Helm.ValuesObject = &runtime.RawExtension{Raw: raw}
+	return nil
 }
 
-func remoteRepoName(appName, pathToPkg string, repo v1alpha1.RemoteRepositorySpec) string {
-	return fmt.Sprintf("%s-%s", appName, filepath.Base(pathToPkg))
+// findArgoCDObject returns the object of the given kind and name from a multi-document yaml file.
+func findArgoCDObject(scheme *runtime.Scheme, b []byte, kind, name string) (client.Object, error) {
+	docs, err := util.SplitYamlDocuments(b)
+	if err != nil {
+		return nil, err
+	}
+	for _, doc := range docs {
+		meta := metav1.PartialObjectMetadata{}
+		if err = yaml.Unmarshal(doc, &meta); err != nil {
+			return nil, fmt.Errorf("parsing yaml document: %w", err)
+		}
+		if meta.Kind != kind || meta.Name != name {
+			continue
+		}
+		objs, cErr := k8s.ConvertYamlToObjects(scheme, doc)
+		if cErr != nil {
+			return nil, fmt.Errorf("converting yaml to object %w", cErr)
+		}
+		if len(objs) == 1 {
+			return objs[0], nil
+		}
+	}
+	return nil, fmt.Errorf("%s %s not found", kind, name)
 }
 
-func isCNOEScheme(repoURL string) bool {
-	return strings.HasPrefix(repoURL, v1alpha1.CNOEURIScheme)
+// isReplicated returns true if cnoe:// sources of the package are replicated to the in-cluster git server.
+// Local and archive packages are always replicated because Argo CD cannot access them otherwise.
+func isReplicated(resource *v1alpha1.CustomPackage) bool {
+	return resource.Spec.Replicate || resource.Spec.RemoteRepository.Url == ""
 }
 
-func getCNOEAbsPath(fPath, repoURL string) (string, error) {
-	parentDir := filepath.Dir(fPath)
+// remoteSource returns the url, path and revision of the remote repository directory a cnoe:// url refers to.
+func remoteSource(resource *v1alpha1.CustomPackage, repoURL string) (string, string, string) {
+	remote := resource.Spec.RemoteRepository
 	relativePath := strings.TrimPrefix(repoURL, v1alpha1.CNOEURIScheme)
-	absPath, err := filepath.Abs(filepath.Join(parentDir, relativePath))
-	if err != nil {
-		return "", err
+
+	revision := remote.Ref
+	if resource.Status.ResolvedRef != "" && util.IsDynamicRef(remote.Ref) {
+		revision = resource.Status.ResolvedRef
+	}
+	if revision == "" {
+		revision = "HEAD"
 	}
+	return argoCDRepoURL(remote.Url), filepath.Join(remote.Path, relativePath), revision
+}
 
-	f, err := os.Stat(absPath)
-	if err != nil {
-		return "", err
+func setRemoteSource(resource *v1alpha1.CustomPackage, s *argov1alpha1.ApplicationSource) {
+	if !packages.IsCNOEScheme(s.RepoURL) {
+		return
+	}
+	repoURL, dir, revision := remoteSource(resource, s.RepoURL)
+	s.RepoURL, s.TargetRevision = repoURL, revision
+	// ref sources are addressed from the repository root, see setRemoteValueFiles.
+	// paths with generator parameters already contain the directory
+	if !isRefOnlySource(s) && !strings.Contains(s.Path, "{{") {
+		s.Path = filepath.Join(dir, s.Path)
 	}
-	if !f.IsDir() {
-		return "", fmt.Errorf("path not a directory: %s", absPath)
+}
+
+// argoCDRepoURL removes the password from the repository url. Argo CD reads credentials from its repository secrets.
+func argoCDRepoURL(repoURL string) string {
+	u, err := url.Parse(repoURL)
+	if err != nil || u.User == nil {
+		return repoURL
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.User(u.User.Username())
+	}
+	return u.String()
+}
+
+func localRepoName(appName, dir string) string {
+	return fmt.Sprintf("%s-%s", appName, filepath.Base(dir))
+}
+
+func remoteRepoName(appName, pathToPkg string, repo v1alpha1.RemoteRepositorySpec) string {
+	return fmt.Sprintf("%s-%s", appName, filepath.Base(pathToPkg))
+}
+
+func archiveRepoName(appName, pathToPkg string, archive v1alpha1.ArchiveSpec) string {
+	if pathToPkg != "." {
+		return fmt.Sprintf("%s-%s", appName, filepath.Base(pathToPkg))
 	}
-	return absPath, err
+	// the package is at the archive root, use the archive name instead
+	return fmt.Sprintf("%s-%s", appName, util.ArchiveName(archive.Url))
 }
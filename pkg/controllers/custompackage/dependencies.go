@@ -0,0 +1,55 @@
+package custompackage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	argov1alpha1 "github.com/cnoe-io/argocd-api/api/argo/application/v1alpha1"
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
+	"github.com/cnoe-io/idpbuilder/pkg/packages"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// blockingDependencies returns the dependencies of the package whose ArgoCD applications are not Healthy.
+// It returns an error when the dependencies of the package form a cycle.
+func (r *Reconciler) blockingDependencies(ctx context.Context, resource *v1alpha1.CustomPackage) ([]string, error) {
+	if len(resource.Spec.DependsOn) == 0 {
+		return nil, nil
+	}
+
+	pkgList := &v1alpha1.CustomPackageList{}
+	if err := r.Client.List(ctx, pkgList, client.InNamespace(resource.Namespace)); err != nil {
+		return nil, fmt.Errorf("listing custom packages: %w", err)
+	}
+	graph := map[string][]string{resource.Spec.ApplicationName(): resource.Spec.DependsOn}
+	for i := range pkgList.Items {
+		pkg := &pkgList.Items[i]
+		if pkg.Name == resource.Name {
+			continue
+		}
+		graph[pkg.Spec.ApplicationName()] = append(graph[pkg.Spec.ApplicationName()], pkg.Spec.DependsOn...)
+	}
+
+	if cycle := packages.FindDependencyCycle(graph, resource.Spec.ApplicationName()); cycle != nil {
+		return []string{cycle[1]}, fmt.Errorf("dependency cycle detected: %s", strings.Join(cycle, " -> "))
+	}
+
+	var blocked []string
+	for _, dep := range resource.Spec.DependsOn {
+		app := argov1alpha1.Application{}
+		err := r.Client.Get(ctx, client.ObjectKey{Name: dep, Namespace: resource.Spec.ArgoCD.Namespace}, &app)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				blocked = append(blocked, dep)
+				continue
+			}
+			return nil, fmt.Errorf("getting argocd application %s: %w", dep, err)
+		}
+		if app.Status.Health.Status != "Healthy" {
+			blocked = append(blocked, dep)
+		}
+	}
+	return blocked, nil
+}
@@ -0,0 +1,151 @@
+package custompackage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
+	"github.com/cnoe-io/idpbuilder/pkg/resources/localbuild"
+	"github.com/cnoe-io/idpbuilder/pkg/util"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// reconcileFluxPackage applies the Flux object of the package with the GitRepository sources it references in the same file.
+// cnoe:// source urls are replicated to the in-cluster git server.
+func (r *Reconciler) reconcileFluxPackage(ctx context.Context, resource *v1alpha1.CustomPackage) (ctrl.Result, error) {
+	b, err := r.getArgoCDAppFile(ctx, resource)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("reading file %s: %w", resource.Spec.PackageFile(), err)
+	}
+
+	obj, sources, err := findFluxObjects(b, resource.Spec.Flux)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("reading %s: %w", resource.Spec.PackageFile(), err)
+	}
+
+	if obj.GetKind() == localbuild.FluxHelmReleaseKind {
+		if err = mergeHelmReleaseValues(obj, resource); err != nil {
+			return ctrl.Result{}, fmt.Errorf("merging values of %s: %w", obj.GetName(), err)
+		}
+	}
+
+	synced := true
+	repoRefs := make([]v1alpha1.ObjectRef, 0, len(sources))
+	for _, s := range sources {
+		repoURL, _, _ := unstructured.NestedString(s.Object, "spec", "url")
+		res, repo, sErr := r.reconcileArgoCDSource(ctx, resource, repoURL, obj.GetName())
+		if sErr != nil {
+			return res, sErr
+		}
+		if repo == nil {
+			continue
+		}
+		if repo.Status.InternalGitRepositoryUrl == "" {
+			synced = false
+		}
+		setFluxSourceURL(s, repo.Status.InternalGitRepositoryUrl)
+		repoRefs = append(repoRefs, v1alpha1.ObjectRef{
+			Namespace: repo.Namespace,
+			Name:      repo.Name,
+			UID:       string(repo.ObjectMeta.UID),
+		})
+	}
+	resource.Status.GitRepositoryRefs = repoRefs
+	resource.Status.Synced = synced
+
+	// sources are not applied until the in-cluster repositories exist so that Flux does not pull cnoe:// urls
+	if !synced {
+		return ctrl.Result{RequeueAfter: requeueTime}, nil
+	}
+
+	for _, o := range append(sources, obj) {
+		util.SetPackageLabels(o)
+		if aErr := r.Client.Patch(ctx, o, client.Apply, client.ForceOwnership, client.FieldOwner(v1alpha1.FieldManager)); aErr != nil {
+			return ctrl.Result{}, fmt.Errorf("applying %s %s: %w", o.GetKind(), o.GetName(), aErr)
+		}
+	}
+	return ctrl.Result{}, nil
+}
+
+// findFluxObjects returns the Flux object of the package and the GitRepository sources it references from a multi-document yaml file.
+func findFluxObjects(b []byte, spec *v1alpha1.FluxPackageSpec) (*unstructured.Unstructured, []*unstructured.Unstructured, error) {
+	docs, err := util.SplitYamlDocuments(b)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var obj *unstructured.Unstructured
+	repos := map[string]*unstructured.Unstructured{}
+	for _, doc := range docs {
+		o := &unstructured.Unstructured{}
+		if _, _, dErr := scheme.Codecs.UniversalDeserializer().Decode(doc, nil, o); dErr != nil {
+			return nil, nil, fmt.Errorf("parsing yaml document: %w", dErr)
+		}
+		gvk := o.GroupVersionKind()
+		switch {
+		case gvk.Group == localbuild.FluxSourceGroup && gvk.Kind == localbuild.FluxGitRepositoryKind:
+			repos[o.GetName()] = o
+		case o.GetKind() == spec.Kind && o.GetName() == spec.Name && localbuild.IsFluxApplication(gvk):
+			obj = o
+		}
+	}
+	if obj == nil {
+		return nil, nil, fmt.Errorf("%s %s not found", spec.Kind, spec.Name)
+	}
+
+	var sources []*unstructured.Unstructured
+	for _, ref := range fluxSourceRefs(obj) {
+		if repo, ok := repos[ref]; ok {
+			if repo.GetNamespace() == "" {
+				repo.SetNamespace(obj.GetNamespace())
+			}
+			sources = append(sources, repo)
+		}
+	}
+	return obj, sources, nil
+}
+
+// fluxSourceRefs returns the names of the GitRepositories the Kustomization or HelmRelease is pulled from.
+func fluxSourceRefs(obj *unstructured.Unstructured) []string {
+	paths := [][]string{
+		{"spec", "sourceRef"},
+		{"spec", "chart", "spec", "sourceRef"},
+		{"spec", "chartRef"},
+	}
+	var out []string
+	for _, p := range paths {
+		ref, ok, _ := unstructured.NestedStringMap(obj.Object, p...)
+		if ok && ref["kind"] == localbuild.FluxGitRepositoryKind {
+			out = append(out, ref["name"])
+		}
+	}
+	return out
+}
+
+// setFluxSourceURL points the GitRepository to the branch of the in-cluster repository.
+func setFluxSourceURL(source *unstructured.Unstructured, repoURL string) {
+	_ = unstructured.SetNestedField(source.Object, repoURL, "spec", "url")
+	_ = unstructured.SetNestedStringMap(source.Object, map[string]string{"branch": localbuild.FluxSourceBranch}, "spec", "ref")
+}
+
+// mergeHelmReleaseValues merges the package values into the values of the HelmRelease. Package values take precedence.
+func mergeHelmReleaseValues(obj *unstructured.Unstructured, resource *v1alpha1.CustomPackage) error {
+	if resource.Spec.Values == nil {
+		return nil
+	}
+	pkgValues, err := util.JSONToValues(resource.Spec.Values)
+	if err != nil {
+		return err
+	}
+	current, _, err := unstructured.NestedMap(obj.Object, "spec", "values")
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		current = map[string]interface{}{}
+	}
+	return unstructured.SetNestedMap(obj.Object, util.MergeValues(current, pkgValues), "spec", "values")
+}
@@ -0,0 +1,99 @@
+package custompackage
+
+import (
+	"testing"
+
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const fluxPackageFile = `apiVersion: source.toolkit.fluxcd.io/v1
+kind: GitRepository
+metadata:
+  name: podinfo
+spec:
+  url: cnoe://podinfo
+---
+apiVersion: source.toolkit.fluxcd.io/v1
+kind: GitRepository
+metadata:
+  name: unused
+spec:
+  url: cnoe://unused
+---
+apiVersion: kustomize.toolkit.fluxcd.io/v1
+kind: Kustomization
+metadata:
+  name: podinfo
+  namespace: flux-system
+spec:
+  path: ./
+  sourceRef:
+    kind: GitRepository
+    name: podinfo
+---
+apiVersion: helm.toolkit.fluxcd.io/v2
+kind: HelmRelease
+metadata:
+  name: chart
+  namespace: flux-system
+spec:
+  chart:
+    spec:
+      chart: ./chart
+      sourceRef:
+        kind: GitRepository
+        name: podinfo
+  values:
+    replicas: 1
+    image:
+      tag: v1
+`
+
+func TestFindFluxObjects(t *testing.T) {
+	obj, sources, err := findFluxObjects([]byte(fluxPackageFile), &v1alpha1.FluxPackageSpec{Name: "podinfo", Kind: "Kustomization"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Kustomization", obj.GetKind())
+	assert.Len(t, sources, 1)
+	assert.Equal(t, "podinfo", sources[0].GetName())
+	// sources without a namespace are applied to the namespace of the object
+	assert.Equal(t, "flux-system", sources[0].GetNamespace())
+
+	obj, sources, err = findFluxObjects([]byte(fluxPackageFile), &v1alpha1.FluxPackageSpec{Name: "chart", Kind: "HelmRelease"})
+	assert.NoError(t, err)
+	assert.Equal(t, "HelmRelease", obj.GetKind())
+	assert.Len(t, sources, 1)
+
+	_, _, err = findFluxObjects([]byte(fluxPackageFile), &v1alpha1.FluxPackageSpec{Name: "podinfo", Kind: "HelmRelease"})
+	assert.ErrorContains(t, err, "HelmRelease podinfo not found")
+}
+
+func TestSetFluxSourceURL(t *testing.T) {
+	_, sources, err := findFluxObjects([]byte(fluxPackageFile), &v1alpha1.FluxPackageSpec{Name: "podinfo", Kind: "Kustomization"})
+	assert.NoError(t, err)
+
+	setFluxSourceURL(sources[0], "http://gitea-http.gitea.svc.cluster.local:3000/giteaAdmin/idpbuilder-localdev-podinfo-podinfo.git")
+	u, _, _ := unstructured.NestedString(sources[0].Object, "spec", "url")
+	assert.Equal(t, "http://gitea-http.gitea.svc.cluster.local:3000/giteaAdmin/idpbuilder-localdev-podinfo-podinfo.git", u)
+	ref, _, _ := unstructured.NestedStringMap(sources[0].Object, "spec", "ref")
+	assert.Equal(t, map[string]string{"branch": "main"}, ref)
+}
+
+func TestMergeHelmReleaseValues(t *testing.T) {
+	obj, _, err := findFluxObjects([]byte(fluxPackageFile), &v1alpha1.FluxPackageSpec{Name: "chart", Kind: "HelmRelease"})
+	assert.NoError(t, err)
+
+	resource := &v1alpha1.CustomPackage{}
+	assert.NoError(t, mergeHelmReleaseValues(obj, resource))
+
+	resource.Spec.Values = &apiextensionsv1.JSON{Raw: []byte(`{"image":{"tag":"v2"},"debug":true}`)}
+	assert.NoError(t, mergeHelmReleaseValues(obj, resource))
+	values, _, _ := unstructured.NestedMap(obj.Object, "spec", "values")
+	assert.Equal(t, map[string]interface{}{
+		"replicas": int64(1),
+		"image":    map[string]interface{}{"tag": "v2"},
+		"debug":    true,
+	}, values)
+}
@@ -0,0 +1,50 @@
+package custompackage
+
+import (
+	"fmt"
+	"path/filepath"
+
+	argov1alpha1 "github.com/cnoe-io/argocd-api/api/argo/application/v1alpha1"
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
+	"github.com/cnoe-io/idpbuilder/pkg/packages"
+)
+
+// isRefOnlySource returns true if the source only provides files to other sources through $ref value files.
+func isRefOnlySource(s *argov1alpha1.ApplicationSource) bool {
+	return s.Ref != "" && s.Path == "" && s.Chart == ""
+}
+
+// validateValueFiles checks that the $ref value files of the sources refer to a source of the application.
+// For local packages, value files of cnoe:// ref sources must exist in the package directory.
+func validateValueFiles(resource *v1alpha1.CustomPackage, sources argov1alpha1.ApplicationSources) error {
+	local := resource.Spec.RemoteRepository.Url == "" && resource.Spec.Archive.Url == ""
+	return packages.ValidateValueFiles(resource.Spec.PackageFile(), local, sources)
+}
+
+// setRemoteValueFiles prefixes $ref value files with the remote directory of their cnoe:// ref source.
+// Argo CD resolves $ref paths relative to the repository root, not relative to the package directory.
+// It must be called before the urls of the sources are replaced.
+func setRemoteValueFiles(resource *v1alpha1.CustomPackage, sources argov1alpha1.ApplicationSources) {
+	dirs := map[string]string{}
+	for i := range sources {
+		if sources[i].Ref != "" && packages.IsCNOEScheme(sources[i].RepoURL) {
+			_, dir, _ := remoteSource(resource, sources[i].RepoURL)
+			dirs[sources[i].Ref] = dir
+		}
+	}
+
+	for i := range sources {
+		s := &sources[i]
+		if s.Helm == nil {
+			continue
+		}
+		for j, vf := range s.Helm.ValueFiles {
+			ref, p, ok := packages.SplitRefValueFile(vf)
+			dir, found := dirs[ref]
+			if !ok || !found {
+				continue
+			}
+			s.Helm.ValueFiles[j] = fmt.Sprintf("$%s/%s", ref, filepath.Join(dir, p))
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package custompackage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	argov1alpha1 "github.com/cnoe-io/argocd-api/api/argo/application/v1alpha1"
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+func helmSources(valueFiles ...string) argov1alpha1.ApplicationSources {
+	return argov1alpha1.ApplicationSources{
+		{RepoURL: "https://charts.example.com", Chart: "nginx", Helm: &argov1alpha1.ApplicationSourceHelm{ValueFiles: valueFiles}},
+		{RepoURL: "cnoe://values", Ref: "values"},
+	}
+}
+
+func TestValidateValueFiles(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "values", "dev"), 0700))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "values", "dev", "values.yaml"), []byte("replicas: 1\n"), 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "values", "prod.yaml.tmpl"), []byte("replicas: 3\n"), 0600))
+	resource := &v1alpha1.CustomPackage{
+		Spec: v1alpha1.CustomPackageSpec{ArgoCD: v1alpha1.ArgoCDPackageSpec{ApplicationFile: filepath.Join(dir, "app.yaml")}},
+	}
+
+	assert.NoError(t, validateValueFiles(resource, helmSources("$values/dev/values.yaml", "$values/prod.yaml", "values.yaml")))
+	assert.Error(t, validateValueFiles(resource, helmSources("$values/missing.yaml")))
+	assert.Error(t, validateValueFiles(resource, helmSources("$other/values.yaml")))
+
+	sources := helmSources("$values/missing.yaml")
+	sources[0].Helm.IgnoreMissingValueFiles = true
+	assert.NoError(t, validateValueFiles(resource, sources))
+
+	// value files of remote packages are not checked
+	resource.Spec.RemoteRepository.Url = "https://github.com/org/repo"
+	assert.NoError(t, validateValueFiles(resource, helmSources("$values/missing.yaml")))
+}
+
+func TestSetRemoteValueFiles(t *testing.T) {
+	resource := &v1alpha1.CustomPackage{
+		Spec: v1alpha1.CustomPackageSpec{
+			RemoteRepository: v1alpha1.RemoteRepositorySpec{Url: "https://github.com/org/repo", Path: "packages/app", Ref: "main"},
+		},
+	}
+	sources := helmSources("$values/dev/values.yaml", "values.yaml")
+	setRemoteValueFiles(resource, sources)
+	assert.Equal(t, []string{"$values/packages/app/values/dev/values.yaml", "values.yaml"}, sources[0].Helm.ValueFiles)
+
+	setRemoteSource(resource, &sources[1])
+	assert.Equal(t, "https://github.com/org/repo", sources[1].RepoURL)
+	assert.Equal(t, "main", sources[1].TargetRevision)
+	assert.Equal(t, "", sources[1].Path)
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"path/filepath"
 	"time"
 
 	"code.gitea.io/sdk/gitea"
@@ -14,6 +15,7 @@ import (
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
 	gitclient "github.com/go-git/go-git/v5/plumbing/transport/client"
 	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -47,6 +49,7 @@ type RepositoryReconciler struct {
 	GitProviderFunc gitProviderFunc
 	TempDir         string
 	RepoMap         *util.RepoMap
+	GitAuth         *util.GitAuthConfig
 }
 
 type gitProviderFunc func(context.Context, *v1alpha1.GitRepository, client.Client, *runtime.Scheme, v1alpha1.BuildCustomizationSpec) (gitProvider, error)
@@ -83,11 +86,29 @@ func GetGitProvider(ctx context.Context, repo *v1alpha1.GitRepository, kubeClien
 			config:      tmplConfig,
 		}, nil
 	case v1alpha1.GitProviderGitHub:
+		gitHubClient, err := newGitHubClient(nil, repo.Spec.Provider.GitURL)
+		if err != nil {
+			return nil, err
+		}
 		return &gitHubProvider{
 			Client:       kubeClient,
 			Scheme:       scheme,
 			config:       tmplConfig,
-			gitHubClient: newGitHubClient(nil),
+			gitHubClient: gitHubClient,
+		}, nil
+	case v1alpha1.GitProviderGitLab:
+		return &gitLabProvider{
+			Client:       kubeClient,
+			Scheme:       scheme,
+			config:       tmplConfig,
+			gitLabClient: newGitLabClient(repo.Spec.Provider.GitURL, util.GetHttpClient()),
+		}, nil
+	case v1alpha1.GitProviderGitHTTP:
+		return &gitHTTPProvider{
+			Client:     kubeClient,
+			Scheme:     scheme,
+			httpClient: util.GetHttpClient(),
+			config:     tmplConfig,
 		}, nil
 	}
 	return nil, fmt.Errorf("invalid git provider %s ", repo.Spec.Provider.Name)
@@ -102,6 +123,10 @@ func (r *RepositoryReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
+	if !gitRepo.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, r.deleteArgoCDLFSRepository(ctx, &gitRepo)
+	}
+
 	defer r.postProcessReconcile(ctx, req, &gitRepo)
 
 	logger.V(1).Info("reconciling GitRepository", "name", req.Name, "namespace", req.Namespace)
@@ -143,7 +168,8 @@ func (r *RepositoryReconciler) reconcileGitRepo(ctx context.Context, repo *v1alp
 		return ctrl.Result{}, fmt.Errorf("getting git provider credentials: %w", err)
 	}
 
-	if r.Config.StaticPassword {
+	// the static password applies to the git servers installed by idpbuilder, not to token based providers.
+	if r.Config.StaticPassword && creds.accessToken == "" {
 		creds.password = util.StaticPassword
 	}
 
@@ -168,11 +194,30 @@ func (r *RepositoryReconciler) reconcileGitRepo(ctx context.Context, repo *v1alp
 		providerRepo = p
 	}
 
-	err = provider.updateRepoContent(ctx, repo, providerRepo, creds, r.TempDir, r.RepoMap)
+	srcAuth, err := r.GitAuth.AuthMethod(repo.Spec.Source.RemoteRepository.Url)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("getting source repository credentials: %w", err)
+	}
+
+	err = provider.updateRepoContent(ctx, repo, providerRepo, creds, srcAuth, r.TempDir, r.RepoMap)
 	if err != nil {
 		return ctrl.Result{}, fmt.Errorf("updating repository contents: %w", err)
 	}
 
+	// the repository secret is only read by Argo CD
+	if repo.Spec.Source.Type == v1alpha1.SourceTypeRemote && repo.Spec.Source.RemoteRepository.LFS == v1alpha1.LFSModePush &&
+		r.Config.GitOpsEngine != v1alpha1.GitOpsEngineFlux {
+		argoUrl := providerRepo.internalGitRepositoryUrl
+		if argoUrl == "" {
+			argoUrl = providerRepo.cloneUrl
+		}
+		if err = r.reconcileArgoCDLFSRepository(ctx, repo, argoUrl); err != nil {
+			return ctrl.Result{}, fmt.Errorf("enabling lfs in argocd: %w", err)
+		}
+	} else if err = r.deleteArgoCDLFSRepository(ctx, repo); err != nil {
+		return ctrl.Result{}, fmt.Errorf("disabling lfs in argocd: %w", err)
+	}
+
 	repo.Status.ExternalGitRepositoryUrl = providerRepo.cloneUrl
 	repo.Status.InternalGitRepositoryUrl = providerRepo.internalGitRepositoryUrl
 	repo.Status.Synced = true
@@ -222,6 +267,16 @@ func addAllAndCommit(path string, gitRepo *git.Repository) (plumbing.Hash, bool,
 	return h, true, nil
 }
 
+// targetAuth returns the credentials used to clone the target repository. Repositories of token based
+// providers are private and cannot be cloned anonymously.
+func targetAuth(creds gitProviderCredentials) transport.AuthMethod {
+	if creds.accessToken == "" {
+		return nil
+	}
+	auth, _ := getBasicAuth(creds)
+	return &auth
+}
+
 func pushToRemote(ctx context.Context, remoteRepo *git.Repository, creds gitProviderCredentials) error {
 	auth, err := getBasicAuth(creds)
 	if err != nil {
@@ -233,14 +288,16 @@ func pushToRemote(ctx context.Context, remoteRepo *git.Repository, creds gitProv
 	})
 }
 
-// add files from local fs to target repository (gitea for now)
-func reconcileLocalRepoContent(ctx context.Context, repo *v1alpha1.GitRepository, tgtRepo repoInfo, creds gitProviderCredentials, scheme *runtime.Scheme, tmplConfig v1alpha1.BuildCustomizationSpec, tmpDir string, repoMap *util.RepoMap) error {
+// add files from local fs at srcPath to target repository (gitea for now)
+func reconcileLocalRepoContent(ctx context.Context, repo *v1alpha1.GitRepository, srcPath string, tgtRepo repoInfo, creds gitProviderCredentials, scheme *runtime.Scheme, tmplConfig v1alpha1.BuildCustomizationSpec, tmpDir string, repoMap *util.RepoMap) error {
 	logger := log.FromContext(ctx)
 	tgtCloneDir := util.RepoDir(tgtRepo.cloneUrl, tmpDir)
 
 	st := repoMap.LoadOrStore(tgtRepo.cloneUrl, tgtCloneDir)
-	st.MU.Lock()
-	defer st.MU.Unlock()
+	if err := st.Lock(); err != nil {
+		return fmt.Errorf("locking %s: %w", tgtCloneDir, err)
+	}
+	defer st.Unlock()
 
 	tgtRepoSpec := v1alpha1.RemoteRepositorySpec{
 		CloneSubmodules: false,
@@ -249,12 +306,12 @@ func reconcileLocalRepoContent(ctx context.Context, repo *v1alpha1.GitRepository
 		Ref:             "",
 	}
 	logger.V(1).Info("cloning repo", "repoUrl", tgtRepoSpec.Url, "fallbackUrl", getFallbackRepositoryURL(repo, tgtRepo), "cloneDir", tgtCloneDir)
-	_, tgtRepository, err := util.CloneRemoteRepoToDir(ctx, tgtRepoSpec, 1, true, tgtCloneDir, getFallbackRepositoryURL(repo, tgtRepo))
+	_, tgtRepository, err := util.CloneRemoteRepoToDir(ctx, tgtRepoSpec, 1, true, tgtCloneDir, getFallbackRepositoryURL(repo, tgtRepo), targetAuth(creds))
 	if err != nil {
 		return fmt.Errorf("cloning repo %s: %w", tgtRepoSpec.Url, err)
 	}
 
-	err = writeRepoContents(repo, tgtCloneDir, tmplConfig, scheme)
+	err = writeRepoContents(repo, srcPath, tgtCloneDir, tmplConfig, scheme)
 	if err != nil {
 		return fmt.Errorf("writing repo contents: %w", err)
 	}
@@ -284,18 +341,55 @@ func reconcileLocalRepoContent(ctx context.Context, repo *v1alpha1.GitRepository
 	return nil
 }
 
+// add files from the extracted archive at specified path to target repository
+func reconcileArchiveRepoContent(ctx context.Context, repo *v1alpha1.GitRepository, tgtRepo repoInfo, creds gitProviderCredentials, scheme *runtime.Scheme, tmplConfig v1alpha1.BuildCustomizationSpec, tmpDir string, repoMap *util.RepoMap) error {
+	archive := repo.Spec.Source.Archive
+	srcPath := filepath.Clean(repo.Spec.Source.Path)
+	if !filepath.IsLocal(srcPath) {
+		return fmt.Errorf("path %s must be relative to the archive root", repo.Spec.Source.Path)
+	}
+	extractDir := util.RepoDir(archive.Url, tmpDir)
+
+	st := repoMap.LoadOrStore(archive.Url, extractDir)
+	if err := st.Lock(); err != nil {
+		return fmt.Errorf("locking %s: %w", extractDir, err)
+	}
+	defer st.Unlock()
+
+	// the archive is fetched on every reconcile so that content changes under the same url are picked up
+	digest, err := util.FetchArchive(ctx, util.GetHttpClient(), archive, extractDir)
+	if err != nil {
+		return err
+	}
+	repo.Status.ArchiveDigest = digest
+
+	return reconcileLocalRepoContent(ctx, repo, filepath.Join(extractDir, srcPath), tgtRepo, creds, scheme, tmplConfig, tmpDir, repoMap)
+}
+
 // add files from another repository at specified path to target repository (gitea for now)
-func reconcileRemoteRepoContent(ctx context.Context, repo *v1alpha1.GitRepository, tgtRepo repoInfo, creds gitProviderCredentials, tmpDir string, repoMap *util.RepoMap) error {
+func reconcileRemoteRepoContent(ctx context.Context, repo *v1alpha1.GitRepository, tgtRepo repoInfo, creds gitProviderCredentials, srcAuth transport.AuthMethod, tmpDir string, repoMap *util.RepoMap) error {
 	logger := log.FromContext(ctx)
 	srcRepo := repo.Spec.Source.RemoteRepository
+	// only the path copied to the target repository needs to be checked out
+	srcRepo.Path = repo.Spec.Source.Path
+	// dynamic refs are resolved on every reconcile so that new matching tags are picked up
+	srcRepo, err := util.ResolveRemoteRef(ctx, srcRepo, false, srcAuth)
+	if err != nil {
+		return err
+	}
+	if util.IsDynamicRef(repo.Spec.Source.RemoteRepository.Ref) {
+		repo.Status.ResolvedRef = srcRepo.Ref
+	}
 	cloneDir := util.RepoDir(srcRepo.Url, tmpDir)
 
 	st := repoMap.LoadOrStore(srcRepo.Url, cloneDir)
-	st.MU.Lock()
-	defer st.MU.Unlock()
+	if err := st.Lock(); err != nil {
+		return fmt.Errorf("locking %s: %w", cloneDir, err)
+	}
+	defer st.Unlock()
 
 	logger.V(1).Info("cloning repo", "repoUrl", srcRepo.Url, "fallbackUrl", "", "cloneDir", cloneDir)
-	remoteWT, _, err := util.CloneRemoteRepoToDir(ctx, srcRepo, 1, false, cloneDir, "")
+	remoteWT, _, err := util.CloneRemoteRepoToDir(ctx, srcRepo, 1, false, cloneDir, "", srcAuth)
 	if err != nil {
 		return fmt.Errorf("cloning repo, %s: %w", srcRepo.Url, err)
 	}
@@ -310,11 +404,13 @@ func reconcileRemoteRepoContent(ctx context.Context, repo *v1alpha1.GitRepositor
 	tgtCloneDir := util.RepoDir(tgtRepo.cloneUrl, tmpDir)
 	lst := repoMap.LoadOrStore(tgtRepoSpec.Url, tgtCloneDir)
 
-	lst.MU.Lock()
-	defer lst.MU.Unlock()
+	if err := lst.Lock(); err != nil {
+		return fmt.Errorf("locking %s: %w", tgtCloneDir, err)
+	}
+	defer lst.Unlock()
 
 	logger.V(1).Info("cloning repo", "repoUrl", tgtRepoSpec.Url, "fallbackUrl", getFallbackRepositoryURL(repo, tgtRepo), "cloneDir", tgtCloneDir)
-	tgtRepoWT, tgtRepository, err := util.CloneRemoteRepoToDir(ctx, tgtRepoSpec, 1, true, tgtCloneDir, getFallbackRepositoryURL(repo, tgtRepo))
+	tgtRepoWT, tgtRepository, err := util.CloneRemoteRepoToDir(ctx, tgtRepoSpec, 1, true, tgtCloneDir, getFallbackRepositoryURL(repo, tgtRepo), targetAuth(creds))
 	if err != nil {
 		return fmt.Errorf("cloning repo %s: %w", srcRepo.Url, err)
 	}
@@ -324,6 +420,17 @@ func reconcileRemoteRepoContent(ctx context.Context, repo *v1alpha1.GitRepositor
 		return fmt.Errorf("copying contents, %s: %w", tgtRepo.cloneUrl, err)
 	}
 
+	if srcRepo.LFS != "" {
+		tgtUrls := []string{tgtRepo.cloneUrl}
+		if f := getFallbackRepositoryURL(repo, tgtRepo); f != tgtRepo.cloneUrl {
+			tgtUrls = append(tgtUrls, f)
+		}
+		err = replicateLFSObjects(ctx, srcRepo, srcAuth, cloneDir, tgtRepoWT, tgtUrls, creds)
+		if err != nil {
+			return fmt.Errorf("replicating lfs objects, %s: %w", srcRepo.Url, err)
+		}
+	}
+
 	hash, push, err := addAllAndCommit(repo.Spec.Source.Path, tgtRepository)
 	if err != nil {
 		return fmt.Errorf("add and commit %w", err)
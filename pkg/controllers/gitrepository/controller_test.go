@@ -234,7 +234,7 @@ func TestGitRepositoryContentReconcile(t *testing.T) {
 			giteaClient: mockGitea{},
 		}
 		// add file to source directory, reconcile, clone the repo and check if the added file exists
-		err = p.updateRepoContent(ctx, &resource, repoInfo{cloneUrl: localRepoDir}, gitProviderCredentials{}, testCloneDir, util.NewRepoLock())
+		err = p.updateRepoContent(ctx, &resource, repoInfo{cloneUrl: localRepoDir}, gitProviderCredentials{}, nil, testCloneDir, util.NewRepoLock())
 		if err != nil {
 			t.Fatalf("failed adding %v", err)
 		}
@@ -255,7 +255,7 @@ func TestGitRepositoryContentReconcile(t *testing.T) {
 		if err != nil {
 			t.Fatalf("failed to remove added file %v", err)
 		}
-		err = p.updateRepoContent(ctx, &resource, repoInfo{cloneUrl: localRepoDir}, gitProviderCredentials{}, testCloneDir, util.NewRepoLock())
+		err = p.updateRepoContent(ctx, &resource, repoInfo{cloneUrl: localRepoDir}, gitProviderCredentials{}, nil, testCloneDir, util.NewRepoLock())
 		if err != nil {
 			t.Fatalf("failed removing %v", err)
 		}
@@ -309,7 +309,7 @@ func TestGitRepositoryContentReconcileEmbedded(t *testing.T) {
 			Client:      &fakeClient{},
 			giteaClient: mockGitea{},
 		}
-		err = p.updateRepoContent(ctx, &resource, repoInfo{cloneUrl: localRepoDir}, gitProviderCredentials{}, tmpDir, util.NewRepoLock())
+		err = p.updateRepoContent(ctx, &resource, repoInfo{cloneUrl: localRepoDir}, gitProviderCredentials{}, nil, tmpDir, util.NewRepoLock())
 		if err != nil {
 			t.Fatalf("failed adding %v", err)
 		}
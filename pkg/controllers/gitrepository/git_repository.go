@@ -6,12 +6,14 @@ import (
 	"code.gitea.io/sdk/gitea"
 	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
 	"github.com/cnoe-io/idpbuilder/pkg/util"
+	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/google/go-github/v61/github"
 )
 
 type GiteaClient interface {
 	CreateAccessToken(option gitea.CreateAccessTokenOption) (*gitea.AccessToken, *gitea.Response, error)
 	CreateOrg(opt gitea.CreateOrgOption) (*gitea.Organization, *gitea.Response, error)
+	CreateOrgRepo(org string, opt gitea.CreateRepoOption) (*gitea.Repository, *gitea.Response, error)
 	CreateRepo(opt gitea.CreateRepoOption) (*gitea.Repository, *gitea.Response, error)
 	DeleteOrg(orgname string) (*gitea.Response, error)
 	DeleteRepo(owner, repo string) (*gitea.Response, error)
@@ -24,6 +26,17 @@ type GiteaClient interface {
 type gitHubClient interface {
 	getRepo(ctx context.Context, owner, repo string) (*github.Repository, *github.Response, error)
 	createRepo(ctx context.Context, owner string, req *github.Repository) (*github.Repository, *github.Response, error)
+	getUsername(ctx context.Context) (string, error)
+	replaceTopics(ctx context.Context, owner, repo string, topics []string) error
+	setToken(token string) error
+}
+
+type gitLabClient interface {
+	getProject(ctx context.Context, path string) (*gitLabProject, error)
+	createProject(ctx context.Context, opts gitLabCreateProjectOptions) (*gitLabProject, error)
+	setTopics(ctx context.Context, id int, topics []string) error
+	getNamespaceID(ctx context.Context, path string) (int, error)
+	getUsername(ctx context.Context) (string, error)
 	setToken(token string) error
 }
 
@@ -45,5 +58,5 @@ type gitProvider interface {
 	getProviderCredentials(ctx context.Context, repo *v1alpha1.GitRepository) (gitProviderCredentials, error)
 	getRepository(ctx context.Context, repo *v1alpha1.GitRepository) (repoInfo, error)
 	setProviderCredentials(ctx context.Context, repo *v1alpha1.GitRepository, creds gitProviderCredentials) error
-	updateRepoContent(ctx context.Context, repo *v1alpha1.GitRepository, repoInfo repoInfo, creds gitProviderCredentials, tmpDir string, repoMap *util.RepoMap) error
+	updateRepoContent(ctx context.Context, repo *v1alpha1.GitRepository, repoInfo repoInfo, creds gitProviderCredentials, srcAuth transport.AuthMethod, tmpDir string, repoMap *util.RepoMap) error
 }
@@ -11,6 +11,7 @@ import (
 	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
 	"github.com/cnoe-io/idpbuilder/pkg/controllers/localbuild"
 	"github.com/cnoe-io/idpbuilder/pkg/util"
+	"github.com/go-git/go-git/v5/plumbing/transport"
 	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -30,18 +31,28 @@ type giteaProvider struct {
 	Scheme      *runtime.Scheme
 	giteaClient GiteaClient
 	config      v1alpha1.BuildCustomizationSpec
+	// username is the user the client is authenticated as.
+	username string
 }
 
 func (g *giteaProvider) createRepository(ctx context.Context, repo *v1alpha1.GitRepository) (repoInfo, error) {
-	resp, _, err := g.giteaClient.CreateRepo(gitea.CreateRepoOption{
+	opt := gitea.CreateRepoOption{
 		Name:        getRepositoryName(*repo),
 		Description: fmt.Sprintf("created by Git Repository controller for %s in %s namespace", repo.Name, repo.Namespace),
 		// we should reconsider this when targeting non-local clusters.
 		Private:       false,
 		DefaultBranch: DefaultBranchName,
 		AutoInit:      true,
-	})
+	}
 
+	// repositories are looked up under the organization, CreateRepo creates them under the authenticated user
+	var resp *gitea.Repository
+	var err error
+	if org := getOrganizationName(*repo); org != "" && org != g.username {
+		resp, _, err = g.giteaClient.CreateOrgRepo(org, opt)
+	} else {
+		resp, _, err = g.giteaClient.CreateRepo(opt)
+	}
 	if err != nil {
 		return repoInfo{}, fmt.Errorf("failed to create git repository: %w", err)
 	}
@@ -80,6 +91,7 @@ func (g *giteaProvider) getProviderCredentials(ctx context.Context, repo *v1alph
 func (g *giteaProvider) setProviderCredentials(ctx context.Context, repo *v1alpha1.GitRepository, creds gitProviderCredentials) error {
 	g.giteaClient.SetBasicAuth(creds.username, creds.password)
 	g.giteaClient.SetContext(ctx)
+	g.username = creds.username
 	return nil
 }
 
@@ -96,7 +108,7 @@ func (g *giteaProvider) getRepository(ctx context.Context, repo *v1alpha1.GitRep
 		name:                     resp.Name,
 		fullName:                 resp.FullName,
 		cloneUrl:                 resp.CloneURL,
-		internalGitRepositoryUrl: getInternalGiteaRepositoryURL(repo.Namespace, repo.Name, repo.Spec.Provider.InternalGitURL),
+		internalGitRepositoryUrl: getInternalGiteaRepositoryURL(repo.Namespace, repo.Name, repo.Spec.Provider.InternalGitURL, getOrganizationName(*repo)),
 	}, nil
 }
 
@@ -105,24 +117,27 @@ func (g *giteaProvider) updateRepoContent(
 	repo *v1alpha1.GitRepository,
 	repoInfo repoInfo,
 	creds gitProviderCredentials,
+	srcAuth transport.AuthMethod,
 	tmpDir string,
 	repoMap *util.RepoMap,
 ) error {
 	switch repo.Spec.Source.Type {
 	case v1alpha1.SourceTypeLocal, v1alpha1.SourceTypeEmbedded:
-		return reconcileLocalRepoContent(ctx, repo, repoInfo, creds, g.Scheme, g.config, tmpDir, repoMap)
+		return reconcileLocalRepoContent(ctx, repo, repo.Spec.Source.Path, repoInfo, creds, g.Scheme, g.config, tmpDir, repoMap)
+	case v1alpha1.SourceTypeArchive:
+		return reconcileArchiveRepoContent(ctx, repo, repoInfo, creds, g.Scheme, g.config, tmpDir, repoMap)
 	case v1alpha1.SourceTypeRemote:
-		return reconcileRemoteRepoContent(ctx, repo, repoInfo, creds, tmpDir, repoMap)
+		return reconcileRemoteRepoContent(ctx, repo, repoInfo, creds, srcAuth, tmpDir, repoMap)
 	default:
 		return nil
 	}
 }
 
-func writeRepoContents(repo *v1alpha1.GitRepository, dstPath string, config v1alpha1.BuildCustomizationSpec, scheme *runtime.Scheme) error {
+func writeRepoContents(repo *v1alpha1.GitRepository, srcPath, dstPath string, config v1alpha1.BuildCustomizationSpec, scheme *runtime.Scheme) error {
 	if repo.Spec.Source.EmbeddedAppName != "" {
 		resources, err := localbuild.GetEmbeddedRawInstallResources(
 			repo.Spec.Source.EmbeddedAppName, config,
-			v1alpha1.PackageCustomization{Name: repo.Spec.Customization.Name, FilePath: repo.Spec.Customization.FilePath}, scheme)
+			repo.Spec.Customization, scheme)
 		if err != nil {
 			return fmt.Errorf("getting embedded resource; %w", err)
 		}
@@ -137,10 +152,19 @@ func writeRepoContents(repo *v1alpha1.GitRepository, dstPath string, config v1al
 		return nil
 	}
 
-	err := files.CopyDirectory(repo.Spec.Source.Path, dstPath)
+	err := files.CopyDirectory(srcPath, dstPath)
 	if err != nil {
 		return fmt.Errorf("copying files: %w", err)
 	}
+
+	data, err := util.NewPackageTemplateData(config, repo.Spec.Values)
+	if err != nil {
+		return err
+	}
+	err = util.RenderPackageTemplates(dstPath, data)
+	if err != nil {
+		return fmt.Errorf("rendering templates: %w", err)
+	}
 	return nil
 }
 
@@ -159,6 +183,9 @@ func NewGiteaClient(url string, options ...gitea.ClientOption) (GiteaClient, err
 	return gitea.NewClient(url, options...)
 }
 
-func getInternalGiteaRepositoryURL(namespace, name, baseUrl string) string {
-	return fmt.Sprintf("%s/%s/%s-%s.git", baseUrl, v1alpha1.GiteaAdminUserName, namespace, name)
+func getInternalGiteaRepositoryURL(namespace, name, baseUrl, org string) string {
+	if org == "" {
+		org = v1alpha1.GiteaAdminUserName
+	}
+	return fmt.Sprintf("%s/%s/%s-%s.git", baseUrl, org, namespace, name)
 }
@@ -0,0 +1,58 @@
+package gitrepository
+
+import (
+	"context"
+	"testing"
+
+	"code.gitea.io/sdk/gitea"
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// recordingGitea records the owner repositories are created under.
+type recordingGitea struct {
+	GiteaClient
+	owner string
+}
+
+func (g *recordingGitea) SetBasicAuth(user, pass string) {}
+
+func (g *recordingGitea) SetContext(ctx context.Context) {}
+
+func (g *recordingGitea) CreateOrgRepo(org string, opt gitea.CreateRepoOption) (*gitea.Repository, *gitea.Response, error) {
+	g.owner = org
+	return &gitea.Repository{Name: opt.Name, FullName: org + "/" + opt.Name}, &gitea.Response{}, nil
+}
+
+func (g *recordingGitea) CreateRepo(opt gitea.CreateRepoOption) (*gitea.Repository, *gitea.Response, error) {
+	g.owner = "user"
+	return &gitea.Repository{Name: opt.Name, FullName: "user/" + opt.Name}, &gitea.Response{}, nil
+}
+
+func TestGiteaCreateRepository(t *testing.T) {
+	cases := map[string]struct {
+		org   string
+		owner string
+	}{
+		"organization": {org: "team", owner: "team"},
+		"user":         {org: "user", owner: "user"},
+		"no org":       {org: "", owner: "user"},
+	}
+
+	ctx := context.Background()
+	for name, c := range cases {
+		client := &recordingGitea{}
+		p := &giteaProvider{giteaClient: client}
+		assert.NoError(t, p.setProviderCredentials(ctx, nil, gitProviderCredentials{username: "user", password: "pass"}))
+
+		repo := &v1alpha1.GitRepository{
+			ObjectMeta: metav1.ObjectMeta{Name: "repo", Namespace: "ns"},
+			Spec:       v1alpha1.GitRepositorySpec{Provider: v1alpha1.Provider{OrganizationName: c.org}},
+		}
+		info, err := p.createRepository(ctx, repo)
+		assert.NoError(t, err, name)
+		assert.Equal(t, c.owner, client.owner, name)
+		assert.Equal(t, c.owner+"/ns-repo", info.fullName, name)
+	}
+}
@@ -0,0 +1,135 @@
+package gitrepository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
+	"github.com/cnoe-io/idpbuilder/pkg/githttp"
+	"github.com/cnoe-io/idpbuilder/pkg/util"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// gitHTTPProvider manages repositories on the built-in githttp server.
+type gitHTTPProvider struct {
+	client.Client
+	Scheme     *runtime.Scheme
+	httpClient *http.Client
+	config     v1alpha1.BuildCustomizationSpec
+	creds      gitProviderCredentials
+}
+
+func (g *gitHTTPProvider) createRepository(ctx context.Context, repo *v1alpha1.GitRepository) (repoInfo, error) {
+	b, err := json.Marshal(githttp.Repository{Name: getRepositoryName(*repo)})
+	if err != nil {
+		return repoInfo{}, err
+	}
+
+	resp, err := g.do(ctx, http.MethodPost, repo.Spec.Provider.GitURL+githttp.ReposPath, b)
+	if err != nil {
+		return repoInfo{}, fmt.Errorf("failed to create git repository: %w", err)
+	}
+	defer resp.Body.Close()
+	// the repository may have been created by a previous reconcile that failed afterwards.
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusConflict {
+		return repoInfo{}, fmt.Errorf("failed to create git repository: unexpected status code %d", resp.StatusCode)
+	}
+	return gitHTTPRepoInfo(repo), nil
+}
+
+func (g *gitHTTPProvider) getProviderCredentials(ctx context.Context, repo *v1alpha1.GitRepository) (gitProviderCredentials, error) {
+	var secret v1.Secret
+	err := g.Client.Get(ctx, types.NamespacedName{
+		Namespace: repo.Spec.SecretRef.Namespace,
+		Name:      repo.Spec.SecretRef.Name,
+	}, &secret)
+	if err != nil {
+		return gitProviderCredentials{}, err
+	}
+
+	username, ok := secret.Data[giteaAdminUsernameKey]
+	if !ok {
+		return gitProviderCredentials{}, fmt.Errorf("%s key not found in secret %s in %s ns", giteaAdminUsernameKey, repo.Spec.SecretRef.Name, repo.Spec.SecretRef.Namespace)
+	}
+	password, ok := secret.Data[giteaAdminPasswordKey]
+	if !ok {
+		return gitProviderCredentials{}, fmt.Errorf("%s key not found in secret %s in %s ns", giteaAdminPasswordKey, repo.Spec.SecretRef.Name, repo.Spec.SecretRef.Namespace)
+	}
+
+	return gitProviderCredentials{
+		username: string(username),
+		password: string(password),
+	}, nil
+}
+
+func (g *gitHTTPProvider) setProviderCredentials(ctx context.Context, repo *v1alpha1.GitRepository, creds gitProviderCredentials) error {
+	g.creds = creds
+	return nil
+}
+
+func (g *gitHTTPProvider) getRepository(ctx context.Context, repo *v1alpha1.GitRepository) (repoInfo, error) {
+	resp, err := g.do(ctx, http.MethodGet, fmt.Sprintf("%s%s/%s", repo.Spec.Provider.GitURL, githttp.ReposPath, getRepositoryName(*repo)), nil)
+	if err != nil {
+		return repoInfo{}, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return gitHTTPRepoInfo(repo), nil
+	case http.StatusNotFound:
+		return repoInfo{}, notFoundError{}
+	default:
+		return repoInfo{}, fmt.Errorf("getting git repository: unexpected status code %d", resp.StatusCode)
+	}
+}
+
+func (g *gitHTTPProvider) updateRepoContent(
+	ctx context.Context,
+	repo *v1alpha1.GitRepository,
+	repoInfo repoInfo,
+	creds gitProviderCredentials,
+	srcAuth transport.AuthMethod,
+	tmpDir string,
+	repoMap *util.RepoMap,
+) error {
+	switch repo.Spec.Source.Type {
+	case v1alpha1.SourceTypeLocal, v1alpha1.SourceTypeEmbedded:
+		return reconcileLocalRepoContent(ctx, repo, repo.Spec.Source.Path, repoInfo, creds, g.Scheme, g.config, tmpDir, repoMap)
+	case v1alpha1.SourceTypeArchive:
+		return reconcileArchiveRepoContent(ctx, repo, repoInfo, creds, g.Scheme, g.config, tmpDir, repoMap)
+	case v1alpha1.SourceTypeRemote:
+		return reconcileRemoteRepoContent(ctx, repo, repoInfo, creds, srcAuth, tmpDir, repoMap)
+	default:
+		return nil
+	}
+}
+
+func (g *gitHTTPProvider) do(ctx context.Context, method, url string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.SetBasicAuth(g.creds.username, g.creds.password)
+	return g.httpClient.Do(req)
+}
+
+func gitHTTPRepoInfo(repo *v1alpha1.GitRepository) repoInfo {
+	name := getRepositoryName(*repo)
+	return repoInfo{
+		name:                     name,
+		fullName:                 name,
+		cloneUrl:                 fmt.Sprintf("%s/%s.git", repo.Spec.Provider.GitURL, name),
+		internalGitRepositoryUrl: fmt.Sprintf("%s/%s.git", repo.Spec.Provider.InternalGitURL, name),
+	}
+}
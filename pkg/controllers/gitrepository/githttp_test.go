@@ -0,0 +1,62 @@
+package gitrepository
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
+	"github.com/cnoe-io/idpbuilder/pkg/githttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGitHTTPProvider(t *testing.T) {
+	srv := httptest.NewServer(githttp.NewServer(t.TempDir(), "gitAdmin", "secret"))
+	defer srv.Close()
+
+	ctx := context.Background()
+	resource := v1alpha1.GitRepository{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "ns",
+		},
+		Spec: v1alpha1.GitRepositorySpec{
+			Provider: v1alpha1.Provider{
+				Name:           v1alpha1.GitProviderGitHTTP,
+				GitURL:         srv.URL,
+				InternalGitURL: "http://githttp.githttp.svc.cluster.local",
+			},
+		},
+	}
+	expected := repoInfo{
+		name:                     "ns-test",
+		fullName:                 "ns-test",
+		cloneUrl:                 srv.URL + "/ns-test.git",
+		internalGitRepositoryUrl: "http://githttp.githttp.svc.cluster.local/ns-test.git",
+	}
+
+	g := gitHTTPProvider{httpClient: http.DefaultClient}
+	require.NoError(t, g.setProviderCredentials(ctx, &resource, gitProviderCredentials{username: "gitAdmin", password: "wrong"}))
+	_, err := g.createRepository(ctx, &resource)
+	assert.Error(t, err)
+
+	require.NoError(t, g.setProviderCredentials(ctx, &resource, gitProviderCredentials{username: "gitAdmin", password: "secret"}))
+	_, err = g.getRepository(ctx, &resource)
+	assert.ErrorIs(t, err, notFoundError{})
+
+	info, err := g.createRepository(ctx, &resource)
+	require.NoError(t, err)
+	assert.Equal(t, expected, info)
+
+	// creating an existing repository is not an error
+	_, err = g.createRepository(ctx, &resource)
+	assert.NoError(t, err)
+
+	info, err = g.getRepository(ctx, &resource)
+	require.NoError(t, err)
+	assert.Equal(t, expected, info)
+	assert.Equal(t, info.cloneUrl, getFallbackRepositoryURL(&resource, info))
+}
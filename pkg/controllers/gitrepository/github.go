@@ -4,9 +4,13 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"net/url"
+	"slices"
+	"strings"
 
 	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
 	"github.com/cnoe-io/idpbuilder/pkg/util"
+	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/google/go-github/v61/github"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -16,6 +20,7 @@ import (
 
 const (
 	gitHubTokenKey = "token"
+	gitHubHost     = "github.com"
 )
 
 type ghClient struct {
@@ -30,11 +35,26 @@ func (g *ghClient) createRepo(ctx context.Context, owner string, req *github.Rep
 	return g.c.Repositories.Create(ctx, owner, req)
 }
 
+func (g *ghClient) getUsername(ctx context.Context) (string, error) {
+	u, _, err := g.c.Users.Get(ctx, "")
+	if err != nil {
+		return "", err
+	}
+	return u.GetLogin(), nil
+}
+
+func (g *ghClient) replaceTopics(ctx context.Context, owner, repo string, topics []string) error {
+	_, _, err := g.c.Repositories.ReplaceAllTopics(ctx, owner, repo, topics)
+	return err
+}
+
 func (g *ghClient) setToken(token string) error {
 	g.c = g.c.WithAuthToken(token)
 	return nil
 }
 
+// gitHubProvider manages repositories on github.com or GitHub Enterprise. Repositories are created in the
+// organization, or for the token's user when the organization name is empty.
 type gitHubProvider struct {
 	client.Client
 	Scheme       *runtime.Scheme
@@ -43,25 +63,42 @@ type gitHubProvider struct {
 }
 
 func (g *gitHubProvider) createRepository(ctx context.Context, repo *v1alpha1.GitRepository) (repoInfo, error) {
+	settings := repo.Spec.Provider.Repository
+	description := settings.Description
+	if description == "" {
+		description = fmt.Sprintf("created by Git Repository controller for %s in %s namespace", repo.Name, repo.Namespace)
+	}
 	req := github.Repository{
-		Name:    github.String(getRepositoryName(*repo)),
-		Private: github.Bool(true),
+		Name:        github.String(getRepositoryName(*repo)),
+		Description: github.String(description),
+		Private:     github.Bool(settings.Visibility != "public"),
 	}
+	if settings.Visibility != "" {
+		req.Visibility = github.String(settings.Visibility)
+	}
+	// an empty owner creates the repository for the authenticated user
 	r, _, err := g.gitHubClient.createRepo(ctx, getOrganizationName(*repo), &req)
 	if err != nil {
 		return repoInfo{}, fmt.Errorf("creating repo: %w", err)
 	}
 
-	return repoInfo{
-		name:                     *r.Name,
-		cloneUrl:                 *r.CloneURL,
-		internalGitRepositoryUrl: "",
-		fullName:                 *r.FullName,
-	}, nil
+	if err = g.reconcileTopics(ctx, repo, r); err != nil {
+		return repoInfo{}, err
+	}
+	return gitHubRepoInfo(repo, r), nil
 }
 
 func (g *gitHubProvider) getRepository(ctx context.Context, repo *v1alpha1.GitRepository) (repoInfo, error) {
-	r, resp, err := g.gitHubClient.getRepo(ctx, getOrganizationName(*repo), getRepositoryName(*repo))
+	owner := getOrganizationName(*repo)
+	if owner == "" {
+		username, err := g.gitHubClient.getUsername(ctx)
+		if err != nil {
+			return repoInfo{}, fmt.Errorf("getting current user: %w", err)
+		}
+		owner = username
+	}
+
+	r, resp, err := g.gitHubClient.getRepo(ctx, owner, getRepositoryName(*repo))
 	if err != nil {
 		if resp != nil && resp.StatusCode == http.StatusNotFound {
 			return repoInfo{}, notFoundError{}
@@ -70,12 +107,23 @@ func (g *gitHubProvider) getRepository(ctx context.Context, repo *v1alpha1.GitRe
 		}
 	}
 
-	return repoInfo{
-		name:                     *r.Name,
-		cloneUrl:                 *r.CloneURL,
-		internalGitRepositoryUrl: "",
-		fullName:                 *r.FullName,
-	}, nil
+	if err = g.reconcileTopics(ctx, repo, r); err != nil {
+		return repoInfo{}, err
+	}
+	return gitHubRepoInfo(repo, r), nil
+}
+
+// reconcileTopics sets the configured topics. Topics of repositories without configured topics are left alone.
+func (g *gitHubProvider) reconcileTopics(ctx context.Context, repo *v1alpha1.GitRepository, r *github.Repository) error {
+	topics := repo.Spec.Provider.Repository.Topics
+	if len(topics) == 0 || slices.Equal(topics, r.Topics) {
+		return nil
+	}
+	if err := g.gitHubClient.replaceTopics(ctx, r.GetOwner().GetLogin(), r.GetName(), topics); err != nil {
+		return fmt.Errorf("setting repo topics: %w", err)
+	}
+	r.Topics = topics
+	return nil
 }
 
 func (g *gitHubProvider) getProviderCredentials(ctx context.Context, repo *v1alpha1.GitRepository) (gitProviderCredentials, error) {
@@ -90,7 +138,7 @@ func (g *gitHubProvider) getProviderCredentials(ctx context.Context, repo *v1alp
 
 	token, ok := secret.Data[gitHubTokenKey]
 	if !ok {
-		return gitProviderCredentials{}, fmt.Errorf("%s key not found in secret %s in %s ns", giteaAdminUsernameKey, repo.Spec.SecretRef.Name, repo.Spec.SecretRef.Namespace)
+		return gitProviderCredentials{}, fmt.Errorf("%s key not found in secret %s in %s ns", gitHubTokenKey, repo.Spec.SecretRef.Name, repo.Spec.SecretRef.Namespace)
 	}
 
 	return gitProviderCredentials{
@@ -107,14 +155,55 @@ func (g *gitHubProvider) updateRepoContent(
 	repo *v1alpha1.GitRepository,
 	repoInfo repoInfo,
 	creds gitProviderCredentials,
+	srcAuth transport.AuthMethod,
 	tmpDir string,
 	repoMap *util.RepoMap,
 ) error {
-	return reconcileLocalRepoContent(ctx, repo, repoInfo, creds, g.Scheme, g.config, tmpDir, repoMap)
+	switch repo.Spec.Source.Type {
+	case v1alpha1.SourceTypeLocal, v1alpha1.SourceTypeEmbedded:
+		return reconcileLocalRepoContent(ctx, repo, repo.Spec.Source.Path, repoInfo, creds, g.Scheme, g.config, tmpDir, repoMap)
+	case v1alpha1.SourceTypeArchive:
+		return reconcileArchiveRepoContent(ctx, repo, repoInfo, creds, g.Scheme, g.config, tmpDir, repoMap)
+	case v1alpha1.SourceTypeRemote:
+		return reconcileRemoteRepoContent(ctx, repo, repoInfo, creds, srcAuth, tmpDir, repoMap)
+	default:
+		return nil
+	}
 }
 
-func newGitHubClient(httpClient *http.Client) gitHubClient {
-	return &ghClient{
-		c: github.NewClient(httpClient),
+// gitHubRepoInfo returns the urls of the repository. The internal url is derived from the provider's internal url
+// when it is set, e.g. for a GitHub Enterprise server reached through another host within the cluster.
+func gitHubRepoInfo(repo *v1alpha1.GitRepository, r *github.Repository) repoInfo {
+	internalUrl := r.GetCloneURL()
+	if repo.Spec.Provider.InternalGitURL != "" {
+		internalUrl = fmt.Sprintf("%s/%s.git", strings.TrimSuffix(repo.Spec.Provider.InternalGitURL, "/"), r.GetFullName())
+	}
+	return repoInfo{
+		name:                     r.GetName(),
+		cloneUrl:                 r.GetCloneURL(),
+		internalGitRepositoryUrl: internalUrl,
+		fullName:                 r.GetFullName(),
+	}
+}
+
+// newGitHubClient returns a client for github.com, or for GitHub Enterprise when gitURL points to another host.
+func newGitHubClient(httpClient *http.Client, gitURL string) (gitHubClient, error) {
+	c := github.NewClient(httpClient)
+	if gitURL == "" {
+		return &ghClient{c: c}, nil
+	}
+
+	u, err := url.Parse(gitURL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing github url %s: %w", gitURL, err)
+	}
+	if u.Host == gitHubHost || u.Host == "api."+gitHubHost {
+		return &ghClient{c: c}, nil
+	}
+
+	c, err = c.WithEnterpriseURLs(gitURL, gitURL)
+	if err != nil {
+		return nil, fmt.Errorf("configuring github enterprise url %s: %w", gitURL, err)
 	}
+	return &ghClient{c: c}, nil
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
@@ -30,6 +31,16 @@ func (f *fakeGH) createRepo(ctx context.Context, owner string, req *github.Repos
 	return args.Get(0).(*github.Repository), args.Get(1).(*github.Response), args.Error(2)
 }
 
+func (f *fakeGH) getUsername(ctx context.Context) (string, error) {
+	args := f.Called(ctx)
+	return args.String(0), args.Error(1)
+}
+
+func (f *fakeGH) replaceTopics(ctx context.Context, owner, repo string, topics []string) error {
+	args := f.Called(ctx, owner, repo, topics)
+	return args.Error(0)
+}
+
 func (f *fakeGH) setToken(token string) error {
 	return nil
 }
@@ -80,8 +91,9 @@ func TestGitHubCreateRepository(t *testing.T) {
 	}
 
 	expectedInput := &github.Repository{
-		Name:    github.String(getRepositoryName(resource)),
-		Private: github.Bool(true),
+		Name:        github.String(getRepositoryName(resource)),
+		Description: github.String("created by Git Repository controller for test in test namespace"),
+		Private:     github.Bool(true),
 	}
 
 	fakeGH.On("createRepo", ctx, "owner", expectedInput).Return(
@@ -195,3 +207,110 @@ func TestGitHubGetRepository(t *testing.T) {
 	assert.Equal(t, repoInfo{}, resp)
 	fakeGH.AssertExpectations(t)
 }
+
+func TestGitHubUserRepository(t *testing.T) {
+	fakeGH := new(fakeGH)
+	ctx := context.Background()
+	gh := gitHubProvider{
+		Client:       &fakeClient{},
+		gitHubClient: fakeGH,
+	}
+
+	resource := v1alpha1.GitRepository{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "test",
+		},
+		Spec: v1alpha1.GitRepositorySpec{
+			Provider: v1alpha1.Provider{
+				Name: v1alpha1.GitProviderGitHub,
+				Repository: v1alpha1.GitRepositorySettings{
+					Visibility:  "public",
+					Description: "staged packages",
+					Topics:      []string{"idpbuilder"},
+				},
+			},
+		},
+	}
+	created := &github.Repository{
+		Name:     github.String("test-test"),
+		FullName: github.String("me/test-test"),
+		CloneURL: github.String("https://github.com/me/test-test.git"),
+		Owner:    &github.User{Login: github.String("me")},
+	}
+
+	fakeGH.On("getUsername", ctx).Return("me", nil)
+	fakeGH.On("getRepo", ctx, "me", "test-test").Return(
+		&github.Repository{},
+		newResponse(http.Response{StatusCode: http.StatusNotFound}),
+		fmt.Errorf("not found"),
+	)
+	fakeGH.On("createRepo", ctx, "", &github.Repository{
+		Name:        github.String("test-test"),
+		Description: github.String("staged packages"),
+		Private:     github.Bool(false),
+		Visibility:  github.String("public"),
+	}).Return(created, newResponse(http.Response{StatusCode: http.StatusCreated}), nil)
+	fakeGH.On("replaceTopics", ctx, "me", "test-test", []string{"idpbuilder"}).Return(nil).Once()
+
+	_, err := gh.getRepository(ctx, &resource)
+	assert.Equal(t, notFoundError{}, err)
+
+	resp, err := gh.createRepository(ctx, &resource)
+	assert.NoError(t, err)
+	assert.Equal(t, repoInfo{
+		name:                     "test-test",
+		cloneUrl:                 "https://github.com/me/test-test.git",
+		internalGitRepositoryUrl: "https://github.com/me/test-test.git",
+		fullName:                 "me/test-test",
+	}, resp)
+	fakeGH.AssertExpectations(t)
+}
+
+func TestNewGitHubClient(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Write([]byte(`{"name":"repo1","full_name":"owner/repo1"}`))
+	}))
+	defer srv.Close()
+
+	c, err := newGitHubClient(srv.Client(), srv.URL)
+	assert.NoError(t, err)
+	r, _, err := c.getRepo(context.Background(), "owner", "repo1")
+	assert.NoError(t, err)
+	assert.Equal(t, "owner/repo1", r.GetFullName())
+	assert.Equal(t, []string{"/api/v3/repos/owner/repo1"}, paths)
+
+	c, err = newGitHubClient(nil, "https://github.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://api.github.com/", c.(*ghClient).c.BaseURL.String())
+}
+
+func TestGitHubRepoInfo(t *testing.T) {
+	r := &github.Repository{
+		Name:     github.String("test-test"),
+		FullName: github.String("platform/test-test"),
+		CloneURL: github.String("https://github.example.com/platform/test-test.git"),
+	}
+	resource := &v1alpha1.GitRepository{
+		Spec: v1alpha1.GitRepositorySpec{
+			Provider: v1alpha1.Provider{
+				Name:   v1alpha1.GitProviderGitHub,
+				GitURL: "https://github.example.com",
+			},
+		},
+	}
+
+	info := gitHubRepoInfo(resource, r)
+	assert.Equal(t, "https://github.example.com/platform/test-test.git", info.internalGitRepositoryUrl)
+
+	resource.Spec.Provider.InternalGitURL = "http://github.internal.svc.cluster.local/"
+	info = gitHubRepoInfo(resource, r)
+	assert.Equal(t, repoInfo{
+		name:                     "test-test",
+		cloneUrl:                 "https://github.example.com/platform/test-test.git",
+		internalGitRepositoryUrl: "http://github.internal.svc.cluster.local/platform/test-test.git",
+		fullName:                 "platform/test-test",
+	}, info)
+}
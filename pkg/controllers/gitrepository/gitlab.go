@@ -0,0 +1,287 @@
+package gitrepository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
+	"github.com/cnoe-io/idpbuilder/pkg/util"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	gitLabTokenKey = "token"
+	// gitLabTokenUsername is the user name used for pushes authenticated with a token.
+	gitLabTokenUsername = "oauth2"
+	gitLabAPIPath       = "/api/v4"
+)
+
+// gitLabProject is the subset of the GitLab project API object used by the provider.
+type gitLabProject struct {
+	ID                int      `json:"id"`
+	Name              string   `json:"name"`
+	Path              string   `json:"path"`
+	PathWithNamespace string   `json:"path_with_namespace"`
+	HTTPURLToRepo     string   `json:"http_url_to_repo"`
+	Topics            []string `json:"topics"`
+}
+
+type gitLabCreateProjectOptions struct {
+	Name                 string   `json:"name"`
+	Path                 string   `json:"path"`
+	NamespaceID          int      `json:"namespace_id,omitempty"`
+	Description          string   `json:"description,omitempty"`
+	Visibility           string   `json:"visibility"`
+	DefaultBranch        string   `json:"default_branch"`
+	InitializeWithReadme bool     `json:"initialize_with_readme"`
+	Topics               []string `json:"topics,omitempty"`
+}
+
+// gitLabAPIError is returned for responses of the GitLab API with an unexpected status code.
+type gitLabAPIError struct {
+	statusCode int
+	message    string
+}
+
+func (e gitLabAPIError) Error() string {
+	return fmt.Sprintf("gitlab api returned status code %d: %s", e.statusCode, e.message)
+}
+
+type glClient struct {
+	baseUrl    string
+	token      string
+	httpClient *http.Client
+}
+
+func (g *glClient) getProject(ctx context.Context, path string) (*gitLabProject, error) {
+	p := &gitLabProject{}
+	return p, g.do(ctx, http.MethodGet, "/projects/"+url.PathEscape(path), nil, p)
+}
+
+func (g *glClient) createProject(ctx context.Context, opts gitLabCreateProjectOptions) (*gitLabProject, error) {
+	p := &gitLabProject{}
+	return p, g.do(ctx, http.MethodPost, "/projects", opts, p)
+}
+
+func (g *glClient) setTopics(ctx context.Context, id int, topics []string) error {
+	opts := struct {
+		Topics []string `json:"topics"`
+	}{Topics: topics}
+	return g.do(ctx, http.MethodPut, fmt.Sprintf("/projects/%d", id), opts, &gitLabProject{})
+}
+
+func (g *glClient) getNamespaceID(ctx context.Context, path string) (int, error) {
+	ns := struct {
+		ID int `json:"id"`
+	}{}
+	return ns.ID, g.do(ctx, http.MethodGet, "/namespaces/"+url.PathEscape(path), nil, &ns)
+}
+
+func (g *glClient) getUsername(ctx context.Context) (string, error) {
+	u := struct {
+		Username string `json:"username"`
+	}{}
+	return u.Username, g.do(ctx, http.MethodGet, "/user", nil, &u)
+}
+
+func (g *glClient) setToken(token string) error {
+	g.token = token
+	return nil
+}
+
+func (g *glClient) do(ctx context.Context, method, path string, in, out any) error {
+	var body bytes.Buffer
+	if in != nil {
+		if err := json.NewEncoder(&body).Encode(in); err != nil {
+			return fmt.Errorf("encoding request: %w", err)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, g.baseUrl+gitLabAPIPath+path, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("PRIVATE-TOKEN", g.token)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg := struct {
+			Message any `json:"message"`
+		}{}
+		_ = json.NewDecoder(resp.Body).Decode(&msg)
+		return gitLabAPIError{statusCode: resp.StatusCode, message: fmt.Sprint(msg.Message)}
+	}
+	if err = json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
+
+// gitLabProvider manages repositories as GitLab projects. The organization name is the full path of a group,
+// e.g. platform/idp. Projects are created in the namespace of the token's user when it is empty.
+type gitLabProvider struct {
+	client.Client
+	Scheme       *runtime.Scheme
+	gitLabClient gitLabClient
+	config       v1alpha1.BuildCustomizationSpec
+}
+
+func (g *gitLabProvider) createRepository(ctx context.Context, repo *v1alpha1.GitRepository) (repoInfo, error) {
+	settings := repo.Spec.Provider.Repository
+	description := settings.Description
+	if description == "" {
+		description = fmt.Sprintf("created by Git Repository controller for %s in %s namespace", repo.Name, repo.Namespace)
+	}
+	visibility := settings.Visibility
+	if visibility == "" {
+		visibility = "private"
+	}
+
+	opts := gitLabCreateProjectOptions{
+		Name:                 getRepositoryName(*repo),
+		Path:                 getRepositoryName(*repo),
+		Description:          description,
+		Visibility:           visibility,
+		DefaultBranch:        DefaultBranchName,
+		InitializeWithReadme: true,
+		Topics:               settings.Topics,
+	}
+	if org := getOrganizationName(*repo); org != "" {
+		id, err := g.gitLabClient.getNamespaceID(ctx, org)
+		if err != nil {
+			return repoInfo{}, fmt.Errorf("getting group %s: %w", org, err)
+		}
+		opts.NamespaceID = id
+	}
+
+	p, err := g.gitLabClient.createProject(ctx, opts)
+	if err != nil {
+		return repoInfo{}, fmt.Errorf("creating repo: %w", err)
+	}
+	return gitLabRepoInfo(repo, p), nil
+}
+
+func (g *gitLabProvider) getRepository(ctx context.Context, repo *v1alpha1.GitRepository) (repoInfo, error) {
+	namespace := getOrganizationName(*repo)
+	if namespace == "" {
+		username, err := g.gitLabClient.getUsername(ctx)
+		if err != nil {
+			return repoInfo{}, fmt.Errorf("getting current user: %w", err)
+		}
+		namespace = username
+	}
+
+	p, err := g.gitLabClient.getProject(ctx, fmt.Sprintf("%s/%s", namespace, getRepositoryName(*repo)))
+	if err != nil {
+		var apiErr gitLabAPIError
+		if errors.As(err, &apiErr) && apiErr.statusCode == http.StatusNotFound {
+			return repoInfo{}, notFoundError{}
+		}
+		return repoInfo{}, fmt.Errorf("getting repo: %w", err)
+	}
+
+	if err = g.reconcileTopics(ctx, repo, p); err != nil {
+		return repoInfo{}, err
+	}
+	return gitLabRepoInfo(repo, p), nil
+}
+
+// reconcileTopics sets the configured topics. Topics of projects without configured topics are left alone.
+func (g *gitLabProvider) reconcileTopics(ctx context.Context, repo *v1alpha1.GitRepository, p *gitLabProject) error {
+	topics := repo.Spec.Provider.Repository.Topics
+	if len(topics) == 0 || slices.Equal(topics, p.Topics) {
+		return nil
+	}
+	if err := g.gitLabClient.setTopics(ctx, p.ID, topics); err != nil {
+		return fmt.Errorf("setting repo topics: %w", err)
+	}
+	p.Topics = topics
+	return nil
+}
+
+func (g *gitLabProvider) getProviderCredentials(ctx context.Context, repo *v1alpha1.GitRepository) (gitProviderCredentials, error) {
+	var secret v1.Secret
+	err := g.Client.Get(ctx, types.NamespacedName{
+		Namespace: repo.Spec.SecretRef.Namespace,
+		Name:      repo.Spec.SecretRef.Name,
+	}, &secret)
+	if err != nil {
+		return gitProviderCredentials{}, err
+	}
+
+	token, ok := secret.Data[gitLabTokenKey]
+	if !ok || len(token) == 0 {
+		return gitProviderCredentials{}, fmt.Errorf("%s key not found in secret %s in %s ns", gitLabTokenKey, repo.Spec.SecretRef.Name, repo.Spec.SecretRef.Namespace)
+	}
+
+	return gitProviderCredentials{
+		username:    gitLabTokenUsername,
+		accessToken: string(token),
+	}, nil
+}
+
+func (g *gitLabProvider) setProviderCredentials(ctx context.Context, repo *v1alpha1.GitRepository, creds gitProviderCredentials) error {
+	return g.gitLabClient.setToken(creds.accessToken)
+}
+
+func (g *gitLabProvider) updateRepoContent(
+	ctx context.Context,
+	repo *v1alpha1.GitRepository,
+	repoInfo repoInfo,
+	creds gitProviderCredentials,
+	srcAuth transport.AuthMethod,
+	tmpDir string,
+	repoMap *util.RepoMap,
+) error {
+	switch repo.Spec.Source.Type {
+	case v1alpha1.SourceTypeLocal, v1alpha1.SourceTypeEmbedded:
+		return reconcileLocalRepoContent(ctx, repo, repo.Spec.Source.Path, repoInfo, creds, g.Scheme, g.config, tmpDir, repoMap)
+	case v1alpha1.SourceTypeArchive:
+		return reconcileArchiveRepoContent(ctx, repo, repoInfo, creds, g.Scheme, g.config, tmpDir, repoMap)
+	case v1alpha1.SourceTypeRemote:
+		return reconcileRemoteRepoContent(ctx, repo, repoInfo, creds, srcAuth, tmpDir, repoMap)
+	default:
+		return nil
+	}
+}
+
+// gitLabRepoInfo returns the urls of the project. The internal url is derived from the provider's internal url
+// instead of http_url_to_repo, which is based on the external_url GitLab is configured with.
+func gitLabRepoInfo(repo *v1alpha1.GitRepository, p *gitLabProject) repoInfo {
+	internalUrl := p.HTTPURLToRepo
+	if repo.Spec.Provider.InternalGitURL != "" {
+		internalUrl = fmt.Sprintf("%s/%s.git", strings.TrimSuffix(repo.Spec.Provider.InternalGitURL, "/"), p.PathWithNamespace)
+	}
+	return repoInfo{
+		name:                     p.Path,
+		cloneUrl:                 p.HTTPURLToRepo,
+		internalGitRepositoryUrl: internalUrl,
+		fullName:                 p.PathWithNamespace,
+	}
+}
+
+func newGitLabClient(baseUrl string, httpClient *http.Client) gitLabClient {
+	return &glClient{
+		baseUrl:    strings.TrimSuffix(baseUrl, "/"),
+		httpClient: httpClient,
+	}
+}
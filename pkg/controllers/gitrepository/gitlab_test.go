@@ -0,0 +1,254 @@
+package gitrepository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// fakeGitLab serves the parts of the GitLab API used by the provider.
+type fakeGitLab struct {
+	token      string
+	username   string
+	namespaces map[string]int
+	projects   map[string]gitLabProject
+	created    []gitLabCreateProjectOptions
+	updated    []gitLabProject
+}
+
+func (f *fakeGitLab) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("PRIVATE-TOKEN") != f.token {
+		w.WriteHeader(http.StatusUnauthorized)
+		_ = json.NewEncoder(w).Encode(map[string]string{"message": "401 Unauthorized"})
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.EscapedPath(), gitLabAPIPath)
+	switch {
+	case r.Method == http.MethodGet && path == "/user":
+		_ = json.NewEncoder(w).Encode(map[string]string{"username": f.username})
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "/namespaces/"):
+		name, _ := url.PathUnescape(strings.TrimPrefix(path, "/namespaces/"))
+		id, ok := f.namespaces[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]int{"id": id})
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "/projects/"):
+		name, _ := url.PathUnescape(strings.TrimPrefix(path, "/projects/"))
+		p, ok := f.projects[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string]string{"message": "404 Project Not Found"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(p)
+	case r.Method == http.MethodPost && path == "/projects":
+		var opts gitLabCreateProjectOptions
+		_ = json.NewDecoder(r.Body).Decode(&opts)
+		f.created = append(f.created, opts)
+		ns := f.username
+		for k, v := range f.namespaces {
+			if v == opts.NamespaceID {
+				ns = k
+			}
+		}
+		p := gitLabProject{
+			ID:                len(f.projects) + 1,
+			Name:              opts.Name,
+			Path:              opts.Path,
+			PathWithNamespace: fmt.Sprintf("%s/%s", ns, opts.Path),
+			HTTPURLToRepo:     fmt.Sprintf("https://gitlab.example.com/%s/%s.git", ns, opts.Path),
+			Topics:            opts.Topics,
+		}
+		f.projects[p.PathWithNamespace] = p
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(p)
+	case r.Method == http.MethodPut && strings.HasPrefix(path, "/projects/"):
+		var opts struct {
+			Topics []string `json:"topics"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&opts)
+		for k, p := range f.projects {
+			if fmt.Sprint(p.ID) == strings.TrimPrefix(path, "/projects/") {
+				p.Topics = opts.Topics
+				f.projects[k] = p
+				f.updated = append(f.updated, p)
+				_ = json.NewEncoder(w).Encode(p)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newGitLabTestProvider(t *testing.T) (*gitLabProvider, *fakeGitLab, string) {
+	fake := &fakeGitLab{
+		token:      "glpat-token",
+		username:   "root",
+		namespaces: map[string]int{"platform/idp": 7},
+		projects:   map[string]gitLabProject{},
+	}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	g := &gitLabProvider{gitLabClient: newGitLabClient(srv.URL+"/", http.DefaultClient)}
+	return g, fake, srv.URL
+}
+
+func TestGitLabRepository(t *testing.T) {
+	ctx := context.Background()
+	g, fake, baseURL := newGitLabTestProvider(t)
+
+	resource := &v1alpha1.GitRepository{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "ns",
+		},
+		Spec: v1alpha1.GitRepositorySpec{
+			Provider: v1alpha1.Provider{
+				Name:             v1alpha1.GitProviderGitLab,
+				GitURL:           baseURL,
+				InternalGitURL:   "http://gitlab-webservice-default.gitlab.svc.cluster.local:8181/",
+				OrganizationName: "platform/idp",
+			},
+		},
+	}
+	expected := repoInfo{
+		name:                     "ns-test",
+		cloneUrl:                 "https://gitlab.example.com/platform/idp/ns-test.git",
+		internalGitRepositoryUrl: "http://gitlab-webservice-default.gitlab.svc.cluster.local:8181/platform/idp/ns-test.git",
+		fullName:                 "platform/idp/ns-test",
+	}
+
+	require.NoError(t, g.setProviderCredentials(ctx, resource, gitProviderCredentials{accessToken: "wrong"}))
+	_, err := g.getRepository(ctx, resource)
+	assert.ErrorContains(t, err, "status code 401")
+
+	require.NoError(t, g.setProviderCredentials(ctx, resource, gitProviderCredentials{accessToken: "glpat-token"}))
+	_, err = g.getRepository(ctx, resource)
+	assert.ErrorIs(t, err, notFoundError{})
+
+	info, err := g.createRepository(ctx, resource)
+	require.NoError(t, err)
+	assert.Equal(t, expected, info)
+	require.Len(t, fake.created, 1)
+	assert.Equal(t, 7, fake.created[0].NamespaceID)
+	assert.Equal(t, "private", fake.created[0].Visibility)
+	assert.True(t, fake.created[0].InitializeWithReadme)
+
+	info, err = g.getRepository(ctx, resource)
+	require.NoError(t, err)
+	assert.Equal(t, expected, info)
+
+	// without a group, the project is in the namespace of the token's user
+	resource.Spec.Provider.OrganizationName = ""
+	resource.Spec.Provider.InternalGitURL = ""
+	_, err = g.getRepository(ctx, resource)
+	assert.ErrorIs(t, err, notFoundError{})
+	info, err = g.createRepository(ctx, resource)
+	require.NoError(t, err)
+	assert.Equal(t, 0, fake.created[1].NamespaceID)
+	assert.Equal(t, "root/ns-test", info.fullName)
+	assert.Equal(t, info.cloneUrl, info.internalGitRepositoryUrl)
+
+	resource.Spec.Provider.OrganizationName = "missing"
+	_, err = g.createRepository(ctx, resource)
+	assert.ErrorContains(t, err, "getting group missing")
+}
+
+func TestGitLabRepositorySettings(t *testing.T) {
+	ctx := context.Background()
+	g, fake, baseURL := newGitLabTestProvider(t)
+	require.NoError(t, g.setProviderCredentials(ctx, nil, gitProviderCredentials{accessToken: "glpat-token"}))
+
+	resource := &v1alpha1.GitRepository{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "ns",
+		},
+		Spec: v1alpha1.GitRepositorySpec{
+			Provider: v1alpha1.Provider{
+				Name:             v1alpha1.GitProviderGitLab,
+				GitURL:           baseURL,
+				OrganizationName: "platform/idp",
+				Repository: v1alpha1.GitRepositorySettings{
+					Visibility:  "internal",
+					Description: "staged packages",
+					Topics:      []string{"idpbuilder"},
+				},
+			},
+		},
+	}
+
+	_, err := g.createRepository(ctx, resource)
+	require.NoError(t, err)
+	require.Len(t, fake.created, 1)
+	assert.Equal(t, "internal", fake.created[0].Visibility)
+	assert.Equal(t, "staged packages", fake.created[0].Description)
+	assert.Equal(t, []string{"idpbuilder"}, fake.created[0].Topics)
+
+	// topics are only updated when they differ from the configured ones
+	_, err = g.getRepository(ctx, resource)
+	require.NoError(t, err)
+	assert.Empty(t, fake.updated)
+
+	resource.Spec.Provider.Repository.Topics = []string{"idpbuilder", "packages"}
+	_, err = g.getRepository(ctx, resource)
+	require.NoError(t, err)
+	require.Len(t, fake.updated, 1)
+	assert.Equal(t, []string{"idpbuilder", "packages"}, fake.projects["platform/idp/ns-test"].Topics)
+
+	resource.Spec.Provider.Repository.Topics = nil
+	_, err = g.getRepository(ctx, resource)
+	require.NoError(t, err)
+	assert.Len(t, fake.updated, 1)
+}
+
+func TestGitLabGetProviderCredentials(t *testing.T) {
+	ctx := context.Background()
+	fakeK8sClient := new(fakeKubeClient)
+	g := gitLabProvider{Client: fakeK8sClient}
+
+	resource := v1alpha1.GitRepository{
+		Spec: v1alpha1.GitRepositorySpec{
+			SecretRef: v1alpha1.SecretReference{
+				Name:      "test",
+				Namespace: "testNS",
+			},
+		},
+	}
+	fakeK8sClient.On("Get", ctx, types.NamespacedName{
+		Namespace: "testNS",
+		Name:      "test",
+	}, &v1.Secret{}, []client.GetOption(nil)).Run(func(args mock.Arguments) {
+		sec := args.Get(2).(*v1.Secret)
+		sec.Data = map[string][]byte{gitLabTokenKey: []byte("glpat-token")}
+	}).Return(nil)
+
+	creds, err := g.getProviderCredentials(ctx, &resource)
+	require.NoError(t, err)
+	assert.Equal(t, gitProviderCredentials{username: gitLabTokenUsername, accessToken: "glpat-token"}, creds)
+
+	auth, _ := getBasicAuth(creds)
+	assert.Equal(t, gitLabTokenUsername, auth.Username)
+	assert.Equal(t, "glpat-token", auth.Password)
+	fakeK8sClient.AssertExpectations(t)
+}
@@ -0,0 +1,169 @@
+package gitrepository
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
+	"github.com/cnoe-io/idpbuilder/globals"
+	"github.com/cnoe-io/idpbuilder/pkg/util"
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	argoCDSecretTypeLabel      = "argocd.argoproj.io/secret-type"
+	argoCDSecretTypeRepository = "repository"
+
+	// lfsRepositoryFinalizer removes the Argo CD repository secret of a GitRepository when it is deleted.
+	// An owner reference cannot be used because the secret is in the argocd namespace.
+	lfsRepositoryFinalizer = "idpbuilder.cnoe.io/argocd-lfs-repository"
+)
+
+// replicateLFSObjects makes the content of LFS pointer files in the target worktree available to the target repository.
+// Objects are downloaded from the source repository's LFS server into the source clone,
+// then pushed to the target repository's LFS server or written over the pointer files depending on the LFS mode.
+func replicateLFSObjects(ctx context.Context, srcRepo v1alpha1.RemoteRepositorySpec, srcAuth transport.AuthMethod, srcCloneDir string,
+	tgtWT billy.Filesystem, tgtUrls []string, creds gitProviderCredentials,
+) error {
+	logger := log.FromContext(ctx)
+
+	pointers, err := util.FindLFSPointers(tgtWT, ".")
+	if err != nil {
+		return fmt.Errorf("finding lfs pointer files: %w", err)
+	}
+	if len(pointers) == 0 {
+		return nil
+	}
+
+	objects := uniqueLFSObjects(pointers)
+	objectsDir := filepath.Join(srcCloneDir, git.GitDirName, "lfs", "objects")
+
+	src, err := util.NewLFSClient(srcRepo.Url, srcAuth, false)
+	if err != nil {
+		return err
+	}
+	logger.V(1).Info("downloading lfs objects", "repoUrl", srcRepo.Url, "count", len(objects))
+	if err = src.Download(ctx, objects, objectsDir); err != nil {
+		return err
+	}
+
+	switch srcRepo.LFS {
+	case v1alpha1.LFSModeMaterialize:
+		for p, ptr := range pointers {
+			if err = util.CopyLFSObject(objectsDir, ptr, tgtWT, p); err != nil {
+				return fmt.Errorf("materializing %s: %w", p, err)
+			}
+		}
+		return nil
+	case v1alpha1.LFSModePush:
+		auth, err := getBasicAuth(creds)
+		if err != nil {
+			return fmt.Errorf("getting basic auth: %w", err)
+		}
+		// same as cloning, the fallback url is used when the target is not reachable through its clone url
+		for i := range tgtUrls {
+			var tgt *util.LFSClient
+			tgt, err = util.NewLFSClient(tgtUrls[i], &auth, true)
+			if err != nil {
+				return err
+			}
+			logger.V(1).Info("uploading lfs objects", "repoUrl", tgtUrls[i], "count", len(objects))
+			err = tgt.Upload(ctx, objects, objectsDir)
+			if err == nil {
+				return nil
+			}
+		}
+		return err
+	default:
+		return fmt.Errorf("unsupported lfs mode %s", srcRepo.LFS)
+	}
+}
+
+func uniqueLFSObjects(pointers map[string]util.LFSPointer) []util.LFSPointer {
+	seen := make(map[string]struct{}, len(pointers))
+	out := make([]util.LFSPointer, 0, len(pointers))
+	for _, p := range pointers {
+		if _, ok := seen[p.Oid]; ok {
+			continue
+		}
+		seen[p.Oid] = struct{}{}
+		out = append(out, p)
+	}
+	return out
+}
+
+// lfsRepositorySecretName returns the name of the Argo CD repository secret of the GitRepository.
+// Namespaces cannot contain dots, so the name is unique across namespaces.
+func lfsRepositorySecretName(repo *v1alpha1.GitRepository) string {
+	return fmt.Sprintf("%s.%s-lfs", repo.Namespace, repo.Name)
+}
+
+// reconcileArgoCDLFSRepository declares the repository to Argo CD with LFS enabled.
+// Without it, Argo CD renders the pointer files of objects pushed to the LFS store.
+func (r *RepositoryReconciler) reconcileArgoCDLFSRepository(ctx context.Context, repo *v1alpha1.GitRepository, repoUrl string) error {
+	if err := r.updateFinalizers(ctx, repo, controllerutil.AddFinalizer); err != nil {
+		return err
+	}
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      lfsRepositorySecretName(repo),
+			Namespace: globals.ArgoCDNamespace,
+		},
+	}
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, secret, func() error {
+		if secret.Labels == nil {
+			secret.Labels = map[string]string{}
+		}
+		secret.Labels[argoCDSecretTypeLabel] = argoCDSecretTypeRepository
+		secret.Data = map[string][]byte{
+			"type":      []byte("git"),
+			"url":       []byte(repoUrl),
+			"enableLfs": []byte("true"),
+		}
+		return nil
+	})
+	return err
+}
+
+// deleteArgoCDLFSRepository deletes the Argo CD repository secret created by reconcileArgoCDLFSRepository.
+func (r *RepositoryReconciler) deleteArgoCDLFSRepository(ctx context.Context, repo *v1alpha1.GitRepository) error {
+	if !controllerutil.ContainsFinalizer(repo, lfsRepositoryFinalizer) {
+		return nil
+	}
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      lfsRepositorySecretName(repo),
+			Namespace: globals.ArgoCDNamespace,
+		},
+	}
+	if err := r.Client.Delete(ctx, secret); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("deleting argocd repository secret: %w", err)
+	}
+	return r.updateFinalizers(ctx, repo, controllerutil.RemoveFinalizer)
+}
+
+// updateFinalizers adds or removes the finalizer without overwriting the status of repo, which is updated at the
+// end of the reconcile.
+func (r *RepositoryReconciler) updateFinalizers(ctx context.Context, repo *v1alpha1.GitRepository,
+	update func(client.Object, string) bool,
+) error {
+	obj := repo.DeepCopy()
+	if !update(obj, lfsRepositoryFinalizer) {
+		return nil
+	}
+	if err := r.Client.Update(ctx, obj); err != nil {
+		return fmt.Errorf("updating finalizers: %w", err)
+	}
+	repo.Finalizers = obj.Finalizers
+	repo.ResourceVersion = obj.ResourceVersion
+	return nil
+}
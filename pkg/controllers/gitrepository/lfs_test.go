@@ -0,0 +1,56 @@
+package gitrepository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
+	"github.com/cnoe-io/idpbuilder/globals"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestArgoCDLFSRepository(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.NoError(t, v1.AddToScheme(scheme))
+	require.NoError(t, v1alpha1.AddToScheme(scheme))
+
+	repos := []*v1alpha1.GitRepository{
+		{ObjectMeta: metav1.ObjectMeta{Name: "b-c", Namespace: "a"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "c", Namespace: "a-b"}},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(repos[0], repos[1]).Build()
+	r := RepositoryReconciler{Client: c}
+
+	for i := range repos {
+		require.NoError(t, r.reconcileArgoCDLFSRepository(ctx, repos[i], "http://git.example.com/repo.git"))
+		assert.Contains(t, repos[i].Finalizers, lfsRepositoryFinalizer)
+	}
+	assert.NotEqual(t, lfsRepositorySecretName(repos[0]), lfsRepositorySecretName(repos[1]))
+
+	var secrets v1.SecretList
+	require.NoError(t, c.List(ctx, &secrets))
+	assert.Len(t, secrets.Items, 2)
+
+	// deleting the GitRepository deletes the secret and releases the finalizer
+	require.NoError(t, c.Delete(ctx, repos[0]))
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "b-c", Namespace: "a"}})
+	require.NoError(t, err)
+	err = c.Get(ctx, types.NamespacedName{Name: lfsRepositorySecretName(repos[0]), Namespace: globals.ArgoCDNamespace}, &v1.Secret{})
+	assert.True(t, k8serrors.IsNotFound(err))
+	err = c.Get(ctx, types.NamespacedName{Name: "b-c", Namespace: "a"}, &v1alpha1.GitRepository{})
+	assert.True(t, k8serrors.IsNotFound(err))
+
+	require.NoError(t, r.deleteArgoCDLFSRepository(ctx, repos[1]))
+	assert.Empty(t, repos[1].Finalizers)
+	require.NoError(t, c.List(ctx, &secrets))
+	assert.Empty(t, secrets.Items)
+}
@@ -3,19 +3,29 @@ package localbuild
 import (
 	"context"
 	"embed"
+	"fmt"
+
+	argov1alpha1 "github.com/cnoe-io/argocd-api/api/argo/application/v1alpha1"
 	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
 	"github.com/cnoe-io/idpbuilder/globals"
 	"github.com/cnoe-io/idpbuilder/pkg/k8s"
+	"github.com/cnoe-io/idpbuilder/pkg/resources/localbuild"
+	"github.com/cnoe-io/idpbuilder/pkg/util"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
 //go:embed resources/argo/*
 var installArgoFS embed.FS
 
 func RawArgocdInstallResources(templateData any, config v1alpha1.PackageCustomization, scheme *runtime.Scheme) ([][]byte, error) {
-	return k8s.BuildCustomizedManifests(config.FilePath, "resources/argo", installArgoFS, scheme, templateData)
+	return k8s.BuildCustomizedManifests(config.Files(), "resources/argo", installArgoFS, scheme, templateData)
 }
 
 func (r *LocalbuildReconciler) ReconcileArgo(ctx context.Context, req ctrl.Request, resource *v1alpha1.Localbuild) (ctrl.Result, error) {
@@ -56,3 +66,102 @@ func (r *LocalbuildReconciler) ReconcileArgo(ctx context.Context, req ctrl.Reque
 	resource.Status.ArgoCD.Available = true
 	return ctrl.Result{}, nil
 }
+
+// argoCDEngine syncs packages with Argo CD Applications and ApplicationSets.
+type argoCDEngine struct{}
+
+func (argoCDEngine) install(ctx context.Context, r *LocalbuildReconciler, req ctrl.Request, resource *v1alpha1.Localbuild) (ctrl.Result, error) {
+	return r.ReconcileArgo(ctx, req, resource)
+}
+
+func (argoCDEngine) isApplication(gvk *schema.GroupVersionKind) bool {
+	return isSupportedArgoCDTypes(gvk)
+}
+
+func (argoCDEngine) setPackageSpec(spec *v1alpha1.CustomPackageSpec, o *unstructured.Unstructured, filePath string) {
+	spec.ArgoCD = v1alpha1.ArgoCDPackageSpec{
+		ApplicationFile: filePath,
+		Name:            o.GetName(),
+		Namespace:       o.GetNamespace(),
+		Type:            o.GetKind(),
+	}
+	spec.DependsOn = util.GetPackageDependencies(o.GetAnnotations())
+}
+
+func (argoCDEngine) reconcileEmbeddedApp(ctx context.Context, r *LocalbuildReconciler, resource *v1alpha1.Localbuild, appName, repoURL string) error {
+	app := &argov1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      appName,
+			Namespace: globals.ArgoCDNamespace,
+		},
+	}
+
+	util.SetPackageLabels(app)
+
+	if err := controllerutil.SetControllerReference(resource, app, r.Scheme); err != nil {
+		return err
+	}
+
+	err := r.Client.Get(ctx, client.ObjectKeyFromObject(app), app)
+	if err != nil && k8serrors.IsNotFound(err) {
+		localbuild.SetApplicationSpec(
+			app,
+			repoURL,
+			".",
+			defaultArgoCDProjectName,
+			appName,
+			nil,
+		)
+		err = r.Client.Create(ctx, app)
+		if err != nil {
+			return fmt.Errorf("creating %s app CR: %w", appName, err)
+		}
+	}
+
+	localbuild.SetApplicationSpec(
+		app,
+		repoURL,
+		".",
+		defaultArgoCDProjectName,
+		appName,
+		nil,
+	)
+	err = r.Client.Update(ctx, app)
+	if err != nil {
+		return fmt.Errorf("updating argoapp: %w", err)
+	}
+	return nil
+}
+
+func (argoCDEngine) coreAppsHealthy(ctx context.Context, r *LocalbuildReconciler, enabled map[string]bool) (bool, error) {
+	opts, err := corePackageListOptions()
+	if err != nil {
+		return false, err
+	}
+	apps := argov1alpha1.ApplicationList{}
+	err = r.Client.List(ctx, &apps, opts)
+	if err != nil {
+		return false, fmt.Errorf("listing core packages: %w", err)
+	}
+
+	for _, app := range apps.Items {
+		// apps of core packages disabled since they were created are not synced anymore
+		if !enabled[app.Name] {
+			continue
+		}
+		if app.Status.Health.Status != "Healthy" {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (argoCDEngine) refresh(ctx context.Context, r *LocalbuildReconciler) error {
+	if err := r.requestArgoCDAppRefresh(ctx); err != nil {
+		return fmt.Errorf("failed requesting argocd application refresh: %w", err)
+	}
+	if err := r.requestArgoCDAppSetRefresh(ctx); err != nil {
+		return fmt.Errorf("failed requesting argocd application set refresh: %w", err)
+	}
+	return nil
+}
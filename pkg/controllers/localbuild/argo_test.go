@@ -54,12 +54,12 @@ func TestGetRawInstallResources(t *testing.T) {
 	if err != nil {
 		t.Fatalf("GetRawInstallResources() error: %v", err)
 	}
-	if len(resources) != 2 {
-		t.Fatalf("GetRawInstallResources() resources len != 2, got %d", len(resources))
+	if len(resources) != 3 {
+		t.Fatalf("GetRawInstallResources() resources len != 3, got %d", len(resources))
 	}
 
 	resourcePrefix := "# UCP ARGO INSTALL RESOURCES\n"
-	checkPrefix := resources[1][0:len(resourcePrefix)]
+	checkPrefix := resources[2][0:len(resourcePrefix)]
 	if resourcePrefix != string(checkPrefix) {
 		t.Fatalf("GetRawInstallResources() expected 1 resource with prefix %q, got %q", resourcePrefix, checkPrefix)
 	}
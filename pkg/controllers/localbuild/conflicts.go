@@ -0,0 +1,52 @@
+package localbuild
+
+import (
+	"sort"
+
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
+)
+
+// packagePriority returns the explicit priority of the package location or, when it is not set, the index of the package.
+func packagePriority(resource *v1alpha1.Localbuild, location string, index int) int {
+	if p, ok := resource.Spec.PackageConfigs.CustomPackagePriorities[location]; ok {
+		return p
+	}
+	return index
+}
+
+// recordPackageCandidate records that the package at sourcePath defines the ArgoCD application.
+func (r *LocalbuildReconciler) recordPackageCandidate(appName, sourcePath string, priority int) {
+	if r.packageCandidates == nil {
+		r.packageCandidates = map[string][]v1alpha1.PackageCandidate{}
+	}
+	for _, c := range r.packageCandidates[appName] {
+		if c.SourcePath == sourcePath {
+			return
+		}
+	}
+	r.packageCandidates[appName] = append(r.packageCandidates[appName], v1alpha1.PackageCandidate{SourcePath: sourcePath, Priority: priority})
+}
+
+// packageConflicts returns the applications defined by more than one package, ordered by application name.
+func packageConflicts(candidates map[string][]v1alpha1.PackageCandidate) []v1alpha1.PackageConflict {
+	var out []v1alpha1.PackageConflict
+	for appName, c := range candidates {
+		if len(c) < 2 {
+			continue
+		}
+		sorted := append([]v1alpha1.PackageCandidate{}, c...)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			if sorted[i].Priority != sorted[j].Priority {
+				return sorted[i].Priority > sorted[j].Priority
+			}
+			return sorted[i].SourcePath < sorted[j].SourcePath
+		})
+		conflict := v1alpha1.PackageConflict{AppName: appName, Candidates: sorted}
+		if sorted[0].Priority > sorted[1].Priority {
+			conflict.Winner = sorted[0].SourcePath
+		}
+		out = append(out, conflict)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].AppName < out[j].AppName })
+	return out
+}
@@ -0,0 +1,45 @@
+package localbuild
+
+import (
+	"testing"
+
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackagePriority(t *testing.T) {
+	resource := &v1alpha1.Localbuild{}
+	assert.Equal(t, 2, packagePriority(resource, "/pkgs/base", 2))
+
+	resource.Spec.PackageConfigs.CustomPackagePriorities = map[string]int{"/pkgs/base": 10}
+	assert.Equal(t, 10, packagePriority(resource, "/pkgs/base", 2))
+	assert.Equal(t, 1, packagePriority(resource, "/pkgs/other", 1))
+}
+
+func TestPackageConflicts(t *testing.T) {
+	r := &LocalbuildReconciler{}
+	r.recordPackageCandidate("app", "/pkgs/base", 0)
+	r.recordPackageCandidate("app", "/pkgs/overlay", 10)
+	r.recordPackageCandidate("app", "/pkgs/overlay", 10)
+	r.recordPackageCandidate("single", "/pkgs/base", 0)
+	r.recordPackageCandidate("tied", "/pkgs/a", 1)
+	r.recordPackageCandidate("tied", "https://github.com/org/repo//b", 1)
+
+	assert.Equal(t, []v1alpha1.PackageConflict{
+		{
+			AppName: "app",
+			Winner:  "/pkgs/overlay",
+			Candidates: []v1alpha1.PackageCandidate{
+				{SourcePath: "/pkgs/overlay", Priority: 10},
+				{SourcePath: "/pkgs/base", Priority: 0},
+			},
+		},
+		{
+			AppName: "tied",
+			Candidates: []v1alpha1.PackageCandidate{
+				{SourcePath: "/pkgs/a", Priority: 1},
+				{SourcePath: "https://github.com/org/repo//b", Priority: 1},
+			},
+		},
+	}, packageConflicts(r.packageCandidates))
+}
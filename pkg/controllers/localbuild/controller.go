@@ -10,18 +10,21 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/Masterminds/semver/v3"
 	argocdapp "github.com/cnoe-io/argocd-api/api/argo/application"
 	argov1alpha1 "github.com/cnoe-io/argocd-api/api/argo/application/v1alpha1"
 	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
 	"github.com/cnoe-io/idpbuilder/globals"
-	"github.com/cnoe-io/idpbuilder/pkg/resources/localbuild"
 	"github.com/cnoe-io/idpbuilder/pkg/util"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -30,6 +33,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/selection"
 	"k8s.io/client-go/kubernetes/scheme"
+	"oras.land/oras-go/v2/registry/remote"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -60,6 +64,11 @@ type LocalbuildReconciler struct {
 	Config         v1alpha1.BuildCustomizationSpec
 	TempDir        string
 	RepoMap        *util.RepoMap
+	GitAuth        *util.GitAuthConfig
+	// RegistryConfig is the path to the docker style config file with credentials for OCI registries.
+	RegistryConfig string
+	// packageCandidates are the packages defining each ArgoCD application, recorded while packages are reconciled.
+	packageCandidates map[string][]v1alpha1.PackageCandidate
 }
 
 type subReconciler func(ctx context.Context, req ctrl.Request, resource *v1alpha1.Localbuild) (ctrl.Result, error)
@@ -101,7 +110,7 @@ func (r *LocalbuildReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		}
 	}
 
-	if r.Config.StaticPassword {
+	if r.Config.StaticPassword && localBuild.Spec.PackageConfigs.Argo.Enabled && localBuild.Spec.BuildCustomization.GitOpsEnginePackageName() == v1alpha1.ArgoCDPackageName {
 		logger.V(1).Info("static password is enabled")
 
 		// Check if the Argocd Initial admin secret exists
@@ -123,7 +132,9 @@ func (r *LocalbuildReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 				logger.V(1).Info(fmt.Sprintf("Argocd admin password change succeeded !"))
 			}
 		}
+	}
 
+	if r.Config.StaticPassword && localBuild.Spec.PackageConfigs.Gitea.Enabled && localBuild.Spec.BuildCustomization.GitServerPackageName() == v1alpha1.GiteaPackageName {
 		// Check if the Gitea credentials secret exists
 		giteaAdminPassword, err := r.extractGiteaAdminSecret(ctx)
 		if err != nil {
@@ -143,7 +154,7 @@ func (r *LocalbuildReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		}
 	}
 
-	logger.V(1).Info("done installing core packages. passing control to argocd")
+	logger.V(1).Info("done installing core packages. passing control to the gitops engine")
 	_, err = r.ReconcileArgoAppsWithGitea(ctx, req, &localBuild)
 	if err != nil {
 		return ctrl.Result{}, err
@@ -158,12 +169,16 @@ func (r *LocalbuildReconciler) installCorePackages(ctx context.Context, req ctrl
 	var wg sync.WaitGroup
 
 	installers := map[string]subReconciler{
-		v1alpha1.IngressNginxPackageName: r.ReconcileNginx,
-		v1alpha1.ArgoCDPackageName:       r.ReconcileArgo,
-		v1alpha1.GiteaPackageName:        r.ReconcileGitea,
+		resource.Spec.BuildCustomization.IngressPackageName():      r.ReconcileIngress,
+		resource.Spec.BuildCustomization.GitOpsEnginePackageName(): r.ReconcileGitOpsEngine,
+		resource.Spec.BuildCustomization.GitServerPackageName():    r.ReconcileGitServer,
 	}
 	logger.V(1).Info("installing core packages")
 	for k, v := range installers {
+		if !resource.Spec.PackageConfigs.CorePackageEnabled(k) {
+			logger.V(1).Info("core package disabled", "name", k)
+			continue
+		}
 		wg.Add(1)
 		name := k
 		inst := v
@@ -183,24 +198,19 @@ func (r *LocalbuildReconciler) installCorePackages(ctx context.Context, req ctrl
 func (r *LocalbuildReconciler) postProcessReconcile(ctx context.Context, req ctrl.Request, resource *v1alpha1.Localbuild) {
 	logger := log.FromContext(ctx)
 
+	resource.Status.ObservedGeneration = resource.GetGeneration()
+	if err := r.Status().Update(ctx, resource); err != nil {
+		logger.Error(err, "Failed to update resource status after reconcile")
+	}
+
 	logger.Info("Checking if we should shutdown")
 	if r.shouldShutdown {
 		logger.Info("Shutting Down")
-		err := r.requestArgoCDAppRefresh(ctx)
-		if err != nil {
-			logger.V(1).Info("failed requesting argocd application refresh", "error", err)
-		}
-		err = r.requestArgoCDAppSetRefresh(ctx)
+		err := newGitOpsEngine(resource.Spec.BuildCustomization).refresh(ctx, r)
 		if err != nil {
-			logger.V(1).Info("failed requesting argocd application set refresh", "error", err)
+			logger.V(1).Info("failed requesting refresh of packages", "error", err)
 		}
 		r.CancelFunc()
-		return
-	}
-
-	resource.Status.ObservedGeneration = resource.GetGeneration()
-	if err := r.Status().Update(ctx, resource); err != nil {
-		logger.Error(err, "Failed to update resource status after reconcile")
 	}
 }
 
@@ -236,23 +246,23 @@ func (r *LocalbuildReconciler) SetupWithManager(mgr ctrl.Manager) error {
 
 func (r *LocalbuildReconciler) ReconcileArgoAppsWithGitea(ctx context.Context, req ctrl.Request, resource *v1alpha1.Localbuild) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
-	logger.Info("installing bootstrap apps to ArgoCD")
+	logger.Info("installing bootstrap apps to the gitops engine")
 
-	// push bootstrap app manifests to Gitea. let ArgoCD take over
-	// will need a way to filter them based on user input
-	bootStrapApps := []string{v1alpha1.ArgoCDPackageName, v1alpha1.IngressNginxPackageName, v1alpha1.GiteaPackageName}
-	for _, n := range bootStrapApps {
+	// push bootstrap app manifests to the git server. let the gitops engine take over
+	for _, n := range bootstrapApps(resource) {
 		result, err := r.reconcileEmbeddedApp(ctx, n, resource)
 		if err != nil {
 			return result, fmt.Errorf("reconciling bootstrap apps %w", err)
 		}
 	}
 
+	r.packageCandidates = nil
+
 	// Process packages in REVERSE order (highest priority first) to avoid creating
 	// lower priority packages first then having to delete them
 	for i := len(resource.Spec.PackageConfigs.CustomPackageDirs) - 1; i >= 0; i-- {
 		s := resource.Spec.PackageConfigs.CustomPackageDirs[i]
-		result, err := r.reconcileCustomPkgDir(ctx, resource, s, i)
+		result, err := r.reconcileCustomPkgDir(ctx, resource, s, s, nil, packagePriority(resource, s, i))
 		if err != nil {
 			return result, err
 		}
@@ -260,7 +270,7 @@ func (r *LocalbuildReconciler) ReconcileArgoAppsWithGitea(ctx context.Context, r
 
 	for i := len(resource.Spec.PackageConfigs.CustomPackageFiles) - 1; i >= 0; i-- {
 		s := resource.Spec.PackageConfigs.CustomPackageFiles[i]
-		result, err := r.reconcileCustomPkgFile(ctx, resource, s, i)
+		result, err := r.reconcileCustomPkgFile(ctx, resource, s, packagePriority(resource, s, i))
 		if err != nil {
 			return result, err
 		}
@@ -268,12 +278,46 @@ func (r *LocalbuildReconciler) ReconcileArgoAppsWithGitea(ctx context.Context, r
 
 	for i := len(resource.Spec.PackageConfigs.CustomPackageUrls) - 1; i >= 0; i-- {
 		s := resource.Spec.PackageConfigs.CustomPackageUrls[i]
-		result, err := r.reconcileCustomPkgUrl(ctx, resource, s, i)
+		result, err := r.reconcileCustomPkgUrl(ctx, resource, s, packagePriority(resource, s, i))
+		if err != nil {
+			return result, err
+		}
+	}
+
+	for i := len(resource.Spec.PackageConfigs.CustomPackageOCIArtifacts) - 1; i >= 0; i-- {
+		s := resource.Spec.PackageConfigs.CustomPackageOCIArtifacts[i]
+		result, err := r.reconcileCustomPkgOCI(ctx, resource, s, packagePriority(resource, s, i))
+		if err != nil {
+			return result, err
+		}
+	}
+
+	for i := len(resource.Spec.PackageConfigs.CustomPackageHelmCharts) - 1; i >= 0; i-- {
+		s := resource.Spec.PackageConfigs.CustomPackageHelmCharts[i]
+		result, err := r.reconcileCustomPkgHelm(ctx, resource, s, packagePriority(resource, s, i))
 		if err != nil {
 			return result, err
 		}
 	}
 
+	for i := len(resource.Spec.PackageConfigs.CustomPackageArchives) - 1; i >= 0; i-- {
+		s := resource.Spec.PackageConfigs.CustomPackageArchives[i]
+		result, err := r.reconcileCustomPkgArchive(ctx, resource, s, packagePriority(resource, s, i))
+		if err != nil {
+			return result, err
+		}
+	}
+
+	resource.Status.PackageConflicts = packageConflicts(r.packageCandidates)
+	if len(resource.Status.PackageConflicts) > 0 {
+		logger.Info("multiple packages define the same applications. run idpbuilder get packages --conflicts for details",
+			"count", len(resource.Status.PackageConflicts))
+		if resource.Spec.PackageConfigs.StrictConflicts {
+			r.shouldShutdown = true
+			return ctrl.Result{}, nil
+		}
+	}
+
 	shutdown, err := r.shouldShutDown(ctx, resource)
 	if err != nil {
 		return ctrl.Result{Requeue: true}, err
@@ -283,59 +327,68 @@ func (r *LocalbuildReconciler) ReconcileArgoAppsWithGitea(ctx context.Context, r
 	return ctrl.Result{}, nil
 }
 
-func (r *LocalbuildReconciler) reconcileEmbeddedApp(ctx context.Context, appName string, resource *v1alpha1.Localbuild) (ctrl.Result, error) {
-	logger := log.FromContext(ctx)
-
-	logger.V(1).Info("Ensuring embedded ArgoCD Application", "name", appName)
-	repo, err := r.reconcileGitRepo(ctx, resource, "embedded", appName, appName, "")
-
-	if err != nil {
-		return ctrl.Result{}, fmt.Errorf("creating %s repo CR: %w", appName, err)
+// bootstrapApps returns the names of the core packages that are managed by the gitops engine.
+func bootstrapApps(resource *v1alpha1.Localbuild) []string {
+	if !resource.Spec.PackageConfigs.EmbeddedArgoApplications.Enabled {
+		return nil
 	}
-
-	app := &argov1alpha1.Application{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      appName,
-			Namespace: globals.ArgoCDNamespace,
-		},
+	var out []string
+	for _, n := range []string{resource.Spec.BuildCustomization.GitOpsEnginePackageName(), resource.Spec.BuildCustomization.IngressPackageName(), resource.Spec.BuildCustomization.GitServerPackageName()} {
+		if resource.Spec.PackageConfigs.CorePackageEnabled(n) {
+			out = append(out, n)
+		}
 	}
+	return out
+}
 
-	util.SetPackageLabels(app)
-
-	if err := controllerutil.SetControllerReference(resource, app, r.Scheme); err != nil {
-		return ctrl.Result{}, err
+// gitServer returns the git server repositories are pushed to. It is the installed Gitea or githttp unless it is disabled.
+func gitServer(resource *v1alpha1.Localbuild) (v1alpha1.Provider, v1alpha1.SecretReference, error) {
+	if !resource.Spec.PackageConfigs.Gitea.Enabled {
+		s := resource.Spec.PackageConfigs.GitServer
+		if s == nil {
+			return v1alpha1.Provider{}, v1alpha1.SecretReference{}, fmt.Errorf("gitea is disabled and no git server is configured")
+		}
+		return s.Provider, s.SecretRef, nil
 	}
 
-	err = r.Client.Get(ctx, client.ObjectKeyFromObject(app), app)
-	if err != nil && k8serrors.IsNotFound(err) {
-		localbuild.SetApplicationSpec(
-			app,
-			repo.Status.InternalGitRepositoryUrl,
-			".",
-			defaultArgoCDProjectName,
-			appName,
-			nil,
-		)
-		err = r.Client.Create(ctx, app)
-		if err != nil {
-			return ctrl.Result{}, fmt.Errorf("creating %s app CR: %w", appName, err)
+	if resource.Spec.BuildCustomization.GitServerPackageName() == v1alpha1.GitHTTPPackageName {
+		provider := v1alpha1.Provider{
+			Name:           v1alpha1.GitProviderGitHTTP,
+			GitURL:         resource.Status.GitHTTP.ExternalURL,
+			InternalGitURL: resource.Status.GitHTTP.InternalURL,
 		}
+		secretRef := v1alpha1.SecretReference{
+			Name:      resource.Status.GitHTTP.AdminUserSecretName,
+			Namespace: resource.Status.GitHTTP.AdminUserSecretNamespace,
+		}
+		return provider, secretRef, nil
 	}
 
-	localbuild.SetApplicationSpec(
-		app,
-		repo.Status.InternalGitRepositoryUrl,
-		".",
-		defaultArgoCDProjectName,
-		appName,
-		nil,
-	)
-	err = r.Client.Update(ctx, app)
+	provider := v1alpha1.Provider{
+		Name:             v1alpha1.GitProviderGitea,
+		GitURL:           resource.Status.Gitea.ExternalURL,
+		InternalGitURL:   resource.Status.Gitea.InternalURL,
+		OrganizationName: v1alpha1.GiteaAdminUserName,
+	}
+	secretRef := v1alpha1.SecretReference{
+		Name:      resource.Status.Gitea.AdminUserSecretName,
+		Namespace: resource.Status.Gitea.AdminUserSecretNamespace,
+	}
+	return provider, secretRef, nil
+}
+
+func (r *LocalbuildReconciler) reconcileEmbeddedApp(ctx context.Context, appName string, resource *v1alpha1.Localbuild) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	logger.V(1).Info("Ensuring embedded application", "name", appName)
+	repo, err := r.reconcileGitRepo(ctx, resource, "embedded", appName, appName, "")
+
 	if err != nil {
-		return ctrl.Result{}, fmt.Errorf("updating argoapp: %w", err)
+		return ctrl.Result{}, fmt.Errorf("creating %s repo CR: %w", appName, err)
 	}
 
-	return ctrl.Result{}, nil
+	err = newGitOpsEngine(resource.Spec.BuildCustomization).reconcileEmbeddedApp(ctx, r, resource, appName, repo.Status.InternalGitRepositoryUrl)
+	return ctrl.Result{}, err
 }
 
 func (r *LocalbuildReconciler) shouldShutDown(ctx context.Context, resource *v1alpha1.Localbuild) (bool, error) {
@@ -351,26 +404,13 @@ func (r *LocalbuildReconciler) shouldShutDown(ctx context.Context, resource *v1a
 	}
 
 	// check if core packages are ready
-	selector := labels.NewSelector()
-	req, err := labels.NewRequirement(v1alpha1.PackageTypeLabelKey, selection.Equals, []string{v1alpha1.PackageTypeLabelCore})
-	if err != nil {
-		return false, fmt.Errorf("building labels with key %s and value %s : %w", v1alpha1.PackageTypeLabelKey, v1alpha1.PackageTypeLabelCore, err)
-	}
-
-	opts := client.ListOptions{
-		LabelSelector: selector.Add(*req),
-		Namespace:     "",
-	}
-	apps := argov1alpha1.ApplicationList{}
-	err = r.Client.List(ctx, &apps, &opts)
-	if err != nil {
-		return false, fmt.Errorf("listing core packages: %w", err)
+	enabled := map[string]bool{}
+	for _, n := range bootstrapApps(resource) {
+		enabled[n] = true
 	}
-
-	for _, app := range apps.Items {
-		if app.Status.Health.Status != "Healthy" {
-			return false, nil
-		}
+	healthy, err := newGitOpsEngine(resource.Spec.BuildCustomization).coreAppsHealthy(ctx, r, enabled)
+	if err != nil || !healthy {
+		return false, err
 	}
 
 	// check if repositories are ready
@@ -441,6 +481,8 @@ func (r *LocalbuildReconciler) reconcileCustomPkg(
 	b []byte,
 	filePath string,
 	remote *util.KustomizeRemote,
+	archive *v1alpha1.ArchiveSpec,
+	values *apiextensionsv1.JSON,
 	priority int,
 	sourcePath string,
 ) error {
@@ -450,10 +492,11 @@ func (r *LocalbuildReconciler) reconcileCustomPkg(
 		return fErr
 	}
 
-	if isSupportedArgoCDTypes(gvk) {
-		kind := o.GetKind()
+	engine := newGitOpsEngine(resource.Spec.BuildCustomization)
+	if engine.isApplication(gvk) {
 		appName := o.GetName()
-		appNS := o.GetNamespace()
+
+		r.recordPackageCandidate(appName, sourcePath, priority)
 
 		// Check if a higher-priority CustomPackage already exists for this app
 		projectNS := globals.GetProjectNamespace(resource.Name)
@@ -464,8 +507,8 @@ func (r *LocalbuildReconciler) reconcileCustomPkg(
 
 		for i := range existingPkgs.Items {
 			existingPkg := &existingPkgs.Items[i]
-			// Check if this package is for the same ArgoCD app
-			if existingPkg.Spec.ArgoCD.Name == appName {
+			// Check if this package is for the same application
+			if existingPkg.Spec.ApplicationName() == appName {
 				// Get existing package's priority
 				existingPriorityStr, exists := existingPkg.ObjectMeta.Annotations[v1alpha1.PackagePriorityAnnotation]
 				if exists {
@@ -508,6 +551,11 @@ func (r *LocalbuildReconciler) reconcileCustomPkg(
 
 		cliStartTime, _ := util.GetCLIStartTimeAnnotationValue(resource.ObjectMeta.Annotations)
 
+		provider, secretRef, gErr := gitServer(resource)
+		if gErr != nil {
+			return gErr
+		}
+
 		_, fErr = controllerutil.CreateOrUpdate(ctx, r.Client, customPkg, func() error {
 			if err := controllerutil.SetControllerReference(resource, customPkg, r.Scheme); err != nil {
 				return err
@@ -521,20 +569,23 @@ func (r *LocalbuildReconciler) reconcileCustomPkg(
 			customPkg.ObjectMeta.Annotations[v1alpha1.PackageSourcePathAnnotation] = sourcePath
 
 			customPkg.Spec = v1alpha1.CustomPackageSpec{
-				Replicate:           true,
-				GitServerURL:        resource.Status.Gitea.ExternalURL,
-				InternalGitServeURL: resource.Status.Gitea.InternalURL,
-				GitServerAuthSecretRef: v1alpha1.SecretReference{
-					Name:      resource.Status.Gitea.AdminUserSecretName,
-					Namespace: resource.Status.Gitea.AdminUserSecretNamespace,
-				},
-				ArgoCD: v1alpha1.ArgoCDPackageSpec{
-					ApplicationFile: filePath,
-					Name:            appName,
-					Namespace:       appNS,
-					Type:            kind,
-				},
+				// only remote packages can be pulled by Argo CD without replicating them
+				Replicate:              remote == nil || remote.Replicate,
+				GitServerURL:           provider.GitURL,
+				InternalGitServeURL:    provider.InternalGitURL,
+				GitServerProvider:      provider.Name,
+				GitServerOrganization:  provider.OrganizationName,
+				GitServerRepository:    provider.Repository,
+				GitServerAuthSecretRef: secretRef,
+				Values:                 values,
+			}
+
+			// the application file is read from the extracted archive by the CustomPackage controller
+			pkgFile := filePath
+			if archive != nil {
+				pkgFile = filepath.Base(filePath)
 			}
+			engine.setPackageSpec(&customPkg.Spec, o, pkgFile)
 
 			if remote != nil {
 				customPkg.Spec.RemoteRepository = v1alpha1.RemoteRepositorySpec{
@@ -542,9 +593,14 @@ func (r *LocalbuildReconciler) reconcileCustomPkg(
 					Ref:             remote.Ref,
 					CloneSubmodules: remote.Submodules,
 					Path:            remote.Path(),
+					LFS:             remote.LFS,
 				}
 			}
 
+			if archive != nil {
+				customPkg.Spec.Archive = *archive
+			}
+
 			return nil
 		})
 		return fErr
@@ -566,11 +622,23 @@ func (r *LocalbuildReconciler) reconcileCustomPkgUrl(ctx context.Context, resour
 		Path:            remote.Path(),
 	}
 
+	auth, err := r.GitAuth.AuthMethod(rs.Url)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("getting credentials for %s: %w", pkgUrl, err)
+	}
+	// the dynamic ref is kept in the CustomPackage spec so that it is re-resolved by the CustomPackage and GitRepository controllers
+	rs, err = util.ResolveRemoteRef(ctx, rs, false, auth)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
 	cloneDir := util.RepoDir(rs.Url, r.TempDir)
 	st := r.RepoMap.LoadOrStore(rs.Url, cloneDir)
-	st.MU.Lock()
-	defer st.MU.Unlock()
-	wt, _, err := util.CloneRemoteRepoToDir(ctx, rs, 1, false, cloneDir, "")
+	if err = st.Lock(); err != nil {
+		return ctrl.Result{}, fmt.Errorf("locking %s: %w", cloneDir, err)
+	}
+	defer st.Unlock()
+	wt, _, err := util.CloneRemoteRepoToDir(ctx, rs, 1, false, cloneDir, "", auth)
 	if err != nil {
 		return ctrl.Result{}, fmt.Errorf("cloning repo, %s: %w", pkgUrl, err)
 	}
@@ -580,22 +648,217 @@ func (r *LocalbuildReconciler) reconcileCustomPkgUrl(ctx context.Context, resour
 		return ctrl.Result{}, fmt.Errorf("getting yaml files from repo, %s: %w", pkgUrl, err)
 	}
 
+	var defaults []byte
+	valuesFile := path.Join(remote.Path(), util.PackageValuesFile)
+	if _, sErr := wt.Stat(valuesFile); sErr == nil {
+		defaults, err = util.ReadWorktreeFile(wt, valuesFile)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	values, err := packageValues(resource, pkgUrl, defaults)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	var docs []packageDocument
 	for _, yamlFile := range yamlFiles {
+		if path.Base(yamlFile) == util.PackageValuesFile {
+			continue
+		}
 		b, fErr := util.ReadWorktreeFile(wt, yamlFile)
 		if fErr != nil {
 			logger.V(1).Info("processing", "file", yamlFile, "err", fErr)
 			continue
 		}
 
-		rErr := r.reconcileCustomPkg(ctx, resource, b, yamlFile, remote, priority, pkgUrl)
-		if rErr != nil {
-			logger.Error(rErr, "reconciling custom pkg", "file", yamlFile, "pkgUrl", pkgUrl)
+		fileDocs, sErr := splitPackageFile(yamlFile, b)
+		if sErr != nil {
+			logger.Error(sErr, "reading yaml documents", "file", yamlFile, "pkgUrl", pkgUrl)
+			continue
 		}
+		docs = append(docs, fileDocs...)
 	}
+
+	r.reconcileCustomPkgDocs(ctx, resource, docs, remote, nil, values, priority, pkgUrl)
 	return ctrl.Result{}, nil
 }
 
-func (r *LocalbuildReconciler) reconcileCustomPkgDir(ctx context.Context, resource *v1alpha1.Localbuild, pkgDir string, priority int) (ctrl.Result, error) {
+// reconcileCustomPkgOCI pulls the OCI artifact when its digest changed and processes its content like a local directory.
+// Helm charts are written as chart packages, see util.WriteHelmChartPackage.
+func (r *LocalbuildReconciler) reconcileCustomPkgOCI(ctx context.Context, resource *v1alpha1.Localbuild, ref string, priority int) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	repo, tag, err := util.NewOCIRepository(ref, r.RegistryConfig)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	desc, err := repo.Resolve(ctx, tag)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("resolving %s: %w", ref, err)
+	}
+
+	pkgDir := util.RepoDir(ref, r.TempDir)
+	st := r.RepoMap.LoadOrStore(ref, pkgDir)
+	if err = st.Lock(); err != nil {
+		return ctrl.Result{}, fmt.Errorf("locking %s: %w", pkgDir, err)
+	}
+	defer st.Unlock()
+
+	_, sErr := os.Stat(pkgDir)
+	if sErr != nil || ociArtifactDigest(resource, ref) != desc.Digest.String() {
+		logger.V(1).Info("pulling oci artifact", "reference", ref, "digest", desc.Digest.String(), "dir", pkgDir)
+		digest, pErr := r.pullOCIArtifact(ctx, repo, tag, ref, desc, pkgDir)
+		if pErr != nil {
+			return ctrl.Result{}, fmt.Errorf("pulling %s: %w", ref, pErr)
+		}
+		setOCIArtifactDigest(resource, ref, digest)
+	}
+
+	return r.reconcileCustomPkgDir(ctx, resource, pkgDir, ref, nil, priority)
+}
+
+func (r *LocalbuildReconciler) pullOCIArtifact(ctx context.Context, repo *remote.Repository, tag, ref string, desc ocispec.Descriptor, pkgDir string) (string, error) {
+	archive, isChart, err := util.FetchOCIHelmChart(ctx, repo, desc)
+	if err != nil {
+		return "", err
+	}
+	if !isChart {
+		return util.PullOCIArtifact(ctx, repo, tag, pkgDir)
+	}
+
+	chartRef, err := util.ParseHelmChartOptions(ref, path.Base(repo.Reference.Repository))
+	if err != nil {
+		return "", err
+	}
+	return desc.Digest.String(), util.WriteHelmChartPackage(archive, chartRef, pkgDir)
+}
+
+// reconcileCustomPkgHelm downloads the chart from the chart repository and processes it as a chart package.
+// The chart repository index is only checked when the version is not exact or the chart was not downloaded yet.
+func (r *LocalbuildReconciler) reconcileCustomPkgHelm(ctx context.Context, resource *v1alpha1.Localbuild, ref string, priority int) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	chartRef, err := util.ParseHelmPackage(ref)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	pkgDir := util.RepoDir(ref, r.TempDir)
+	st := r.RepoMap.LoadOrStore(ref, pkgDir)
+	if err = st.Lock(); err != nil {
+		return ctrl.Result{}, fmt.Errorf("locking %s: %w", pkgDir, err)
+	}
+	defer st.Unlock()
+
+	_, sErr := os.Stat(pkgDir)
+	current := helmChartVersion(resource, ref)
+	_, vErr := semver.StrictNewVersion(chartRef.Version)
+	if sErr != nil || current == "" || vErr != nil {
+		archive, version, dErr := util.DownloadHelmChart(ctx, util.GetHttpClient(), chartRef)
+		if dErr != nil {
+			return ctrl.Result{}, dErr
+		}
+		if sErr != nil || version != current {
+			logger.V(1).Info("writing helm chart package", "reference", ref, "version", version, "dir", pkgDir)
+			if wErr := util.WriteHelmChartPackage(archive, chartRef, pkgDir); wErr != nil {
+				return ctrl.Result{}, wErr
+			}
+			setHelmChartVersion(resource, ref, version)
+		}
+	}
+
+	return r.reconcileCustomPkgDir(ctx, resource, pkgDir, ref, nil, priority)
+}
+
+// reconcileCustomPkgArchive fetches the archive, verifies its checksum and processes its content like a local directory.
+// The archive is only extracted again when its content changed.
+func (r *LocalbuildReconciler) reconcileCustomPkgArchive(ctx context.Context, resource *v1alpha1.Localbuild, pkg string, priority int) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	archive, err := util.ParseArchivePackage(pkg)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	pkgDir := util.RepoDir(archive.Url, r.TempDir)
+	st := r.RepoMap.LoadOrStore(archive.Url, pkgDir)
+	if err = st.Lock(); err != nil {
+		return ctrl.Result{}, fmt.Errorf("locking %s: %w", pkgDir, err)
+	}
+	defer st.Unlock()
+
+	digest, err := util.FetchArchive(ctx, util.GetHttpClient(), archive, pkgDir)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	logger.V(1).Info("fetched archive", "url", archive.Url, "sha256", digest, "dir", pkgDir)
+
+	return r.reconcileCustomPkgDir(ctx, resource, pkgDir, pkg, &archive, priority)
+}
+
+// packageValues merges the default values of the package with the values of all packages and the values of the package
+// at location, in this order of precedence.
+func packageValues(resource *v1alpha1.Localbuild, location string, defaults []byte) (*apiextensionsv1.JSON, error) {
+	values, err := util.ParseValues(defaults)
+	if err != nil {
+		return nil, fmt.Errorf("parsing default values of package %s: %w", location, err)
+	}
+	global, err := util.JSONToValues(resource.Spec.PackageConfigs.CustomPackageValues)
+	if err != nil {
+		return nil, err
+	}
+	values = util.MergeValues(values, global)
+	if v, ok := resource.Spec.PackageConfigs.CustomPackageLocationValues[location]; ok {
+		local, jErr := util.JSONToValues(&v)
+		if jErr != nil {
+			return nil, fmt.Errorf("values of package %s: %w", location, jErr)
+		}
+		values = util.MergeValues(values, local)
+	}
+	return util.ValuesToJSON(values)
+}
+
+func helmChartVersion(resource *v1alpha1.Localbuild, ref string) string {
+	for _, c := range resource.Status.HelmCharts {
+		if c.Reference == ref {
+			return c.Version
+		}
+	}
+	return ""
+}
+
+func setHelmChartVersion(resource *v1alpha1.Localbuild, ref, version string) {
+	for i := range resource.Status.HelmCharts {
+		if resource.Status.HelmCharts[i].Reference == ref {
+			resource.Status.HelmCharts[i].Version = version
+			return
+		}
+	}
+	resource.Status.HelmCharts = append(resource.Status.HelmCharts, v1alpha1.HelmChartStatus{Reference: ref, Version: version})
+}
+
+func ociArtifactDigest(resource *v1alpha1.Localbuild, ref string) string {
+	for _, a := range resource.Status.OCIArtifacts {
+		if a.Reference == ref {
+			return a.Digest
+		}
+	}
+	return ""
+}
+
+func setOCIArtifactDigest(resource *v1alpha1.Localbuild, ref, digest string) {
+	for i := range resource.Status.OCIArtifacts {
+		if resource.Status.OCIArtifacts[i].Reference == ref {
+			resource.Status.OCIArtifacts[i].Digest = digest
+			return
+		}
+	}
+	resource.Status.OCIArtifacts = append(resource.Status.OCIArtifacts, v1alpha1.OCIArtifactStatus{Reference: ref, Digest: digest})
+}
+
+// reconcileCustomPkgDir processes the yaml files in pkgDir. archive is set when pkgDir is an extracted archive.
+func (r *LocalbuildReconciler) reconcileCustomPkgDir(ctx context.Context, resource *v1alpha1.Localbuild, pkgDir, sourcePath string, archive *v1alpha1.ArchiveSpec, priority int) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
 
 	files, err := os.ReadDir(pkgDir)
@@ -603,9 +866,19 @@ func (r *LocalbuildReconciler) reconcileCustomPkgDir(ctx context.Context, resour
 		return ctrl.Result{}, fmt.Errorf("reading dir, %s: %w", pkgDir, err)
 	}
 
+	defaults, err := os.ReadFile(filepath.Join(pkgDir, util.PackageValuesFile))
+	if err != nil && !os.IsNotExist(err) {
+		return ctrl.Result{}, fmt.Errorf("reading default values, %s: %w", pkgDir, err)
+	}
+	values, err := packageValues(resource, sourcePath, defaults)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	var docs []packageDocument
 	for i := range files {
 		file := files[i]
-		if !file.Type().IsRegular() || !util.IsYamlFile(file.Name()) {
+		if !file.Type().IsRegular() || !util.IsYamlFile(file.Name()) || file.Name() == util.PackageValuesFile {
 			continue
 		}
 
@@ -616,18 +889,19 @@ func (r *LocalbuildReconciler) reconcileCustomPkgDir(ctx context.Context, resour
 			continue
 		}
 
-		rErr := r.reconcileCustomPkg(ctx, resource, b, filePath, nil, priority, pkgDir)
-		if rErr != nil {
-			logger.Error(rErr, "reconciling custom pkg", "file", filePath, "pkgDir", pkgDir)
+		fileDocs, sErr := splitPackageFile(filePath, b)
+		if sErr != nil {
+			logger.Error(sErr, "reading yaml documents", "file", filePath, "pkgDir", pkgDir)
+			continue
 		}
+		docs = append(docs, fileDocs...)
 	}
 
+	r.reconcileCustomPkgDocs(ctx, resource, docs, nil, archive, values, priority, sourcePath)
 	return ctrl.Result{}, nil
 }
 
 func (r *LocalbuildReconciler) reconcileCustomPkgFile(ctx context.Context, resource *v1alpha1.Localbuild, pkgFile string, priority int) (ctrl.Result, error) {
-	logger := log.FromContext(ctx)
-
 	file, err := os.Open(pkgFile)
 	defer file.Close()
 	if err != nil {
@@ -652,11 +926,17 @@ func (r *LocalbuildReconciler) reconcileCustomPkgFile(ctx context.Context, resou
 		return ctrl.Result{}, fmt.Errorf("reading file, %s: %w", pkgFile, err)
 	}
 
-	rErr := r.reconcileCustomPkg(ctx, resource, b, pkgFile, nil, priority, pkgFile)
-	if rErr != nil {
-		logger.Error(rErr, "reconciling custom pkg", "file", pkgFile)
+	values, err := packageValues(resource, pkgFile, nil)
+	if err != nil {
+		return ctrl.Result{}, err
 	}
 
+	docs, err := splitPackageFile(pkgFile, b)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	r.reconcileCustomPkgDocs(ctx, resource, docs, nil, nil, values, priority, pkgFile)
 	return ctrl.Result{}, nil
 }
 
@@ -673,6 +953,11 @@ func (r *LocalbuildReconciler) reconcileGitRepo(ctx context.Context, resource *v
 		return nil, err
 	}
 
+	provider, secretRef, err := gitServer(resource)
+	if err != nil {
+		return nil, err
+	}
+
 	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, repo, func() error {
 		if err := controllerutil.SetControllerReference(resource, repo, r.Scheme); err != nil {
 			return err
@@ -687,16 +972,8 @@ func (r *LocalbuildReconciler) reconcileGitRepo(ctx context.Context, resource *v
 			Source: v1alpha1.GitRepositorySource{
 				Type: repoType,
 			},
-			Provider: v1alpha1.Provider{
-				Name:             v1alpha1.GitProviderGitea,
-				GitURL:           resource.Status.Gitea.ExternalURL,
-				InternalGitURL:   resource.Status.Gitea.InternalURL,
-				OrganizationName: v1alpha1.GiteaAdminUserName,
-			},
-			SecretRef: v1alpha1.SecretReference{
-				Name:      resource.Status.Gitea.AdminUserSecretName,
-				Namespace: resource.Status.Gitea.AdminUserSecretNamespace,
-			},
+			Provider:  provider,
+			SecretRef: secretRef,
 		}
 
 		if repoType == v1alpha1.SourceTypeEmbedded {
@@ -707,8 +984,9 @@ func (r *LocalbuildReconciler) reconcileGitRepo(ctx context.Context, resource *v
 		f, ok := resource.Spec.PackageConfigs.CorePackageCustomization[embeddedName]
 		if ok {
 			repo.Spec.Customization = v1alpha1.PackageCustomization{
-				Name:     embeddedName,
-				FilePath: f.FilePath,
+				Name:      embeddedName,
+				FilePath:  f.FilePath,
+				FilePaths: f.FilePaths,
 			}
 		}
 		return nil
@@ -945,6 +1223,15 @@ func (r *LocalbuildReconciler) applyArgoCDAnnotation(ctx context.Context, obj cl
 	return nil
 }
 
+// corePackageListOptions selects the applications of the core packages.
+func corePackageListOptions() (*client.ListOptions, error) {
+	req, err := labels.NewRequirement(v1alpha1.PackageTypeLabelKey, selection.Equals, []string{v1alpha1.PackageTypeLabelCore})
+	if err != nil {
+		return nil, fmt.Errorf("building labels with key %s and value %s : %w", v1alpha1.PackageTypeLabelKey, v1alpha1.PackageTypeLabelCore, err)
+	}
+	return &client.ListOptions{LabelSelector: labels.NewSelector().Add(*req)}, nil
+}
+
 func getCustomPackageName(fileName, appName string) string {
 	s := strings.Split(fileName, ".")
 	return fmt.Sprintf("%s-%s", strings.ToLower(s[0]), appName)
@@ -965,6 +1252,12 @@ func GetEmbeddedRawInstallResources(name string, templateData any, config v1alph
 		return RawGiteaInstallResources(templateData, config, scheme)
 	case v1alpha1.IngressNginxPackageName:
 		return RawNginxInstallResources(templateData, config, scheme)
+	case v1alpha1.GatewayPackageName:
+		return RawGatewayInstallResources(templateData, config, scheme)
+	case v1alpha1.FluxPackageName:
+		return RawFluxInstallResources(templateData, config, scheme)
+	case v1alpha1.GitHTTPPackageName:
+		return RawGitHTTPInstallResources(templateData, config, scheme)
 	default:
 		return nil, fmt.Errorf("unsupported embedded app name %s", name)
 	}
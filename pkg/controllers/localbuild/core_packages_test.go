@@ -0,0 +1,69 @@
+package localbuild
+
+import (
+	"testing"
+
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBootstrapApps(t *testing.T) {
+	resource := &v1alpha1.Localbuild{}
+	resource.Spec.PackageConfigs.Argo.Enabled = true
+	resource.Spec.PackageConfigs.Gitea.Enabled = true
+	resource.Spec.PackageConfigs.Nginx.Enabled = true
+	assert.Empty(t, bootstrapApps(resource))
+
+	resource.Spec.PackageConfigs.EmbeddedArgoApplications.Enabled = true
+	assert.Equal(t, []string{v1alpha1.ArgoCDPackageName, v1alpha1.IngressNginxPackageName, v1alpha1.GiteaPackageName}, bootstrapApps(resource))
+
+	resource.Spec.BuildCustomization.IngressController = v1alpha1.IngressControllerGateway
+	assert.Equal(t, []string{v1alpha1.ArgoCDPackageName, v1alpha1.GatewayPackageName, v1alpha1.GiteaPackageName}, bootstrapApps(resource))
+
+	resource.Spec.PackageConfigs.Nginx.Enabled = false
+	assert.Equal(t, []string{v1alpha1.ArgoCDPackageName, v1alpha1.GiteaPackageName}, bootstrapApps(resource))
+
+	resource.Spec.BuildCustomization.GitOpsEngine = v1alpha1.GitOpsEngineFlux
+	assert.Equal(t, []string{v1alpha1.FluxPackageName, v1alpha1.GiteaPackageName}, bootstrapApps(resource))
+
+	resource.Spec.BuildCustomization.GitServer = v1alpha1.GitServerGitHTTP
+	assert.Equal(t, []string{v1alpha1.FluxPackageName, v1alpha1.GitHTTPPackageName}, bootstrapApps(resource))
+}
+
+func TestGitServer(t *testing.T) {
+	resource := &v1alpha1.Localbuild{}
+	resource.Spec.PackageConfigs.Gitea.Enabled = true
+	resource.Status.Gitea.ExternalURL = "https://gitea.cnoe.localtest.me:8443"
+	resource.Status.Gitea.AdminUserSecretName = "gitea-credential"
+
+	provider, secretRef, err := gitServer(resource)
+	assert.NoError(t, err)
+	assert.Equal(t, v1alpha1.GitProviderGitea, provider.Name)
+	assert.Equal(t, resource.Status.Gitea.ExternalURL, provider.GitURL)
+	assert.Equal(t, v1alpha1.GiteaAdminUserName, provider.OrganizationName)
+	assert.Equal(t, "gitea-credential", secretRef.Name)
+
+	resource.Spec.BuildCustomization.GitServer = v1alpha1.GitServerGitHTTP
+	resource.Status.GitHTTP.ExternalURL = "https://git.cnoe.localtest.me:8443"
+	resource.Status.GitHTTP.InternalURL = "http://githttp.githttp.svc.cluster.local"
+	resource.Status.GitHTTP.AdminUserSecretName = "githttp-credential"
+	provider, secretRef, err = gitServer(resource)
+	assert.NoError(t, err)
+	assert.Equal(t, v1alpha1.GitProviderGitHTTP, provider.Name)
+	assert.Equal(t, resource.Status.GitHTTP.ExternalURL, provider.GitURL)
+	assert.Equal(t, resource.Status.GitHTTP.InternalURL, provider.InternalGitURL)
+	assert.Equal(t, "githttp-credential", secretRef.Name)
+
+	resource.Spec.PackageConfigs.Gitea.Enabled = false
+	_, _, err = gitServer(resource)
+	assert.ErrorContains(t, err, "no git server is configured")
+
+	resource.Spec.PackageConfigs.GitServer = &v1alpha1.GitServerSpec{
+		Provider:  v1alpha1.Provider{Name: v1alpha1.GitProviderGitHub, GitURL: "https://github.com", OrganizationName: "platform"},
+		SecretRef: v1alpha1.SecretReference{Name: "git-server", Namespace: "default"},
+	}
+	provider, secretRef, err = gitServer(resource)
+	assert.NoError(t, err)
+	assert.Equal(t, resource.Spec.PackageConfigs.GitServer.Provider, provider)
+	assert.Equal(t, "git-server", secretRef.Name)
+}
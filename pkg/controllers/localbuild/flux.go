@@ -0,0 +1,184 @@
+package localbuild
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"time"
+
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
+	"github.com/cnoe-io/idpbuilder/globals"
+	"github.com/cnoe-io/idpbuilder/pkg/k8s"
+	"github.com/cnoe-io/idpbuilder/pkg/resources/localbuild"
+	"github.com/cnoe-io/idpbuilder/pkg/util"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const fluxReconcileRequestAnnotation = "reconcile.fluxcd.io/requestedAt"
+
+// resources/flux contains the Flux controllers generated by hack/flux/generate-manifests.sh.
+//
+//go:embed resources/flux/*
+var installFluxFS embed.FS
+
+const fluxGenerateScript = "hack/flux/generate-manifests.sh"
+
+// CheckFluxManifests returns an error if this build of idpbuilder does not embed the Flux controllers.
+func CheckFluxManifests() error {
+	return checkGeneratedManifests(installFluxFS, "resources/flux", fluxGenerateScript)
+}
+
+func RawFluxInstallResources(templateData any, config v1alpha1.PackageCustomization, scheme *runtime.Scheme) ([][]byte, error) {
+	if err := CheckFluxManifests(); err != nil {
+		return nil, err
+	}
+	return k8s.BuildCustomizedManifests(config.Files(), "resources/flux", installFluxFS, scheme, templateData)
+}
+
+func (r *LocalbuildReconciler) ReconcileFlux(ctx context.Context, req ctrl.Request, resource *v1alpha1.Localbuild) (ctrl.Result, error) {
+	flux := EmbeddedInstallation{
+		name:           "Flux",
+		resourcePath:   "resources/flux",
+		resourceFS:     installFluxFS,
+		namespace:      globals.FluxNamespace,
+		generateScript: fluxGenerateScript,
+		monitoredResources: map[string]schema.GroupVersionKind{
+			"source-controller": {
+				Group:   "apps",
+				Version: "v1",
+				Kind:    "Deployment",
+			},
+			"kustomize-controller": {
+				Group:   "apps",
+				Version: "v1",
+				Kind:    "Deployment",
+			},
+			"helm-controller": {
+				Group:   "apps",
+				Version: "v1",
+				Kind:    "Deployment",
+			},
+		},
+	}
+
+	v, ok := resource.Spec.PackageConfigs.CorePackageCustomization[v1alpha1.FluxPackageName]
+	if ok {
+		flux.customization = v
+	}
+
+	if result, err := flux.Install(ctx, resource, r.Client, r.Scheme, r.Config); err != nil {
+		return result, err
+	}
+
+	resource.Status.Flux.Available = true
+	return ctrl.Result{}, nil
+}
+
+// fluxEngine syncs packages with Flux Kustomizations and HelmReleases.
+type fluxEngine struct{}
+
+func (fluxEngine) install(ctx context.Context, r *LocalbuildReconciler, req ctrl.Request, resource *v1alpha1.Localbuild) (ctrl.Result, error) {
+	return r.ReconcileFlux(ctx, req, resource)
+}
+
+func (fluxEngine) isApplication(gvk *schema.GroupVersionKind) bool {
+	return gvk != nil && localbuild.IsFluxApplication(*gvk)
+}
+
+func (fluxEngine) setPackageSpec(spec *v1alpha1.CustomPackageSpec, o *unstructured.Unstructured, filePath string) {
+	spec.Flux = &v1alpha1.FluxPackageSpec{
+		File:      filePath,
+		Name:      o.GetName(),
+		Namespace: o.GetNamespace(),
+		Kind:      o.GetKind(),
+	}
+	// Flux only pulls the in-cluster repositories. dependencies are declared with spec.dependsOn of the Flux objects.
+	spec.Replicate = true
+}
+
+// reconcileEmbeddedApp applies a GitRepository and a Kustomization for the core package in the flux-system namespace.
+func (fluxEngine) reconcileEmbeddedApp(ctx context.Context, r *LocalbuildReconciler, resource *v1alpha1.Localbuild, appName, repoURL string) error {
+	if repoURL == "" {
+		// the repository is not pushed yet
+		return nil
+	}
+	objs := []*unstructured.Unstructured{
+		localbuild.NewFluxGitRepository(appName, globals.FluxNamespace, repoURL),
+		localbuild.NewFluxKustomization(appName, globals.FluxNamespace, appName, "./"),
+	}
+	for _, o := range objs {
+		util.SetPackageLabels(o)
+		if err := controllerutil.SetControllerReference(resource, o, r.Scheme); err != nil {
+			return err
+		}
+		if err := r.Client.Patch(ctx, o, client.Apply, client.ForceOwnership, client.FieldOwner(v1alpha1.FieldManager)); err != nil {
+			return fmt.Errorf("applying %s %s: %w", o.GetKind(), appName, err)
+		}
+	}
+	return nil
+}
+
+func (fluxEngine) coreAppsHealthy(ctx context.Context, r *LocalbuildReconciler, enabled map[string]bool) (bool, error) {
+	opts, err := corePackageListOptions()
+	if err != nil {
+		return false, err
+	}
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(localbuild.FluxKustomizationGVK.GroupVersion().WithKind(localbuild.FluxKustomizationKind + "List"))
+	err = r.Client.List(ctx, list, opts)
+	if err != nil {
+		return false, fmt.Errorf("listing core packages: %w", err)
+	}
+
+	for i := range list.Items {
+		k := list.Items[i]
+		if !enabled[k.GetName()] {
+			continue
+		}
+		if !fluxReady(&k) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// refresh requests the reconciliation of the Kustomizations and HelmReleases of all packages.
+func (fluxEngine) refresh(ctx context.Context, r *LocalbuildReconciler) error {
+	now := time.Now().Format(time.RFC3339Nano)
+	for _, gvk := range []schema.GroupVersionKind{
+		localbuild.FluxKustomizationGVK,
+		{Group: localbuild.FluxHelmGroup, Version: "v2", Kind: localbuild.FluxHelmReleaseKind},
+	} {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+		if err := r.Client.List(ctx, list, client.HasLabels{v1alpha1.PackageTypeLabelKey}); err != nil {
+			return fmt.Errorf("listing %s for refresh: %w", gvk.Kind, err)
+		}
+		for i := range list.Items {
+			o := &list.Items[i]
+			err := util.ApplyAnnotation(ctx, r.Client, o, map[string]string{fluxReconcileRequestAnnotation: now}, client.FieldOwner(v1alpha1.FieldManager))
+			if err != nil {
+				return fmt.Errorf("applying refresh annotation for %s: %w", o.GetName(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// fluxReady returns true when the Ready condition of the Flux object is True.
+func fluxReady(o *unstructured.Unstructured) bool {
+	conditions, _, _ := unstructured.NestedSlice(o.Object, "status", "conditions")
+	for _, c := range conditions {
+		m, ok := c.(map[string]interface{})
+		if ok && m["type"] == "Ready" {
+			return m["status"] == string(metav1.ConditionTrue)
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package localbuild
+
+import (
+	"testing"
+
+	argocdapp "github.com/cnoe-io/argocd-api/api/argo/application"
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// the Flux manifests are generated with hack/flux/generate-manifests.sh and committed
+func TestFluxManifestsEmbedded(t *testing.T) {
+	assert.NoError(t, CheckFluxManifests())
+}
+
+func TestNewGitOpsEngine(t *testing.T) {
+	assert.IsType(t, argoCDEngine{}, newGitOpsEngine(v1alpha1.BuildCustomizationSpec{}))
+	assert.IsType(t, fluxEngine{}, newGitOpsEngine(v1alpha1.BuildCustomizationSpec{GitOpsEngine: v1alpha1.GitOpsEngineFlux}))
+}
+
+func TestGitOpsEngineIsApplication(t *testing.T) {
+	app := &schema.GroupVersionKind{Group: argocdapp.Group, Version: "v1alpha1", Kind: argocdapp.ApplicationKind}
+	kustomization := &schema.GroupVersionKind{Group: "kustomize.toolkit.fluxcd.io", Version: "v1", Kind: "Kustomization"}
+	helmRelease := &schema.GroupVersionKind{Group: "helm.toolkit.fluxcd.io", Version: "v2", Kind: "HelmRelease"}
+	source := &schema.GroupVersionKind{Group: "source.toolkit.fluxcd.io", Version: "v1", Kind: "GitRepository"}
+
+	assert.True(t, argoCDEngine{}.isApplication(app))
+	assert.False(t, argoCDEngine{}.isApplication(kustomization))
+
+	assert.False(t, fluxEngine{}.isApplication(app))
+	assert.True(t, fluxEngine{}.isApplication(kustomization))
+	assert.True(t, fluxEngine{}.isApplication(helmRelease))
+	assert.False(t, fluxEngine{}.isApplication(source))
+	assert.False(t, fluxEngine{}.isApplication(nil))
+}
+
+func TestGitOpsEngineSetPackageSpec(t *testing.T) {
+	o := &unstructured.Unstructured{}
+	o.SetGroupVersionKind(schema.GroupVersionKind{Group: "kustomize.toolkit.fluxcd.io", Version: "v1", Kind: "Kustomization"})
+	o.SetName("podinfo")
+	o.SetNamespace("flux-system")
+	o.SetAnnotations(map[string]string{v1alpha1.PackageDependsOnAnnotation: "backend"})
+
+	spec := v1alpha1.CustomPackageSpec{}
+	fluxEngine{}.setPackageSpec(&spec, o, "/tmp/pkg/podinfo.yaml")
+	assert.Equal(t, &v1alpha1.FluxPackageSpec{File: "/tmp/pkg/podinfo.yaml", Name: "podinfo", Namespace: "flux-system", Kind: "Kustomization"}, spec.Flux)
+	assert.True(t, spec.Replicate)
+	assert.Empty(t, spec.DependsOn)
+	assert.Equal(t, "podinfo", spec.ApplicationName())
+	assert.Equal(t, "/tmp/pkg/podinfo.yaml", spec.PackageFile())
+
+	o.SetGroupVersionKind(schema.GroupVersionKind{Group: argocdapp.Group, Version: "v1alpha1", Kind: argocdapp.ApplicationKind})
+	o.SetNamespace("argocd")
+	spec = v1alpha1.CustomPackageSpec{}
+	argoCDEngine{}.setPackageSpec(&spec, o, "/tmp/pkg/podinfo.yaml")
+	assert.Nil(t, spec.Flux)
+	assert.Equal(t, v1alpha1.ArgoCDPackageSpec{ApplicationFile: "/tmp/pkg/podinfo.yaml", Name: "podinfo", Namespace: "argocd", Type: argocdapp.ApplicationKind}, spec.ArgoCD)
+	assert.Equal(t, []string{"backend"}, spec.DependsOn)
+}
+
+func TestFluxReady(t *testing.T) {
+	o := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	assert.False(t, fluxReady(o))
+
+	o.Object["status"] = map[string]interface{}{
+		"conditions": []interface{}{
+			map[string]interface{}{"type": "Reconciling", "status": "True"},
+			map[string]interface{}{"type": "Ready", "status": "False"},
+		},
+	}
+	assert.False(t, fluxReady(o))
+
+	o.Object["status"].(map[string]interface{})["conditions"].([]interface{})[1].(map[string]interface{})["status"] = "True"
+	assert.True(t, fluxReady(o))
+}
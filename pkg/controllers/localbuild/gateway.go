@@ -0,0 +1,48 @@
+package localbuild
+
+import (
+	"embed"
+
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
+	"github.com/cnoe-io/idpbuilder/globals"
+	"github.com/cnoe-io/idpbuilder/pkg/k8s"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// resources/gateway/k8s contains Envoy Gateway generated by hack/envoy-gateway/generate-manifests.sh
+// and the Gateway the routes of Argo CD and Gitea are attached to.
+//
+//go:embed resources/gateway/k8s/*
+var installGatewayFS embed.FS
+
+const gatewayGenerateScript = "hack/envoy-gateway/generate-manifests.sh"
+
+// CheckGatewayManifests returns an error if this build of idpbuilder does not embed the Envoy Gateway controller.
+func CheckGatewayManifests() error {
+	return checkGeneratedManifests(installGatewayFS, "resources/gateway/k8s", gatewayGenerateScript)
+}
+
+func RawGatewayInstallResources(templateData any, config v1alpha1.PackageCustomization, scheme *runtime.Scheme) ([][]byte, error) {
+	if err := CheckGatewayManifests(); err != nil {
+		return nil, err
+	}
+	return k8s.BuildCustomizedManifests(config.Files(), "resources/gateway/k8s", installGatewayFS, scheme, templateData)
+}
+
+func gatewayInstallation() EmbeddedInstallation {
+	return EmbeddedInstallation{
+		name:           "Envoy Gateway",
+		resourcePath:   "resources/gateway/k8s",
+		resourceFS:     installGatewayFS,
+		namespace:      globals.GatewayNamespace,
+		generateScript: gatewayGenerateScript,
+		monitoredResources: map[string]schema.GroupVersionKind{
+			"envoy-gateway": {
+				Group:   "apps",
+				Version: "v1",
+				Kind:    "Deployment",
+			},
+		},
+	}
+}
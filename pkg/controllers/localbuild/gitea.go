@@ -25,7 +25,7 @@ import (
 var installGiteaFS embed.FS
 
 func RawGiteaInstallResources(templateData any, config v1alpha1.PackageCustomization, scheme *runtime.Scheme) ([][]byte, error) {
-	return k8s.BuildCustomizedManifests(config.FilePath, "resources/gitea/k8s", installGiteaFS, scheme, templateData)
+	return k8s.BuildCustomizedManifests(config.Files(), "resources/gitea/k8s", installGiteaFS, scheme, templateData)
 }
 
 func (r *LocalbuildReconciler) newGiteaAdminSecret(password string) corev1.Secret {
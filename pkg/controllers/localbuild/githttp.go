@@ -0,0 +1,113 @@
+package localbuild
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"net/http"
+
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
+	"github.com/cnoe-io/idpbuilder/pkg/githttp"
+	"github.com/cnoe-io/idpbuilder/pkg/k8s"
+	"github.com/cnoe-io/idpbuilder/pkg/util"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+//go:embed resources/githttp/*
+var installGitHTTPFS embed.FS
+
+func RawGitHTTPInstallResources(templateData any, config v1alpha1.PackageCustomization, scheme *runtime.Scheme) ([][]byte, error) {
+	return k8s.BuildCustomizedManifests(config.Files(), "resources/githttp", installGitHTTPFS, scheme, templateData)
+}
+
+// ReconcileGitServer installs the git server that hosts the package repositories, Gitea or githttp.
+func (r *LocalbuildReconciler) ReconcileGitServer(ctx context.Context, req ctrl.Request, resource *v1alpha1.Localbuild) (ctrl.Result, error) {
+	if resource.Spec.BuildCustomization.GitServerPackageName() == v1alpha1.GitHTTPPackageName {
+		return r.ReconcileGitHTTP(ctx, req, resource)
+	}
+	return r.ReconcileGitea(ctx, req, resource)
+}
+
+func (r *LocalbuildReconciler) newGitHTTPAdminSecret(password string) corev1.Secret {
+	obj := util.GitHTTPAdminSecretObject()
+	obj.StringData = map[string]string{
+		"username": util.GitHTTPAdminName,
+		"password": password,
+	}
+	return obj
+}
+
+// ReconcileGitHTTP installs the built-in git server. Unlike Gitea, it has no setup beyond the credentials secret.
+func (r *LocalbuildReconciler) ReconcileGitHTTP(ctx context.Context, req ctrl.Request, resource *v1alpha1.Localbuild) (ctrl.Result, error) {
+	logger := log.FromContext(ctx, "installer", "githttp")
+	gitHTTP := EmbeddedInstallation{
+		name:         "GitHTTP",
+		resourcePath: "resources/githttp",
+		resourceFS:   installGitHTTPFS,
+		namespace:    util.GitHTTPNamespace,
+		monitoredResources: map[string]schema.GroupVersionKind{
+			"githttp": {
+				Group:   "apps",
+				Version: "v1",
+				Kind:    "Deployment",
+			},
+		},
+	}
+
+	sec := util.GitHTTPAdminSecretObject()
+	err := r.Client.Get(ctx, types.NamespacedName{
+		Namespace: sec.GetNamespace(),
+		Name:      sec.GetName(),
+	}, &sec)
+	if err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return ctrl.Result{}, fmt.Errorf("getting githttp secret: %w", err)
+		}
+		password := util.StaticPassword
+		if !r.Config.StaticPassword {
+			password, err = util.GeneratePassword()
+			if err != nil {
+				return ctrl.Result{}, fmt.Errorf("generating githttp password: %w", err)
+			}
+		}
+		creds := r.newGitHTTPAdminSecret(password)
+		gitHTTP.unmanagedResources = []client.Object{&creds}
+	}
+
+	v, ok := resource.Spec.PackageConfigs.CorePackageCustomization[v1alpha1.GitHTTPPackageName]
+	if ok {
+		gitHTTP.customization = v
+	}
+
+	if result, err := gitHTTP.Install(ctx, resource, r.Client, r.Scheme, r.Config); err != nil {
+		return result, err
+	}
+
+	baseUrl := util.GitHTTPBaseUrl(r.Config)
+
+	// need this to ensure gitrepository controller can reach the server through the ingress.
+	logger.V(1).Info("checking githttp endpoint", "url", baseUrl)
+	resp, err := util.GetHttpClient().Get(baseUrl + githttp.HealthPath)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		logger.V(1).Info("githttp manifests installed successfully. endpoint not ready", "statusCode", resp.StatusCode)
+		return ctrl.Result{RequeueAfter: errRequeueTime}, nil
+	}
+
+	resource.Status.GitHTTP.ExternalURL = baseUrl
+	resource.Status.GitHTTP.InternalURL = util.GitHTTPInternalURL
+	resource.Status.GitHTTP.AdminUserSecretName = util.GitHTTPAdminSecret
+	resource.Status.GitHTTP.AdminUserSecretNamespace = util.GitHTTPNamespace
+	resource.Status.GitHTTP.Available = true
+	return ctrl.Result{}, nil
+}
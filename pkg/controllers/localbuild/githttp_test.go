@@ -0,0 +1,55 @@
+package localbuild
+
+import (
+	"testing"
+
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
+	"github.com/cnoe-io/idpbuilder/pkg/k8s"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestGitHTTPResources(t *testing.T) {
+	cases := map[string]struct {
+		config    v1alpha1.BuildCustomizationSpec
+		ingresses []string
+		routes    []string
+		hostnames []interface{}
+	}{
+		"nginx subdomain": {
+			config:    v1alpha1.BuildCustomizationSpec{Host: "cnoe.localtest.me", IngressHost: "cnoe.localtest.me", GitHTTPImage: "ghcr.io/cnoe-io/idpbuilder:0.10.0"},
+			ingresses: []string{"githttp"},
+		},
+		"nginx path": {
+			config:    v1alpha1.BuildCustomizationSpec{Host: "cnoe.localtest.me", IngressHost: "cnoe.localtest.me", UsePathRouting: true, GitHTTPImage: "ghcr.io/cnoe-io/idpbuilder:0.10.0"},
+			ingresses: []string{"githttp-path"},
+		},
+		"gateway subdomain": {
+			config: v1alpha1.BuildCustomizationSpec{Host: "cnoe.localtest.me", IngressHost: "idp.example.com", GitHTTPImage: "ghcr.io/cnoe-io/idpbuilder:0.10.0",
+				IngressController: v1alpha1.IngressControllerGateway},
+			routes:    []string{"githttp"},
+			hostnames: []interface{}{"git.idp.example.com", "git.cnoe.localtest.me"},
+		},
+	}
+
+	for name, c := range cases {
+		e := EmbeddedInstallation{resourceFS: installGitHTTPFS, resourcePath: "resources/githttp"}
+		objs, err := e.installResources(k8s.GetScheme(), c.config)
+		assert.NoError(t, err, name)
+
+		routes, ingresses := routesAndIngresses(objs)
+		assert.Equal(t, c.ingresses, ingresses, name)
+		assert.Len(t, routes, len(c.routes), name)
+		for _, r := range c.routes {
+			hostnames, _, _ := unstructured.NestedSlice(routes[r].Object, "spec", "hostnames")
+			assert.Equal(t, c.hostnames, hostnames, name)
+		}
+
+		for _, o := range objs {
+			if d, ok := o.(*appsv1.Deployment); ok {
+				assert.Equal(t, c.config.GitHTTPImage, d.Spec.Template.Spec.Containers[0].Image, name)
+			}
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package localbuild
+
+import (
+	"context"
+
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// gitOpsEngine is the CD technology that syncs the core and custom packages from the in-cluster git repositories.
+type gitOpsEngine interface {
+	// install installs the engine itself.
+	install(ctx context.Context, r *LocalbuildReconciler, req ctrl.Request, resource *v1alpha1.Localbuild) (ctrl.Result, error)
+	// isApplication returns true for the kinds that define a custom package.
+	isApplication(gvk *schema.GroupVersionKind) bool
+	// setPackageSpec sets the fields of the CustomPackage spec for the object o defined in filePath.
+	setPackageSpec(spec *v1alpha1.CustomPackageSpec, o *unstructured.Unstructured, filePath string)
+	// reconcileEmbeddedApp syncs the core package appName from repoURL.
+	reconcileEmbeddedApp(ctx context.Context, r *LocalbuildReconciler, resource *v1alpha1.Localbuild, appName, repoURL string) error
+	// coreAppsHealthy returns true when the enabled core packages are synced and healthy.
+	coreAppsHealthy(ctx context.Context, r *LocalbuildReconciler, enabled map[string]bool) (bool, error)
+	// refresh requests the engine to sync all packages again.
+	refresh(ctx context.Context, r *LocalbuildReconciler) error
+}
+
+func newGitOpsEngine(config v1alpha1.BuildCustomizationSpec) gitOpsEngine {
+	if config.GitOpsEnginePackageName() == v1alpha1.FluxPackageName {
+		return fluxEngine{}
+	}
+	return argoCDEngine{}
+}
+
+func (r *LocalbuildReconciler) ReconcileGitOpsEngine(ctx context.Context, req ctrl.Request, resource *v1alpha1.Localbuild) (ctrl.Result, error) {
+	return newGitOpsEngine(resource.Spec.BuildCustomization).install(ctx, r, req, resource)
+}
@@ -0,0 +1,37 @@
+package localbuild
+
+import (
+	"context"
+
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// ingressInstallation returns the installation of the ingress controller selected by the build customization.
+// Argo CD and Gitea ship the ingresses or routes for each controller and render the matching ones.
+func ingressInstallation(config v1alpha1.BuildCustomizationSpec) EmbeddedInstallation {
+	if config.IngressPackageName() == v1alpha1.GatewayPackageName {
+		return gatewayInstallation()
+	}
+	return nginxInstallation()
+}
+
+func (r *LocalbuildReconciler) ReconcileIngress(ctx context.Context, req ctrl.Request, resource *v1alpha1.Localbuild) (ctrl.Result, error) {
+	ingress := ingressInstallation(resource.Spec.BuildCustomization)
+
+	v, ok := resource.Spec.PackageConfigs.CorePackageCustomization[resource.Spec.BuildCustomization.IngressPackageName()]
+	if ok {
+		ingress.customization = v
+	}
+
+	if result, err := ingress.Install(ctx, resource, r.Client, r.Scheme, r.Config); err != nil {
+		return result, err
+	}
+
+	if resource.Spec.BuildCustomization.IngressPackageName() == v1alpha1.GatewayPackageName {
+		resource.Status.Gateway.Available = true
+	} else {
+		resource.Status.Nginx.Available = true
+	}
+	return ctrl.Result{}, nil
+}
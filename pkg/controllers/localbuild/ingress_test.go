@@ -0,0 +1,123 @@
+package localbuild
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
+	"github.com/cnoe-io/idpbuilder/globals"
+	"github.com/cnoe-io/idpbuilder/pkg/k8s"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestIngressInstallation(t *testing.T) {
+	assert.Equal(t, globals.NginxNamespace, ingressInstallation(v1alpha1.BuildCustomizationSpec{}).namespace)
+	assert.Equal(t, globals.GatewayNamespace,
+		ingressInstallation(v1alpha1.BuildCustomizationSpec{IngressController: v1alpha1.IngressControllerGateway}).namespace)
+}
+
+func routesAndIngresses(objs []client.Object) (map[string]*unstructured.Unstructured, []string) {
+	routes := map[string]*unstructured.Unstructured{}
+	var ingresses []string
+	for _, o := range objs {
+		switch o.GetObjectKind().GroupVersionKind().Kind {
+		case "HTTPRoute":
+			routes[o.GetName()] = o.(*unstructured.Unstructured)
+		case "Ingress":
+			ingresses = append(ingresses, o.GetName())
+		}
+	}
+	return routes, ingresses
+}
+
+func TestGatewayRoutes(t *testing.T) {
+	cases := map[string]struct {
+		config     v1alpha1.BuildCustomizationSpec
+		routes     []string
+		hostnames  []interface{}
+		rulesCount int
+	}{
+		"nginx": {
+			config: v1alpha1.BuildCustomizationSpec{Protocol: "https", Host: "cnoe.localtest.me", IngressHost: "cnoe.localtest.me", Port: "8443"},
+		},
+		"gateway subdomain": {
+			config: v1alpha1.BuildCustomizationSpec{Protocol: "https", Host: "cnoe.localtest.me", IngressHost: "idp.example.com", Port: "8443",
+				IngressController: v1alpha1.IngressControllerGateway},
+			routes:     []string{"argocd-server-route", "my-gitea-custom"},
+			hostnames:  []interface{}{"gitea.idp.example.com", "gitea.cnoe.localtest.me"},
+			rulesCount: 1,
+		},
+		"gateway path": {
+			config: v1alpha1.BuildCustomizationSpec{Protocol: "https", Host: "cnoe.localtest.me", IngressHost: "cnoe.localtest.me", Port: "8443",
+				UsePathRouting: true, IngressController: v1alpha1.IngressControllerGateway},
+			routes:     []string{"argocd-server-route-http", "my-gitea-path"},
+			hostnames:  []interface{}{"cnoe.localtest.me"},
+			rulesCount: 3,
+		},
+	}
+
+	for name, c := range cases {
+		var objs []client.Object
+		for _, e := range []EmbeddedInstallation{{resourceFS: installArgoFS, resourcePath: "resources/argo"}, {resourceFS: installGiteaFS, resourcePath: "resources/gitea/k8s"}} {
+			o, err := e.installResources(k8s.GetScheme(), c.config)
+			assert.NoError(t, err, name)
+			objs = append(objs, o...)
+		}
+		routes, ingresses := routesAndIngresses(objs)
+		if c.config.IngressController != v1alpha1.IngressControllerGateway {
+			assert.Empty(t, routes, name)
+			assert.NotEmpty(t, ingresses, name)
+			continue
+		}
+		assert.Empty(t, ingresses, name)
+		assert.Len(t, routes, len(c.routes), name)
+		for _, r := range c.routes {
+			assert.Contains(t, routes, r, name)
+		}
+
+		gitea := routes[c.routes[1]]
+		hostnames, _, _ := unstructured.NestedSlice(gitea.Object, "spec", "hostnames")
+		assert.Equal(t, c.hostnames, hostnames, name)
+		rules, _, _ := unstructured.NestedSlice(gitea.Object, "spec", "rules")
+		assert.Len(t, rules, c.rulesCount, name)
+		parents, _, _ := unstructured.NestedSlice(gitea.Object, "spec", "parentRefs")
+		assert.Equal(t, []interface{}{map[string]interface{}{"name": "idpbuilder", "namespace": globals.GatewayNamespace}}, parents, name)
+	}
+}
+
+func TestGatewayInstallResources(t *testing.T) {
+	e := gatewayInstallation()
+	objs, err := e.installResources(k8s.GetScheme(), v1alpha1.BuildCustomizationSpec{Protocol: "https", Host: "cnoe.localtest.me", Port: "8443"})
+	assert.NoError(t, err)
+
+	var gateway *unstructured.Unstructured
+	for _, o := range objs {
+		if o.GetObjectKind().GroupVersionKind().Kind == "Gateway" {
+			gateway = o.(*unstructured.Unstructured)
+		}
+	}
+	if assert.NotNil(t, gateway) {
+		listeners, _, _ := unstructured.NestedSlice(gateway.Object, "spec", "listeners")
+		assert.Len(t, listeners, 2)
+		port, _, _ := unstructured.NestedInt64(listeners[1].(map[string]interface{}), "port")
+		assert.Equal(t, int64(8443), port)
+		certs, _, _ := unstructured.NestedSlice(listeners[0].(map[string]interface{}), "tls", "certificateRefs")
+		assert.Equal(t, globals.SelfSignedCertSecretName, certs[0].(map[string]interface{})["name"])
+	}
+}
+
+// the Envoy Gateway manifests are generated with hack/envoy-gateway/generate-manifests.sh and committed
+func TestGatewayManifestsEmbedded(t *testing.T) {
+	assert.NoError(t, CheckGatewayManifests())
+}
+
+func TestCheckGeneratedManifests(t *testing.T) {
+	resources := fstest.MapFS{"resources/gateway/k8s/install.yaml": {Data: []byte("kind: Namespace\n")}}
+	assert.NoError(t, checkGeneratedManifests(resources, "resources/gateway/k8s", gatewayGenerateScript))
+
+	err := checkGeneratedManifests(fstest.MapFS{}, "resources/gateway/k8s", gatewayGenerateScript)
+	assert.ErrorContains(t, err, "resources/gateway/k8s/install.yaml is not embedded")
+	assert.ErrorContains(t, err, gatewayGenerateScript)
+}
@@ -5,6 +5,8 @@ import (
 	"embed"
 	"errors"
 	"fmt"
+	"io/fs"
+	"path"
 	"sync"
 	"time"
 
@@ -23,6 +25,9 @@ import (
 
 var timeout = time.After(5 * time.Minute)
 
+// generatedManifestsFile is the file the hack/*/generate-manifests.sh scripts write the manifests of a core package to.
+const generatedManifestsFile = "install.yaml"
+
 type EmbeddedInstallation struct {
 	name         string
 	resourcePath string
@@ -38,10 +43,23 @@ type EmbeddedInstallation struct {
 
 	// resources that need to be created without using static manifests or gitops
 	unmanagedResources []client.Object
+
+	// script that generates the manifests of resourcePath. when set, the installation fails if they are not embedded.
+	generateScript string
+}
+
+// checkGeneratedManifests returns an error if the manifests generated by script are missing from resourcePath.
+// They are committed like the manifests of the other core packages; this guards against a tree without them.
+func checkGeneratedManifests(resourceFS fs.FS, resourcePath, script string) error {
+	p := path.Join(resourcePath, generatedManifestsFile)
+	if _, err := fs.Stat(resourceFS, p); err != nil {
+		return fmt.Errorf("%s is not embedded in this build of idpbuilder: run %s and rebuild idpbuilder", p, script)
+	}
+	return nil
 }
 
 func (e *EmbeddedInstallation) installResources(scheme *runtime.Scheme, templateData any) ([]client.Object, error) {
-	return k8s.BuildCustomizedObjects(e.customization.FilePath, e.resourcePath, e.resourceFS, scheme, templateData)
+	return k8s.BuildCustomizedObjects(e.customization.Files(), e.resourcePath, e.resourceFS, scheme, templateData)
 }
 
 func (e *EmbeddedInstallation) newNamespace(namespace string) *corev1.Namespace {
@@ -55,6 +73,12 @@ func (e *EmbeddedInstallation) newNamespace(namespace string) *corev1.Namespace
 func (e *EmbeddedInstallation) Install(ctx context.Context, resource *v1alpha1.Localbuild, cli client.Client, sc *runtime.Scheme, cfg v1alpha1.BuildCustomizationSpec) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
 
+	if e.generateScript != "" {
+		if err := checkGeneratedManifests(e.resourceFS, e.resourcePath, e.generateScript); err != nil {
+			return ctrl.Result{}, fmt.Errorf("installing %s: %w", e.name, err)
+		}
+	}
+
 	nsClient := client.NewNamespacedClient(cli, e.namespace)
 	installObjs, err := e.installResources(sc, cfg)
 	if err != nil {
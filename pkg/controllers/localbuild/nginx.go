@@ -1,7 +1,6 @@
 package localbuild
 
 import (
-	"context"
 	"embed"
 
 	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
@@ -9,18 +8,17 @@ import (
 	"github.com/cnoe-io/idpbuilder/pkg/k8s"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
-	ctrl "sigs.k8s.io/controller-runtime"
 )
 
 //go:embed resources/nginx/k8s/*
 var installNginxFS embed.FS
 
 func RawNginxInstallResources(templateData any, config v1alpha1.PackageCustomization, scheme *runtime.Scheme) ([][]byte, error) {
-	return k8s.BuildCustomizedManifests(config.FilePath, "resources/nginx/k8s", installNginxFS, scheme, templateData)
+	return k8s.BuildCustomizedManifests(config.Files(), "resources/nginx/k8s", installNginxFS, scheme, templateData)
 }
 
-func (r *LocalbuildReconciler) ReconcileNginx(ctx context.Context, req ctrl.Request, resource *v1alpha1.Localbuild) (ctrl.Result, error) {
-	nginx := EmbeddedInstallation{
+func nginxInstallation() EmbeddedInstallation {
+	return EmbeddedInstallation{
 		name:         "Nginx",
 		resourcePath: "resources/nginx/k8s",
 		resourceFS:   installNginxFS,
@@ -33,16 +31,4 @@ func (r *LocalbuildReconciler) ReconcileNginx(ctx context.Context, req ctrl.Requ
 			},
 		},
 	}
-
-	v, ok := resource.Spec.PackageConfigs.CorePackageCustomization[v1alpha1.IngressNginxPackageName]
-	if ok {
-		nginx.customization = v
-	}
-
-	if result, err := nginx.Install(ctx, resource, r.Client, r.Scheme, r.Config); err != nil {
-		return result, err
-	}
-
-	resource.Status.Nginx.Available = true
-	return ctrl.Result{}, nil
 }
@@ -0,0 +1,106 @@
+package localbuild
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
+	"github.com/cnoe-io/idpbuilder/globals"
+	"github.com/cnoe-io/idpbuilder/pkg/packages"
+	"github.com/cnoe-io/idpbuilder/pkg/util"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// packageDocument is a yaml document of a package file.
+type packageDocument struct {
+	filePath string
+	b        []byte
+}
+
+// splitPackageFile returns the documents of a package file.
+func splitPackageFile(filePath string, b []byte) ([]packageDocument, error) {
+	docs, err := util.SplitYamlDocuments(b)
+	if err != nil {
+		return nil, fmt.Errorf("splitting %s: %w", filePath, err)
+	}
+	out := make([]packageDocument, 0, len(docs))
+	for i := range docs {
+		out = append(out, packageDocument{filePath: filePath, b: docs[i]})
+	}
+	return out, nil
+}
+
+// reconcileCustomPkgDocs applies the package resources of a package, then creates the CustomPackages of its applications
+// so that AppProjects and secrets exist before the applications that reference them.
+func (r *LocalbuildReconciler) reconcileCustomPkgDocs(
+	ctx context.Context,
+	resource *v1alpha1.Localbuild,
+	docs []packageDocument,
+	remote *util.KustomizeRemote,
+	archive *v1alpha1.ArchiveSpec,
+	values *apiextensionsv1.JSON,
+	priority int,
+	sourcePath string,
+) {
+	logger := log.FromContext(ctx)
+
+	apps := make([]packageDocument, 0, len(docs))
+	for _, d := range docs {
+		o := &unstructured.Unstructured{}
+		if _, _, err := scheme.Codecs.UniversalDeserializer().Decode(d.b, nil, o); err != nil {
+			logger.Error(err, "decoding package document", "file", d.filePath, "sourcePath", sourcePath)
+			continue
+		}
+		if !packages.IsPackageResource(o) {
+			apps = append(apps, d)
+			continue
+		}
+		if err := r.applyPackageResource(ctx, o, priority, sourcePath); err != nil {
+			logger.Error(err, "applying package resource", "file", d.filePath, "kind", o.GetKind(), "name", o.GetName())
+		}
+	}
+
+	for _, d := range apps {
+		if err := r.reconcileCustomPkg(ctx, resource, d.b, d.filePath, remote, archive, values, priority, sourcePath); err != nil {
+			logger.Error(err, "reconciling custom pkg", "file", d.filePath, "sourcePath", sourcePath)
+		}
+	}
+}
+
+// applyPackageResource applies the resource in the ArgoCD namespace unless it sets its own namespace.
+// A resource applied by a package with a higher priority is not overwritten.
+func (r *LocalbuildReconciler) applyPackageResource(ctx context.Context, o *unstructured.Unstructured, priority int, sourcePath string) error {
+	if o.GetNamespace() == "" {
+		o.SetNamespace(globals.ArgoCDNamespace)
+	}
+
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(o.GroupVersionKind())
+	err := r.Client.Get(ctx, client.ObjectKeyFromObject(o), existing)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return fmt.Errorf("getting %s %s: %w", o.GetKind(), o.GetName(), err)
+	}
+	if err == nil {
+		var existingPriority int
+		if _, sErr := fmt.Sscanf(existing.GetAnnotations()[v1alpha1.PackagePriorityAnnotation], "%d", &existingPriority); sErr == nil && existingPriority > priority {
+			log.FromContext(ctx).V(1).Info("skipping package resource applied by a higher priority package",
+				"kind", o.GetKind(), "name", o.GetName(), "skippingPackage", sourcePath)
+			return nil
+		}
+	}
+
+	annotations := o.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[v1alpha1.PackagePriorityAnnotation] = fmt.Sprintf("%d", priority)
+	annotations[v1alpha1.PackageSourcePathAnnotation] = sourcePath
+	o.SetAnnotations(annotations)
+
+	return r.Client.Patch(ctx, o, client.Apply, client.ForceOwnership, client.FieldOwner(v1alpha1.FieldManager))
+}
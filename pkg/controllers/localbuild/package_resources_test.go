@@ -0,0 +1,16 @@
+package localbuild
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitPackageFile(t *testing.T) {
+	docs, err := splitPackageFile("apps.yaml", []byte("kind: Application\nmetadata:\n  name: a\n---\nkind: Application\nmetadata:\n  name: b\n"))
+	assert.Nil(t, err)
+	assert.Len(t, docs, 2)
+	for _, d := range docs {
+		assert.Equal(t, "apps.yaml", d.filePath)
+	}
+}
@@ -0,0 +1,35 @@
+package localbuild
+
+import (
+	"testing"
+
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+func TestPackageValues(t *testing.T) {
+	resource := &v1alpha1.Localbuild{
+		Spec: v1alpha1.LocalbuildSpec{
+			PackageConfigs: v1alpha1.PackageConfigsSpec{
+				CustomPackageValues: &apiextensionsv1.JSON{Raw: []byte(`{"team":"platform","domain":"example.com"}`)},
+				CustomPackageLocationValues: map[string]apiextensionsv1.JSON{
+					"/pkgs/app": {Raw: []byte(`{"team":"a"}`)},
+				},
+			},
+		},
+	}
+	defaults := []byte("team: none\nreplicas: 2\n")
+
+	values, err := packageValues(resource, "/pkgs/app", defaults)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"team":"a","domain":"example.com","replicas":2}`, string(values.Raw))
+
+	values, err = packageValues(resource, "/pkgs/other", defaults)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"team":"platform","domain":"example.com","replicas":2}`, string(values.Raw))
+
+	values, err = packageValues(&v1alpha1.Localbuild{}, "/pkgs/app", nil)
+	assert.NoError(t, err)
+	assert.Nil(t, values)
+}
@@ -21,6 +21,8 @@ func RunControllers(
 	exitOnSync bool,
 	cfg v1alpha1.BuildCustomizationSpec,
 	tmpDir string,
+	gitAuth *util.GitAuthConfig,
+	registryConfig string,
 ) error {
 	logger := log.FromContext(ctx)
 
@@ -28,13 +30,15 @@ func RunControllers(
 
 	// Run Localbuild controller
 	if err := (&localbuild.LocalbuildReconciler{
-		Client:     mgr.GetClient(),
-		Scheme:     mgr.GetScheme(),
-		ExitOnSync: exitOnSync,
-		CancelFunc: ctxCancel,
-		Config:     cfg,
-		TempDir:    tmpDir,
-		RepoMap:    repoMap,
+		Client:         mgr.GetClient(),
+		Scheme:         mgr.GetScheme(),
+		ExitOnSync:     exitOnSync,
+		CancelFunc:     ctxCancel,
+		Config:         cfg,
+		TempDir:        tmpDir,
+		RepoMap:        repoMap,
+		GitAuth:        gitAuth,
+		RegistryConfig: registryConfig,
 	}).SetupWithManager(mgr); err != nil {
 		logger.Error(err, "unable to create localbuild controller")
 		return err
@@ -48,6 +52,7 @@ func RunControllers(
 		GitProviderFunc: gitrepository.GetGitProvider,
 		TempDir:         tmpDir,
 		RepoMap:         repoMap,
+		GitAuth:         gitAuth,
 	}).SetupWithManager(mgr, nil)
 	if err != nil {
 		logger.Error(err, "unable to create repo controller")
@@ -59,6 +64,7 @@ func RunControllers(
 		Recorder: mgr.GetEventRecorderFor("custompackage-controller"),
 		TempDir:  tmpDir,
 		RepoMap:  repoMap,
+		GitAuth:  gitAuth,
 	}).SetupWithManager(mgr)
 	if err != nil {
 		logger.Error(err, "unable to create custom package controller")
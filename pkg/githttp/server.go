@@ -0,0 +1,492 @@
+// Package githttp serves bare git repositories with the git smart HTTP protocol.
+// It is a lightweight alternative to Gitea for hosting the package repositories.
+package githttp
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/packfile"
+	"github.com/go-git/go-git/v5/plumbing/format/pktline"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/protocol/packp"
+	"github.com/go-git/go-git/v5/plumbing/revlist"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/server"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	DefaultBranch = "main"
+	// ReposPath is the path of the API to create and get repositories.
+	ReposPath = "/api/repos"
+	// HealthPath returns 200 when the server is up.
+	HealthPath = "/healthz"
+	// UsernameEnv and PasswordEnv are the environment variables with the credentials of the server.
+	UsernameEnv = "GITHTTP_USERNAME"
+	PasswordEnv = "GITHTTP_PASSWORD"
+
+	uploadPackService  = "git-upload-pack"
+	receivePackService = "git-receive-pack"
+	repoSuffix         = ".git"
+	commitAuthorName   = "githttp"
+	commitAuthorEmail  = "idpbuilder-agent@cnoe.io"
+)
+
+var (
+	ErrRepositoryExists = errors.New("repository already exists")
+	ErrInvalidName      = errors.New("invalid repository name")
+
+	repoNameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+)
+
+// Repository is the representation of a repository in the API.
+type Repository struct {
+	Name string `json:"name"`
+}
+
+// Server serves the bare repositories in Dir. Repositories can be read anonymously.
+// Pushes and API calls require basic auth with Username and Password.
+type Server struct {
+	dir      string
+	username string
+	password string
+
+	loader    server.Loader
+	transport transport.Transport
+	mux       *http.ServeMux
+	// mu serializes writes to the repositories. Reads may run concurrently.
+	mu sync.RWMutex
+}
+
+func NewServer(dir, username, password string) *Server {
+	loader := server.NewFilesystemLoader(osfs.New(dir))
+	s := &Server{
+		dir:       dir,
+		username:  username,
+		password:  password,
+		loader:    loader,
+		transport: server.NewServer(loader),
+		mux:       http.NewServeMux(),
+	}
+	s.mux.HandleFunc("GET "+HealthPath, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	s.mux.HandleFunc("POST "+ReposPath, s.authenticated(s.handleCreateRepository))
+	s.mux.HandleFunc("GET "+ReposPath+"/{name}", s.authenticated(s.handleGetRepository))
+	s.mux.HandleFunc("GET /{repo}/info/refs", s.handleInfoRefs)
+	s.mux.HandleFunc("POST /{repo}/"+uploadPackService, s.handleUploadPack)
+	s.mux.HandleFunc("POST /{repo}/"+receivePackService, s.authenticated(s.handleReceivePack))
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// CreateRepository creates a bare repository with an empty commit on the default branch,
+// so that it can be cloned before anything is pushed to it.
+func (s *Server) CreateRepository(name string) error {
+	if !repoNameRegex.MatchString(name) {
+		return ErrInvalidName
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := filepath.Join(s.dir, name+repoSuffix)
+	if _, err := os.Stat(path); err == nil {
+		return ErrRepositoryExists
+	}
+
+	repo, err := git.PlainInitWithOptions(path, &git.PlainInitOptions{
+		Bare: true,
+		InitOptions: git.InitOptions{
+			DefaultBranch: plumbing.NewBranchReferenceName(DefaultBranch),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("initializing repository: %w", err)
+	}
+
+	treeHash, err := storeObject(repo.Storer, &object.Tree{})
+	if err != nil {
+		return fmt.Errorf("writing empty tree: %w", err)
+	}
+	sig := object.Signature{Name: commitAuthorName, Email: commitAuthorEmail, When: time.Now()}
+	commitHash, err := storeObject(repo.Storer, &object.Commit{
+		Author:    sig,
+		Committer: sig,
+		Message:   "initial commit",
+		TreeHash:  treeHash,
+	})
+	if err != nil {
+		return fmt.Errorf("writing initial commit: %w", err)
+	}
+	return repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName(DefaultBranch), commitHash))
+}
+
+// RepositoryExists returns true if the repository with the given name exists.
+func (s *Server) RepositoryExists(name string) bool {
+	if !repoNameRegex.MatchString(name) {
+		return false
+	}
+	_, err := s.loader.Load(endpoint(name))
+	return err == nil
+}
+
+func (s *Server) handleCreateRepository(w http.ResponseWriter, r *http.Request) {
+	var repo Repository
+	if err := json.NewDecoder(r.Body).Decode(&repo); err != nil {
+		http.Error(w, fmt.Sprintf("decoding request: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	err := s.CreateRepository(repo.Name)
+	switch {
+	case errors.Is(err, ErrInvalidName):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, ErrRepositoryExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		log.FromContext(r.Context()).Error(err, "creating repository", "name", repo.Name)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusCreated, repo)
+}
+
+func (s *Server) handleGetRepository(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if !s.RepositoryExists(name) {
+		http.NotFound(w, r)
+		return
+	}
+	writeJSON(w, http.StatusOK, Repository{Name: name})
+}
+
+// handleInfoRefs advertises the references of the repository. Only the smart protocol is supported.
+func (s *Server) handleInfoRefs(w http.ResponseWriter, r *http.Request) {
+	service := r.URL.Query().Get("service")
+	switch service {
+	case uploadPackService:
+	case receivePackService:
+		if !s.authorized(r) {
+			unauthorized(w)
+			return
+		}
+	default:
+		http.Error(w, "only the smart http protocol is supported", http.StatusForbidden)
+		return
+	}
+
+	ep, ok := repoEndpoint(w, r)
+	if !ok {
+		return
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var ar *packp.AdvRefs
+	var err error
+	if service == uploadPackService {
+		var sess transport.UploadPackSession
+		if sess, err = s.transport.NewUploadPackSession(ep, nil); err == nil {
+			ar, err = sess.AdvertisedReferencesContext(r.Context())
+		}
+	} else {
+		var sess transport.ReceivePackSession
+		if sess, err = s.transport.NewReceivePackSession(ep, nil); err == nil {
+			ar, err = sess.AdvertisedReferencesContext(r.Context())
+		}
+	}
+	if err != nil {
+		writeSessionError(w, r, err)
+		return
+	}
+
+	ar.Prefix = [][]byte{[]byte("# service=" + service), pktline.Flush}
+	w.Header().Set("Content-Type", fmt.Sprintf("application/x-%s-advertisement", service))
+	w.Header().Set("Cache-Control", "no-cache")
+	if err = ar.Encode(w); err != nil {
+		log.FromContext(r.Context()).Error(err, "writing advertised references")
+	}
+}
+
+// handleUploadPack sends the objects the client wants. The shallow capability is not advertised, so the git CLI
+// refuses --depth. go-git clients that ask for a depth anyway receive the full history.
+func (s *Server) handleUploadPack(w http.ResponseWriter, r *http.Request) {
+	ep, ok := repoEndpoint(w, r)
+	if !ok {
+		return
+	}
+	body, err := requestBody(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer body.Close()
+
+	req := packp.NewUploadPackRequest()
+	if err = req.UploadRequest.Decode(body); err != nil {
+		http.Error(w, fmt.Sprintf("decoding upload request: %s", err), http.StatusBadRequest)
+		return
+	}
+	haves, done, err := decodeHaves(body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("decoding haves: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	st, err := s.loader.Load(ep)
+	if err != nil {
+		writeSessionError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", fmt.Sprintf("application/x-%s-result", uploadPackService))
+	w.Header().Set("Cache-Control", "no-cache")
+
+	common := commonObjects(st, haves)
+	// without multi_ack, only the first common object is acknowledged
+	acks := common[:min(len(common), 1)]
+	if !done {
+		// a negotiation round of a stateless client. it sends done once a common object is acknowledged.
+		if err = encodeNegotiation(w, req, acks); err != nil {
+			log.FromContext(r.Context()).Error(err, "writing negotiation response")
+		}
+		return
+	}
+
+	objs, err := objectsToUpload(st, req.Wants, common)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("listing objects: %s", err), http.StatusInternalServerError)
+		return
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		_, eErr := packfile.NewEncoder(pw, st, false).Encode(objs, 10)
+		pw.CloseWithError(eErr)
+	}()
+	resp := packp.NewUploadPackResponseWithPackfile(req, pr)
+	resp.ACKs = acks
+	if err = resp.Encode(w); err != nil {
+		log.FromContext(r.Context()).Error(err, "writing packfile")
+	}
+}
+
+func (s *Server) handleReceivePack(w http.ResponseWriter, r *http.Request) {
+	ep, ok := repoEndpoint(w, r)
+	if !ok {
+		return
+	}
+	body, err := requestBody(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer body.Close()
+
+	req := packp.NewReferenceUpdateRequest()
+	if err = req.Decode(body); err != nil {
+		http.Error(w, fmt.Sprintf("decoding reference update request: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, err := s.transport.NewReceivePackSession(ep, nil)
+	if err != nil {
+		writeSessionError(w, r, err)
+		return
+	}
+	status, err := sess.ReceivePack(r.Context(), req)
+	if status == nil {
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	// errors of individual references are reported to the client in the status
+	w.Header().Set("Content-Type", fmt.Sprintf("application/x-%s-result", receivePackService))
+	w.Header().Set("Cache-Control", "no-cache")
+	if err = status.Encode(w); err != nil {
+		log.FromContext(r.Context()).Error(err, "writing report status")
+	}
+}
+
+func (s *Server) authenticated(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.authorized(r) {
+			unauthorized(w)
+			return
+		}
+		h(w, r)
+	}
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	user, pass, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+	userOK := subtle.ConstantTimeCompare([]byte(user), []byte(s.username)) == 1
+	passOK := subtle.ConstantTimeCompare([]byte(pass), []byte(s.password)) == 1
+	return userOK && passOK
+}
+
+func unauthorized(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", `Basic realm="githttp"`)
+	http.Error(w, "unauthorized", http.StatusUnauthorized)
+}
+
+// repoEndpoint returns the endpoint of the repository in the path. The repository must end with .git.
+func repoEndpoint(w http.ResponseWriter, r *http.Request) (*transport.Endpoint, bool) {
+	name, ok := strings.CutSuffix(r.PathValue("repo"), repoSuffix)
+	if !ok || !repoNameRegex.MatchString(name) {
+		http.NotFound(w, r)
+		return nil, false
+	}
+	return endpoint(name), true
+}
+
+func endpoint(name string) *transport.Endpoint {
+	return &transport.Endpoint{Path: "/" + name + repoSuffix}
+}
+
+func writeSessionError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, transport.ErrRepositoryNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	log.FromContext(r.Context()).Error(err, "serving repository", "path", r.URL.Path)
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func requestBody(r *http.Request) (io.ReadCloser, error) {
+	if r.Header.Get("Content-Encoding") != "gzip" {
+		return r.Body, nil
+	}
+	gz, err := gzip.NewReader(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading gzip body: %w", err)
+	}
+	return gz, nil
+}
+
+// decodeHaves reads the have lines that follow the upload request. done is true when the client ended the negotiation.
+func decodeHaves(r io.Reader) ([]plumbing.Hash, bool, error) {
+	var haves []plumbing.Hash
+	scanner := pktline.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(string(scanner.Bytes()), "\n")
+		switch {
+		case line == "":
+			// flush-pkt between batches of haves
+		case line == "done":
+			return haves, true, nil
+		case strings.HasPrefix(line, "have "):
+			h := strings.TrimPrefix(line, "have ")
+			if !plumbing.IsHash(h) {
+				return nil, false, fmt.Errorf("invalid have line %q", line)
+			}
+			haves = append(haves, plumbing.NewHash(h))
+		default:
+			return nil, false, fmt.Errorf("unexpected line %q", line)
+		}
+	}
+	return haves, false, scanner.Err()
+}
+
+func encodeNegotiation(w io.Writer, req *packp.UploadPackRequest, acks []plumbing.Hash) error {
+	if !req.Depth.IsZero() {
+		// empty shallow-update section
+		if err := pktline.NewEncoder(w).Flush(); err != nil {
+			return err
+		}
+	}
+	resp := packp.ServerResponse{ACKs: acks}
+	return resp.Encode(w, false)
+}
+
+// commonObjects returns the haves the repository knows about.
+func commonObjects(st storer.Storer, haves []plumbing.Hash) []plumbing.Hash {
+	var out []plumbing.Hash
+	for _, h := range haves {
+		if st.HasEncodedObject(h) == nil {
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
+// objectsToUpload returns the objects reachable from wants that are not reachable from the common objects.
+func objectsToUpload(st storer.Storer, wants, common []plumbing.Hash) ([]plumbing.Hash, error) {
+	existing, err := revlist.Objects(st, common, nil)
+	if err != nil {
+		return nil, err
+	}
+	return revlist.Objects(st, wants, existing)
+}
+
+type encoder interface {
+	Encode(o plumbing.EncodedObject) error
+}
+
+func storeObject(st storer.EncodedObjectStorer, o encoder) (plumbing.Hash, error) {
+	obj := st.NewEncodedObject()
+	if err := o.Encode(obj); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return st.SetEncodedObject(obj)
+}
+
+// ListenAndServe serves s on addr until ctx is done.
+func ListenAndServe(ctx context.Context, addr string, s *Server) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+	select {
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return srv.Shutdown(shutdownCtx)
+	case err := <-errCh:
+		return err
+	}
+}
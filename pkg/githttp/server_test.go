@@ -0,0 +1,181 @@
+package githttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(NewServer(t.TempDir(), "admin", "secret"))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func createRepo(t *testing.T, url, name, user, pass string) *http.Response {
+	b, err := json.Marshal(Repository{Name: name})
+	require.NoError(t, err)
+	req, err := http.NewRequest(http.MethodPost, url+ReposPath, bytes.NewReader(b))
+	require.NoError(t, err)
+	req.SetBasicAuth(user, pass)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	return resp
+}
+
+func getRepo(t *testing.T, url, name string) int {
+	req, err := http.NewRequest(http.MethodGet, url+ReposPath+"/"+name, nil)
+	require.NoError(t, err)
+	req.SetBasicAuth("admin", "secret")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestRepositoryAPI(t *testing.T) {
+	srv := newTestServer(t)
+
+	assert.Equal(t, http.StatusNotFound, getRepo(t, srv.URL, "repo1"))
+	assert.Equal(t, http.StatusUnauthorized, createRepo(t, srv.URL, "repo1", "admin", "wrong").StatusCode)
+	assert.Equal(t, http.StatusBadRequest, createRepo(t, srv.URL, "../repo1", "admin", "secret").StatusCode)
+	assert.Equal(t, http.StatusCreated, createRepo(t, srv.URL, "repo1", "admin", "secret").StatusCode)
+	assert.Equal(t, http.StatusConflict, createRepo(t, srv.URL, "repo1", "admin", "secret").StatusCode)
+	assert.Equal(t, http.StatusOK, getRepo(t, srv.URL, "repo1"))
+
+	resp, err := http.Get(srv.URL + "/repo1.git/info/refs")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp, err = http.Get(srv.URL + "/missing.git/info/refs?service=git-upload-pack")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestPushAndClone(t *testing.T) {
+	srv := newTestServer(t)
+	repoURL := srv.URL + "/repo1.git"
+	require.Equal(t, http.StatusCreated, createRepo(t, srv.URL, "repo1", "admin", "secret").StatusCode)
+
+	// the repository can be cloned before anything is pushed
+	dir := t.TempDir()
+	repo, err := git.PlainClone(dir, false, &git.CloneOptions{URL: repoURL, Depth: 1})
+	require.NoError(t, err)
+
+	head, err := repo.Head()
+	require.NoError(t, err)
+	assert.Equal(t, "refs/heads/"+DefaultBranch, head.Name().String())
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app.yaml"), []byte("kind: Application\n"), 0644))
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+	_, err = wt.Add("app.yaml")
+	require.NoError(t, err)
+	_, err = wt.Commit("add app", &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@cnoe.io", When: time.Now()},
+	})
+	require.NoError(t, err)
+
+	err = repo.Push(&git.PushOptions{Auth: &githttp.BasicAuth{Username: "admin", Password: "wrong"}})
+	assert.ErrorIs(t, err, transport.ErrAuthenticationRequired)
+
+	err = repo.Push(&git.PushOptions{Auth: &githttp.BasicAuth{Username: "admin", Password: "secret"}})
+	require.NoError(t, err)
+
+	cloneDir := t.TempDir()
+	_, err = git.PlainClone(cloneDir, false, &git.CloneOptions{URL: repoURL})
+	require.NoError(t, err)
+	b, err := os.ReadFile(filepath.Join(cloneDir, "app.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "kind: Application\n", string(b))
+
+	// fetching with objects the server already has only sends the new objects
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app.yaml"), []byte("kind: ApplicationSet\n"), 0644))
+	_, err = wt.Commit("update app", &git.CommitOptions{
+		All:    true,
+		Author: &object.Signature{Name: "test", Email: "test@cnoe.io", When: time.Now()},
+	})
+	require.NoError(t, err)
+	require.NoError(t, repo.Push(&git.PushOptions{Auth: &githttp.BasicAuth{Username: "admin", Password: "secret"}}))
+
+	cloned, err := git.PlainOpen(cloneDir)
+	require.NoError(t, err)
+	cwt, err := cloned.Worktree()
+	require.NoError(t, err)
+	require.NoError(t, cwt.Pull(&git.PullOptions{}))
+	b, err = os.ReadFile(filepath.Join(cloneDir, "app.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "kind: ApplicationSet\n", string(b))
+}
+
+// the git CLI is what Argo CD's repo-server uses to fetch repositories
+func TestCloneWithGitCLI(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	srv := newTestServer(t)
+	repoURL := srv.URL + "/repo1.git"
+	require.Equal(t, http.StatusCreated, createRepo(t, srv.URL, "repo1", "admin", "secret").StatusCode)
+
+	dir := t.TempDir()
+	repo, err := git.PlainClone(dir, false, &git.CloneOptions{URL: repoURL})
+	require.NoError(t, err)
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+	commit := func(content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "app.yaml"), []byte(content), 0644))
+		_, err = wt.Add("app.yaml")
+		require.NoError(t, err)
+		_, err = wt.Commit("update app", &git.CommitOptions{
+			Author: &object.Signature{Name: "test", Email: "test@cnoe.io", When: time.Now()},
+		})
+		require.NoError(t, err)
+		require.NoError(t, repo.Push(&git.PushOptions{Auth: &githttp.BasicAuth{Username: "admin", Password: "secret"}}))
+	}
+	commit("kind: Application\n")
+
+	runGit := func(dir string, args ...string) (string, error) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_CONFIG_NOSYSTEM=1", "HOME="+t.TempDir())
+		out, err := cmd.CombinedOutput()
+		return string(out), err
+	}
+
+	cloneDir := filepath.Join(t.TempDir(), "clone")
+	out, err := runGit("", "clone", repoURL, cloneDir)
+	require.NoError(t, err, out)
+	b, err := os.ReadFile(filepath.Join(cloneDir, "app.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "kind: Application\n", string(b))
+
+	commit("kind: ApplicationSet\n")
+	out, err = runGit(cloneDir, "fetch", "origin", "--tags", "--force", "--prune")
+	require.NoError(t, err, out)
+	out, err = runGit(cloneDir, "checkout", "--force", "origin/"+DefaultBranch)
+	require.NoError(t, err, out)
+	b, err = os.ReadFile(filepath.Join(cloneDir, "app.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "kind: ApplicationSet\n", string(b))
+
+	// shallow clones are not supported
+	out, err = runGit("", "clone", "--depth", "1", repoURL, filepath.Join(t.TempDir(), "shallow"))
+	assert.Error(t, err)
+	assert.Contains(t, out, "does not support shallow")
+}
@@ -4,11 +4,13 @@ import (
 	"bytes"
 	"fmt"
 
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/kustomize/kyaml/kio"
 	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/yaml"
 )
 
 type ConversionError struct {
@@ -25,11 +27,15 @@ func ConvertYamlToObjects(scheme *runtime.Scheme, objYamls []byte) ([]client.Obj
 	var k8sObjects []client.Object
 
 	for _, objYaml := range bytes.Split(objYamls, []byte{'\n', '-', '-', '-', '\n'}) {
-		if len(objYaml) == 0 {
+		if len(bytes.TrimSpace(objYaml)) == 0 {
 			continue
 		}
 
 		rtObject, _, err := decode(objYaml, nil, nil)
+		if runtime.IsNotRegisteredError(err) {
+			// kinds without go types, e.g. gateway api resources, are kept as unstructured objects.
+			rtObject, err = decodeUnstructured(objYaml)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -42,6 +48,18 @@ func ConvertYamlToObjects(scheme *runtime.Scheme, objYamls []byte) ([]client.Obj
 	return k8sObjects, nil
 }
 
+func decodeUnstructured(objYaml []byte) (runtime.Object, error) {
+	b, err := yaml.YAMLToJSON(objYaml)
+	if err != nil {
+		return nil, err
+	}
+	u := &unstructured.Unstructured{}
+	if err = u.UnmarshalJSON(b); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
 func ConvertRawResourcesToObjects(scheme *runtime.Scheme, rawResources [][]byte) ([]client.Object, error) {
 	var ret []client.Object
 	for _, resources := range rawResources {
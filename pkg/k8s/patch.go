@@ -0,0 +1,115 @@
+package k8s
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/cnoe-io/idpbuilder/pkg/util/files"
+	"sigs.k8s.io/kustomize/api/konfig"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+// patchFields are the fields of a Kustomization that customization files may set.
+var patchFields = map[string]bool{
+	"apiVersion":            true,
+	"kind":                  true,
+	"metadata":              true,
+	"patches":               true,
+	"patchesStrategicMerge": true,
+	"patchesJson6902":       true,
+}
+
+// isKustomization returns true if the node is a Kustomization that contains patches for the core package manifests.
+func isKustomization(n *kyaml.RNode) bool {
+	return n.GetKind() == types.KustomizationKind
+}
+
+// applyPatches applies the strategic merge and JSON 6902 patches of the Kustomization to the manifests.
+// Patches given by path are read relative to dir and rendered with templateData.
+func applyPatches(dir string, kustomization []byte, manifests [][]byte, templateData any) ([][]byte, error) {
+	fields := map[string]interface{}{}
+	if err := yaml.Unmarshal(kustomization, &fields); err != nil {
+		return nil, fmt.Errorf("parsing kustomization: %w", err)
+	}
+	for f := range fields {
+		if !patchFields[f] {
+			return nil, fmt.Errorf("kustomization field %s is not supported in customization files, only patches are", f)
+		}
+	}
+
+	k := types.Kustomization{}
+	if err := yaml.Unmarshal(kustomization, &k); err != nil {
+		return nil, fmt.Errorf("parsing kustomization: %w", err)
+	}
+
+	patches := make([]types.Patch, 0, len(k.Patches)+len(k.PatchesStrategicMerge)+len(k.PatchesJson6902))
+	patches = append(patches, k.Patches...)
+	for _, p := range k.PatchesStrategicMerge {
+		// a strategic merge patch is either a path or an inline patch
+		if _, err := os.Stat(resolvePath(dir, string(p))); err == nil {
+			patches = append(patches, types.Patch{Path: string(p)})
+		} else {
+			patches = append(patches, types.Patch{Patch: string(p)})
+		}
+	}
+	patches = append(patches, k.PatchesJson6902...)
+
+	for i := range patches {
+		if patches[i].Path == "" {
+			continue
+		}
+		p := resolvePath(dir, patches[i].Path)
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return nil, fmt.Errorf("reading patch: %w", err)
+		}
+		rendered, err := files.ApplyTemplate(b, templateData)
+		if err != nil {
+			return nil, fmt.Errorf("rendering patch %s: %w", p, err)
+		}
+		patches[i].Path, patches[i].Patch = "", string(rendered)
+	}
+
+	fSys := filesys.MakeFsInMemory()
+	resources := make([]string, 0, len(manifests))
+	for i := range manifests {
+		if len(bytes.TrimSpace(manifests[i])) == 0 {
+			continue
+		}
+		name := fmt.Sprintf("resource%d.yaml", i)
+		if err := fSys.WriteFile(name, manifests[i]); err != nil {
+			return nil, err
+		}
+		resources = append(resources, name)
+	}
+	b, err := yaml.Marshal(types.Kustomization{Resources: resources, Patches: patches})
+	if err != nil {
+		return nil, fmt.Errorf("writing kustomization: %w", err)
+	}
+	if err = fSys.WriteFile(konfig.DefaultKustomizationFileName(), b); err != nil {
+		return nil, err
+	}
+
+	resMap, err := krusty.MakeKustomizer(krusty.MakeDefaultOptions()).Run(fSys, filesys.SelfDir)
+	if err != nil {
+		return nil, fmt.Errorf("applying patches: %w", err)
+	}
+	out, err := resMap.AsYaml()
+	if err != nil {
+		return nil, fmt.Errorf("applying patches: %w", err)
+	}
+	return [][]byte{out}, nil
+}
+
+func resolvePath(dir, p string) string {
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(dir, p)
+}
@@ -2,24 +2,30 @@ package k8s
 
 import (
 	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+
 	"github.com/cnoe-io/idpbuilder/pkg/util/files"
 	"github.com/cnoe-io/idpbuilder/pkg/util/fs"
 	"k8s.io/apimachinery/pkg/runtime"
-	"os"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
 )
 
-func BuildCustomizedManifests(filePath, fsPath string, resourceFS embed.FS, scheme *runtime.Scheme, templateData any) ([][]byte, error) {
+// BuildCustomizedManifests renders the manifests in fsPath and applies the customization files in order.
+func BuildCustomizedManifests(filePaths []string, fsPath string, resourceFS embed.FS, scheme *runtime.Scheme, templateData any) ([][]byte, error) {
 	rawResources, err := fs.ConvertFSToBytes(resourceFS, fsPath, templateData)
 	if err != nil {
 		return nil, err
 	}
 
-	if filePath == "" {
+	if len(filePaths) == 0 {
 		return rawResources, nil
 	}
 
-	bs, _, err := applyOverrides(filePath, rawResources, scheme, templateData)
+	bs, _, err := applyOverrides(filePaths, rawResources, scheme, templateData)
 	if err != nil {
 		return nil, err
 	}
@@ -27,17 +33,18 @@ func BuildCustomizedManifests(filePath, fsPath string, resourceFS embed.FS, sche
 	return bs, nil
 }
 
-func BuildCustomizedObjects(filePath, fsPath string, resourceFS embed.FS, scheme *runtime.Scheme, templateData any) ([]client.Object, error) {
+// BuildCustomizedObjects renders the manifests in fsPath, applies the customization files in order and converts them to objects.
+func BuildCustomizedObjects(filePaths []string, fsPath string, resourceFS embed.FS, scheme *runtime.Scheme, templateData any) ([]client.Object, error) {
 	rawResources, err := fs.ConvertFSToBytes(resourceFS, fsPath, templateData)
 	if err != nil {
 		return nil, err
 	}
 
-	if filePath == "" {
+	if len(filePaths) == 0 {
 		return ConvertRawResourcesToObjects(scheme, rawResources)
 	}
 
-	_, objs, err := applyOverrides(filePath, rawResources, scheme, templateData)
+	_, objs, err := applyOverrides(filePaths, rawResources, scheme, templateData)
 	if err != nil {
 		return nil, err
 	}
@@ -45,16 +52,57 @@ func BuildCustomizedObjects(filePath, fsPath string, resourceFS embed.FS, scheme
 	return objs, nil
 }
 
-func applyOverrides(filePath string, originalFiles [][]byte, scheme *runtime.Scheme, templateData any) ([][]byte, []client.Object, error) {
-	customBS, err := os.ReadFile(filePath)
-	if err != nil {
-		return nil, nil, err
+// applyOverrides applies the customization files to the manifests. Objects in a customization file replace the objects
+// with the same identifier, and the patches of a Kustomization in the file are applied on top.
+func applyOverrides(filePaths []string, originalFiles [][]byte, scheme *runtime.Scheme, templateData any) ([][]byte, []client.Object, error) {
+	manifests := originalFiles
+	for _, filePath := range filePaths {
+		customBS, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		rendered, err := files.ApplyTemplate(customBS, templateData)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		nodes, err := kio.FromBytes(rendered)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parsing customization file %s: %w", filePath, err)
+		}
+		var overrides, kustomizations []*kyaml.RNode
+		for i := range nodes {
+			if isKustomization(nodes[i]) {
+				kustomizations = append(kustomizations, nodes[i])
+			} else {
+				overrides = append(overrides, nodes[i])
+			}
+		}
+
+		if len(overrides) > 0 {
+			o, sErr := kio.StringAll(overrides)
+			if sErr != nil {
+				return nil, nil, fmt.Errorf("reading customization file %s: %w", filePath, sErr)
+			}
+			if manifests, _, err = ConvertYamlToObjectsWithOverride(scheme, manifests, []byte(o)); err != nil {
+				return nil, nil, fmt.Errorf("applying customization file %s: %w", filePath, err)
+			}
+		}
+		for _, k := range kustomizations {
+			b, sErr := k.MarshalJSON()
+			if sErr != nil {
+				return nil, nil, fmt.Errorf("reading customization file %s: %w", filePath, sErr)
+			}
+			if manifests, err = applyPatches(filepath.Dir(filePath), b, manifests, templateData); err != nil {
+				return nil, nil, fmt.Errorf("applying customization file %s: %w", filePath, err)
+			}
+		}
 	}
 
-	rendered, err := files.ApplyTemplate(customBS, templateData)
+	objs, err := ConvertRawResourcesToObjects(scheme, manifests)
 	if err != nil {
 		return nil, nil, err
 	}
-
-	return ConvertYamlToObjectsWithOverride(scheme, originalFiles, rendered)
+	return manifests, objs, nil
 }
@@ -4,10 +4,14 @@ import (
 	"bytes"
 	"embed"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
 	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 //go:embed test-resources/*
@@ -38,7 +42,7 @@ func TestBuildCustomizedManifests(t *testing.T) {
 
 	for key := range cases {
 		c := cases[key]
-		b, err := BuildCustomizedManifests(c.filePath, c.fsPath, testDataFS, GetScheme(), v1alpha1.BuildCustomizationSpec{
+		b, err := BuildCustomizedManifests([]string{c.filePath}, c.fsPath, testDataFS, GetScheme(), v1alpha1.BuildCustomizationSpec{
 			Protocol:       "http",
 			Host:           "cnoe.localtest.me",
 			IngressHost:    "localhost",
@@ -70,3 +74,87 @@ func TestBuildCustomizedManifests(t *testing.T) {
 		}
 	}
 }
+
+func TestBuildCustomizedManifestsPatches(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		// strategic merge patch from a file, rendered with the template data
+		"controller-env.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: ingress-nginx-controller
+  namespace: ingress-nginx
+spec:
+  template:
+    spec:
+      containers:
+        - name: controller
+          env:
+            - name: HOST
+              value: {{ .Host }}
+`,
+		"patches.yaml": `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+patches:
+  - path: controller-env.yaml
+  - target:
+      kind: Deployment
+      name: ingress-nginx-controller
+    patch: |-
+      - op: replace
+        path: /spec/revisionHistoryLimit
+        value: 3
+`,
+		// applied after patches.yaml
+		"more-patches.yaml": `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+patchesJson6902:
+  - target:
+      kind: Deployment
+      name: ingress-nginx-controller
+    patch: |-
+      - op: replace
+        path: /spec/revisionHistoryLimit
+        value: 5
+`,
+		"unsupported.yaml": `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+resources:
+  - https://example.com/install.yaml
+`,
+	}
+	for name, content := range files {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+	}
+	data := v1alpha1.BuildCustomizationSpec{Protocol: "http", Host: "cnoe.localtest.me", Port: "8443"}
+
+	objs, err := BuildCustomizedObjects([]string{filepath.Join(dir, "patches.yaml")}, "test-resources/input/nginx", testDataFS, GetScheme(), data)
+	assert.NoError(t, err)
+	dep := findDeployment(objs, "ingress-nginx-controller")
+	if assert.NotNil(t, dep) {
+		assert.Equal(t, int32(3), *dep.Spec.RevisionHistoryLimit)
+		assert.Contains(t, dep.Spec.Template.Spec.Containers[0].Env, corev1.EnvVar{Name: "HOST", Value: "cnoe.localtest.me"})
+		// the patch is merged with the embedded manifest
+		assert.Greater(t, len(dep.Spec.Template.Spec.Containers[0].Env), 1)
+	}
+
+	objs, err = BuildCustomizedObjects([]string{filepath.Join(dir, "patches.yaml"), filepath.Join(dir, "more-patches.yaml")},
+		"test-resources/input/nginx", testDataFS, GetScheme(), data)
+	assert.NoError(t, err)
+	dep = findDeployment(objs, "ingress-nginx-controller")
+	if assert.NotNil(t, dep) {
+		assert.Equal(t, int32(5), *dep.Spec.RevisionHistoryLimit)
+	}
+
+	_, err = BuildCustomizedManifests([]string{filepath.Join(dir, "unsupported.yaml")}, "test-resources/input/nginx", testDataFS, GetScheme(), data)
+	assert.ErrorContains(t, err, "kustomization field resources is not supported")
+}
+
+func findDeployment(objs []client.Object, name string) *appsv1.Deployment {
+	for i := range objs {
+		if d, ok := objs[i].(*appsv1.Deployment); ok && d.Name == name {
+			return d
+		}
+	}
+	return nil
+}
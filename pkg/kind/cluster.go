@@ -20,8 +20,9 @@ import (
 )
 
 const (
-	ingressNginxNodeLabelKey   = "ingress-ready"
-	ingressNginxNodeLabelValue = "true"
+	// the ingress controller, ingress-nginx or envoy, runs on the node with this label.
+	ingressNodeLabelKey   = "ingress-ready"
+	ingressNodeLabelValue = "true"
 )
 
 var (
@@ -61,7 +62,7 @@ func (c *Cluster) getConfig() ([]byte, error) {
 
 	portMappingPairs := parsePortMappings(c.extraPortsMapping)
 
-	registryConfig := findRegistryConfig(c.registryConfig)
+	registryConfig := FindRegistryConfig(c.registryConfig)
 
 	registryCertsDir, err := renderRegistryCertsDir(c.cfg)
 
@@ -222,7 +223,8 @@ func (c *Cluster) ExportKubeConfig(name string, internal bool) error {
 }
 
 func (c *Cluster) ensureCorrectConfig(in []byte) (kindv1alpha4.Cluster, error) {
-	// see pkg/kind/resources/kind.yaml.tmpl and pkg/controllers/localbuild/resources/nginx/k8s/ingress-nginx.yaml
+	// see pkg/kind/resources/kind.yaml.tmpl, pkg/controllers/localbuild/resources/nginx/k8s/ingress-nginx.yaml
+	// and pkg/controllers/localbuild/resources/gateway/k8s/gateway.yaml
 	// defines which container port we should be looking for.
 	containerPort := "443"
 	if c.cfg.Protocol == "http" {
@@ -233,10 +235,10 @@ func (c *Cluster) ensureCorrectConfig(in []byte) (kindv1alpha4.Cluster, error) {
 	if err != nil {
 		return kindv1alpha4.Cluster{}, fmt.Errorf("parsing kind config: %w", err)
 	}
-	// the port and ingress-nginx label must be on the same node to ensure nginx runs on the node with the right port.
+	// the port and ingress label must be on the same node to ensure the ingress controller runs on the node with the right port.
 	appendNecessaryPort := true
 	appendIngressNodeLabel := true
-	// pick the first node for the ingress-nginx if we need to configure node port.
+	// pick the first node for the ingress controller if we need to configure node port.
 	nodePosition := 0
 
 	if parsedCluster.Nodes == nil || len(parsedCluster.Nodes) == 0 {
@@ -251,8 +253,8 @@ nodes:
 				appendNecessaryPort = false
 				nodePosition = i
 				if node.Labels != nil {
-					v, ok := node.Labels[ingressNginxNodeLabelKey]
-					if ok && v == ingressNginxNodeLabelValue {
+					v, ok := node.Labels[ingressNodeLabelKey]
+					if ok && v == ingressNodeLabelValue {
 						appendIngressNodeLabel = false
 					}
 				}
@@ -260,8 +262,8 @@ nodes:
 			}
 		}
 		if node.Labels != nil {
-			v, ok := node.Labels[ingressNginxNodeLabelKey]
-			if ok && v == ingressNginxNodeLabelValue {
+			v, ok := node.Labels[ingressNodeLabelKey]
+			if ok && v == ingressNodeLabelValue {
 				appendIngressNodeLabel = false
 				nodePosition = i
 				break nodes
@@ -287,7 +289,7 @@ nodes:
 		if parsedCluster.Nodes[nodePosition].Labels == nil {
 			parsedCluster.Nodes[nodePosition].Labels = make(map[string]string)
 		}
-		parsedCluster.Nodes[nodePosition].Labels[ingressNginxNodeLabelKey] = ingressNginxNodeLabelValue
+		parsedCluster.Nodes[nodePosition].Labels[ingressNodeLabelKey] = ingressNodeLabelValue
 	}
 
 	return parsedCluster, nil
@@ -77,7 +77,8 @@ func parsePortMappings(extraPortsMapping string) []PortMapping {
 	return portMappingPairs
 }
 
-func findRegistryConfig(registryConfigPaths []string) string {
+// FindRegistryConfig returns the first of the given paths that exists, after expanding environment variables.
+func FindRegistryConfig(registryConfigPaths []string) string {
 	for _, s := range registryConfigPaths {
 		path := os.ExpandEnv(s)
 		if _, err := os.Stat(path); err == nil {
@@ -178,7 +178,7 @@ func TestFindRegistryConfig(t *testing.T) {
 	}
 
 	for _, tc := range tests {
-		out := findRegistryConfig(tc.paths)
+		out := FindRegistryConfig(tc.paths)
 		if !reflect.DeepEqual(tc.expected, out) {
 			t.Errorf("expected:\n%v\ngot:\n%v", tc.expected, out)
 		}
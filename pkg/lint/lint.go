@@ -0,0 +1,583 @@
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	argocdapp "github.com/cnoe-io/argocd-api/api/argo/application"
+	argov1alpha1 "github.com/cnoe-io/argocd-api/api/argo/application/v1alpha1"
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
+	"github.com/cnoe-io/idpbuilder/pkg/k8s"
+	"github.com/cnoe-io/idpbuilder/pkg/packages"
+	"github.com/cnoe-io/idpbuilder/pkg/resources/localbuild"
+	"github.com/cnoe-io/idpbuilder/pkg/util"
+	"github.com/cnoe-io/idpbuilder/pkg/util/files"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+// yamlErrorLine matches the line number of yaml syntax errors, e.g. "yaml: line 3: mapping values are not allowed".
+var yamlErrorLine = regexp.MustCompile(`line (\d+)`)
+
+// Problem is a mistake in a package. Line is 0 when the problem does not refer to a line of File.
+type Problem struct {
+	File    string
+	Line    int
+	Message string
+}
+
+func (p Problem) String() string {
+	if p.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", p.File, p.Line, p.Message)
+	}
+	return fmt.Sprintf("%s: %s", p.File, p.Message)
+}
+
+// Options are the packages to lint and the data used to render their templated files.
+type Options struct {
+	// Packages are package strings as passed to idpbuilder create with -p.
+	Packages []string
+	// Values are the values of all packages.
+	Values *apiextensionsv1.JSON
+	// TemplateData is the configuration of the cluster that templated package files are rendered with.
+	TemplateData v1alpha1.BuildCustomizationSpec
+}
+
+type linter struct {
+	opts     Options
+	problems []Problem
+	// dependencies are the applications each linted application depends on.
+	dependencies map[string][]string
+	// apps are the locations of the linted applications.
+	apps map[string]Problem
+	// fluxApps are the Flux Kustomizations and HelmReleases of the current file. They are checked once its
+	// GitRepository sources are read.
+	fluxApps []fluxDocument
+	// fluxSources are the GitRepository sources of the current file by name.
+	fluxSources map[string]string
+}
+
+// fluxDocument is a Flux Kustomization or HelmRelease of a package file.
+type fluxDocument struct {
+	n *yaml.Node
+	u *unstructured.Unstructured
+}
+
+// Lint checks the local packages without a cluster. Remote packages, OCI artifacts, Helm charts and archives are only
+// checked for a valid package string, their contents are not fetched.
+func Lint(opts Options) []Problem {
+	l := &linter{opts: opts, dependencies: map[string][]string{}, apps: map[string]Problem{}}
+
+	for _, p := range opts.Packages {
+		// packages are parsed one by one so that all invalid packages are reported
+		locs, err := packages.ParsePackageStrings([]string{p})
+		if err != nil {
+			l.add(p, 0, "invalid package: %v", err)
+			continue
+		}
+		for _, f := range locs.Files {
+			values, vErr := l.packageValues(nil, locs.ValuesFiles[f])
+			if vErr != nil {
+				l.add(f, 0, "%v", vErr)
+				continue
+			}
+			l.lintFile(f, values)
+		}
+		for _, d := range locs.Dirs {
+			l.lintDir(d, locs.ValuesFiles[d])
+		}
+	}
+
+	l.lintDependencies()
+
+	sort.SliceStable(l.problems, func(i, j int) bool {
+		if l.problems[i].File != l.problems[j].File {
+			return l.problems[i].File < l.problems[j].File
+		}
+		return l.problems[i].Line < l.problems[j].Line
+	})
+	return l.problems
+}
+
+func (l *linter) add(file string, line int, format string, args ...interface{}) {
+	l.problems = append(l.problems, Problem{File: file, Line: line, Message: fmt.Sprintf(format, args...)})
+}
+
+// packageValues merges the default values of the package with the values of all packages and the values file of the
+// package, in this order of precedence. The order is the same as for packages installed by create.
+func (l *linter) packageValues(defaults []byte, valuesFile string) (map[string]interface{}, error) {
+	values, err := util.ParseValues(defaults)
+	if err != nil {
+		return nil, fmt.Errorf("parsing default values: %w", err)
+	}
+	global, err := util.JSONToValues(l.opts.Values)
+	if err != nil {
+		return nil, err
+	}
+	values = util.MergeValues(values, global)
+	if valuesFile != "" {
+		local, rErr := util.ReadValuesFiles(valuesFile)
+		if rErr != nil {
+			return nil, rErr
+		}
+		values = util.MergeValues(values, local)
+	}
+	return values, nil
+}
+
+// lintDir checks the yaml files in the package directory like idpbuilder create reads them.
+func (l *linter) lintDir(dir, valuesFile string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		l.add(dir, 0, "reading directory: %v", err)
+		return
+	}
+
+	defaults, err := os.ReadFile(filepath.Join(dir, util.PackageValuesFile))
+	if err != nil && !os.IsNotExist(err) {
+		l.add(filepath.Join(dir, util.PackageValuesFile), 0, "reading default values: %v", err)
+		return
+	}
+	values, err := l.packageValues(defaults, valuesFile)
+	if err != nil {
+		l.add(dir, 0, "%v", err)
+		return
+	}
+
+	apps := len(l.apps)
+	for _, e := range entries {
+		if !e.Type().IsRegular() || !util.IsYamlFile(e.Name()) || e.Name() == util.PackageValuesFile {
+			continue
+		}
+		l.lintFile(filepath.Join(dir, e.Name()), values)
+	}
+	if len(l.apps) == apps {
+		if l.isFlux() {
+			l.add(dir, 0, "no Flux Kustomization or HelmRelease found in package directory")
+			return
+		}
+		l.add(dir, 0, "no Argo CD Application or ApplicationSet found in package directory")
+	}
+}
+
+// lintFile checks every document of a package file.
+func (l *linter) lintFile(file string, values map[string]interface{}) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		l.add(file, 0, "reading file: %v", err)
+		return
+	}
+
+	l.fluxApps, l.fluxSources = nil, map[string]string{}
+	defer l.lintFluxApplications(file, values)
+
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	for {
+		doc := &yaml.Node{}
+		err = dec.Decode(doc)
+		if errors.Is(err, io.EOF) {
+			return
+		}
+		if err != nil {
+			l.add(file, yamlErrorLineNumber(err), "invalid yaml: %v", err)
+			return
+		}
+		if len(doc.Content) == 0 {
+			continue
+		}
+		l.lintDocument(file, doc.Content[0], values)
+	}
+}
+
+func (l *linter) lintDocument(file string, n *yaml.Node, values map[string]interface{}) {
+	if n.Kind != yaml.MappingNode {
+		l.add(file, n.Line, "document is not a kubernetes manifest")
+		return
+	}
+	b, err := yaml.Marshal(n)
+	if err != nil {
+		l.add(file, n.Line, "encoding document: %v", err)
+		return
+	}
+	u := &unstructured.Unstructured{}
+	if err = sigsyaml.Unmarshal(b, &u.Object); err != nil {
+		l.add(file, n.Line, "invalid kubernetes manifest: %v", err)
+		return
+	}
+	if u.GetAPIVersion() == "" || u.GetKind() == "" {
+		l.add(file, n.Line, "invalid kubernetes manifest: apiVersion and kind must be set")
+		return
+	}
+	if packages.IsPackageResource(u) {
+		return
+	}
+	if l.isFlux() {
+		l.lintFluxDocument(file, n, u)
+		return
+	}
+
+	gvk := u.GroupVersionKind()
+	if gvk.Group != argocdapp.Group || (gvk.Kind != argocdapp.ApplicationKind && gvk.Kind != argocdapp.ApplicationSetKind) {
+		l.add(file, lineOf(n, "kind"), "unsupported kind %s of %s: packages may contain Applications, "+
+			"ApplicationSets, AppProjects and Argo CD repository and cluster secrets", gvk.Kind, u.GetName())
+		return
+	}
+
+	objs, err := k8s.ConvertYamlToObjects(k8s.GetScheme(), b)
+	if err != nil || len(objs) != 1 {
+		l.add(file, n.Line, "decoding %s %s: %v", gvk.Kind, u.GetName(), err)
+		return
+	}
+	l.dependencies[u.GetName()] = util.GetPackageDependencies(u.GetAnnotations())
+	l.apps[u.GetName()] = Problem{File: file, Line: lineOf(n, "metadata", "name")}
+
+	switch o := objs[0].(type) {
+	case *argov1alpha1.Application:
+		l.lintApplication(file, n, o, values)
+	case *argov1alpha1.ApplicationSet:
+		l.lintApplicationSet(file, n, o, values)
+	}
+}
+
+func (l *linter) lintApplication(file string, n *yaml.Node, app *argov1alpha1.Application, values map[string]interface{}) {
+	if app.Spec.Destination.Server == "" && app.Spec.Destination.Name == "" {
+		l.add(file, lineOf(n, "spec", "destination"), "application %s has no destination: "+
+			"set spec.destination.server or spec.destination.name", app.Name)
+	}
+	l.lintSources(file, n, []string{"spec"}, app.Name, app.Spec.Destination.Namespace, app.Spec.Source, app.Spec.Sources, values)
+}
+
+func (l *linter) lintApplicationSet(file string, n *yaml.Node, appSet *argov1alpha1.ApplicationSet, values map[string]interface{}) {
+	spec := appSet.Spec.Template.Spec
+	// the destination may be set by the template patch
+	if spec.Destination.Server == "" && spec.Destination.Name == "" && appSet.Spec.TemplatePatch == nil {
+		l.add(file, lineOf(n, "spec", "template", "spec", "destination"), "applicationset %s has no destination: "+
+			"set spec.template.spec.destination.server or spec.template.spec.destination.name", appSet.Name)
+	}
+
+	urls, err := packages.ApplicationSetCNOEURLs(appSet)
+	if err != nil {
+		l.add(file, lineOf(n, "spec", "generators"), "reading generators of %s: %v", appSet.Name, err)
+	}
+	for _, u := range urls {
+		if _, cErr := packages.GetCNOEAbsPath(file, u); cErr != nil {
+			l.add(file, lineOf(n, "spec", "generators"), "applicationset %s: invalid %s: %v", appSet.Name, u, cErr)
+		}
+	}
+	l.lintSources(file, n, []string{"spec", "template", "spec"}, appSet.Name, spec.Destination.Namespace, spec.Source, spec.Sources, values)
+}
+
+// lintSources checks the single source or the sources of the application spec at specPath.
+func (l *linter) lintSources(file string, n *yaml.Node, specPath []string, appName, namespace string, source *argov1alpha1.ApplicationSource,
+	sources argov1alpha1.ApplicationSources, values map[string]interface{},
+) {
+	if len(sources) > 0 {
+		if err := packages.ValidateValueFiles(file, true, sources); err != nil {
+			l.add(file, lineOf(n, path(specPath, "sources")...), "%s: %v", appName, err)
+		}
+		for i := range sources {
+			line := lineOf(n, path(specPath, "sources", strconv.Itoa(i), "repoURL")...)
+			l.lintSource(file, line, appName, namespace, &sources[i], values)
+		}
+		return
+	}
+	if source == nil {
+		l.add(file, lineOf(n, specPath...), "%s has no source: set source or sources", appName)
+		return
+	}
+	l.lintSource(file, lineOf(n, path(specPath, "source", "repoURL")...), appName, namespace, source, values)
+}
+
+// lintSource checks that the directory of a cnoe:// source exists and that its manifests build.
+func (l *linter) lintSource(file string, line int, appName, namespace string, s *argov1alpha1.ApplicationSource, values map[string]interface{}) {
+	if !packages.IsCNOEScheme(s.RepoURL) {
+		return
+	}
+	dir, err := packages.GetCNOEAbsPath(file, s.RepoURL)
+	if err != nil {
+		l.add(file, line, "%s: invalid source %s: %v", appName, s.RepoURL, err)
+		return
+	}
+	// ref only sources provide value files, paths with generator parameters are resolved by Argo CD
+	if (s.Ref != "" && s.Path == "" && s.Chart == "") || strings.Contains(s.Path, "{{") {
+		return
+	}
+
+	// templated files are rendered in a copy of the directory, like they are before they are pushed to the git server
+	tmpDir, err := os.MkdirTemp("", "idpbuilder-lint-")
+	if err != nil {
+		l.add(file, line, "creating temp dir: %v", err)
+		return
+	}
+	defer os.RemoveAll(tmpDir)
+	if err = files.CopyDirectory(dir, tmpDir); err != nil {
+		l.add(file, line, "%s: copying %s: %v", appName, dir, err)
+		return
+	}
+	valuesJSON, err := util.ValuesToJSON(values)
+	if err != nil {
+		l.add(file, line, "%s: %v", appName, err)
+		return
+	}
+	data, err := util.NewPackageTemplateData(l.opts.TemplateData, valuesJSON)
+	if err != nil {
+		l.add(file, line, "%s: %v", appName, err)
+		return
+	}
+	// errors refer to the source directory instead of the copy
+	restore := func(err error) string {
+		return strings.ReplaceAll(err.Error(), tmpDir, dir)
+	}
+	if err = util.RenderPackageTemplates(tmpDir, data); err != nil {
+		l.add(file, line, "%s: %s", appName, restore(err))
+		return
+	}
+
+	srcDir := filepath.Join(tmpDir, s.Path)
+	if info, sErr := os.Stat(srcDir); sErr != nil || !info.IsDir() {
+		l.add(file, line, "%s: path %s is not a directory in %s", appName, s.Path, dir)
+		return
+	}
+
+	switch {
+	case isHelmChartDir(srcDir):
+		helmValues, hErr := helmSourceValues(srcDir, s, values)
+		if hErr != nil {
+			l.add(file, line, "%s: %s", appName, restore(hErr))
+			return
+		}
+		ref := util.HelmChartRef{ReleaseName: appName, Namespace: namespace}
+		if s.Helm != nil && s.Helm.ReleaseName != "" {
+			ref.ReleaseName = s.Helm.ReleaseName
+		}
+		if _, hErr = util.RenderHelmChartDir(srcDir, ref, helmValues); hErr != nil {
+			l.add(file, line, "%s: %s", appName, restore(hErr))
+		}
+	case util.IsKustomizationDir(srcDir):
+		if _, kErr := util.BuildKustomization(srcDir); kErr != nil {
+			l.add(file, line, "%s: %s", appName, restore(kErr))
+		}
+	default:
+		recurse := s.Directory != nil && s.Directory.Recurse
+		wErr := filepath.WalkDir(srcDir, func(p string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if p != srcDir && !recurse {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !d.Type().IsRegular() || !util.IsYamlFile(p) {
+				return nil
+			}
+			if vErr := packages.ValidateKubernetesYamlFile(p); vErr != nil {
+				l.add(sourceFile(tmpDir, dir, p), 0, "%s", restore(vErr))
+			}
+			return nil
+		})
+		if wErr != nil {
+			l.add(file, line, "%s: %s", appName, restore(wErr))
+		}
+	}
+}
+
+func (l *linter) isFlux() bool {
+	return l.opts.TemplateData.GitOpsEngine == v1alpha1.GitOpsEngineFlux
+}
+
+// lintFluxDocument records the Flux Kustomizations, HelmReleases and GitRepository sources of a package file.
+func (l *linter) lintFluxDocument(file string, n *yaml.Node, u *unstructured.Unstructured) {
+	gvk := u.GroupVersionKind()
+	switch {
+	case gvk.Group == localbuild.FluxSourceGroup && gvk.Kind == localbuild.FluxGitRepositoryKind:
+		url, _, _ := unstructured.NestedString(u.Object, "spec", "url")
+		l.fluxSources[u.GetName()] = url
+	case localbuild.IsFluxApplication(gvk):
+		l.apps[u.GetName()] = Problem{File: file, Line: lineOf(n, "metadata", "name")}
+		l.fluxApps = append(l.fluxApps, fluxDocument{n: n, u: u})
+	default:
+		l.add(file, lineOf(n, "kind"), "unsupported kind %s of %s: packages may contain Kustomizations, "+
+			"HelmReleases, GitRepositories, AppProjects and Argo CD repository and cluster secrets", gvk.Kind, u.GetName())
+	}
+}
+
+// lintFluxApplications checks the Flux Kustomizations and HelmReleases of a package file. The directories of their
+// cnoe:// GitRepository sources in the same file are checked like the sources of Argo CD applications.
+func (l *linter) lintFluxApplications(file string, values map[string]interface{}) {
+	for _, d := range l.fluxApps {
+		name := d.u.GetName()
+		source := &argov1alpha1.ApplicationSource{}
+		var refPath []string
+		if d.u.GetKind() == localbuild.FluxKustomizationKind {
+			refPath = []string{"spec", "sourceRef"}
+			source.Path, _, _ = unstructured.NestedString(d.u.Object, "spec", "path")
+			// Flux applies the manifests of all subdirectories when the path has no kustomization
+			source.Directory = &argov1alpha1.ApplicationSourceDirectory{Recurse: true}
+		} else {
+			if _, ok, _ := unstructured.NestedMap(d.u.Object, "spec", "chartRef"); ok {
+				continue
+			}
+			refPath = []string{"spec", "chart", "spec", "sourceRef"}
+			source.Path, _, _ = unstructured.NestedString(d.u.Object, "spec", "chart", "spec", "chart")
+			helmValues, _, _ := unstructured.NestedMap(d.u.Object, "spec", "values")
+			b, err := json.Marshal(helmValues)
+			if err != nil {
+				l.add(file, lineOf(d.n, "spec", "values"), "%s: encoding values: %v", name, err)
+				continue
+			}
+			source.Helm = &argov1alpha1.ApplicationSourceHelm{ValuesObject: &runtime.RawExtension{Raw: b}}
+		}
+
+		ref, ok, _ := unstructured.NestedStringMap(d.u.Object, refPath...)
+		if !ok || ref["name"] == "" {
+			l.add(file, lineOf(d.n, refPath[:len(refPath)-1]...), "%s %s has no source: set %s", d.u.GetKind(), name,
+				strings.Join(refPath[1:], "."))
+			continue
+		}
+		url, found := l.fluxSources[ref["name"]]
+		if ref["kind"] != localbuild.FluxGitRepositoryKind || !found {
+			// sources of other kinds or in other files are applied unchanged
+			continue
+		}
+		source.RepoURL = url
+		l.lintSource(file, lineOf(d.n, refPath...), name, d.u.GetNamespace(), source, values)
+	}
+}
+
+// lintDependencies reports the dependency cycles between the linted applications.
+func (l *linter) lintDependencies() {
+	names := make([]string, 0, len(l.dependencies))
+	for name := range l.dependencies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	reported := map[string]bool{}
+	for _, name := range names {
+		cycle := packages.FindDependencyCycle(l.dependencies, name)
+		if cycle == nil {
+			continue
+		}
+		members := append([]string{}, cycle[:len(cycle)-1]...)
+		sort.Strings(members)
+		key := strings.Join(members, ",")
+		if reported[key] {
+			continue
+		}
+		reported[key] = true
+		loc := l.apps[name]
+		l.add(loc.File, loc.Line, "dependency cycle detected: %s", strings.Join(cycle, " -> "))
+	}
+}
+
+func isHelmChartDir(dir string) bool {
+	info, err := os.Stat(filepath.Join(dir, "Chart.yaml"))
+	return err == nil && info.Mode().IsRegular()
+}
+
+// helmSourceValues merges the values of the helm source in the order Argo CD applies them: value files, values,
+// valuesObject. Package values take precedence, like they do for packages installed by create.
+// Value files of other sources and remote value files are not read.
+func helmSourceValues(chartDir string, s *argov1alpha1.ApplicationSource, pkgValues map[string]interface{}) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	if s.Helm == nil {
+		return values, nil
+	}
+	for _, vf := range s.Helm.ValueFiles {
+		if strings.HasPrefix(vf, "$") || strings.Contains(vf, "://") {
+			continue
+		}
+		v, err := util.ReadValuesFiles(filepath.Join(chartDir, vf))
+		if err != nil {
+			if s.Helm.IgnoreMissingValueFiles && errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		values = util.MergeValues(values, v)
+	}
+	for _, raw := range [][]byte{[]byte(s.Helm.Values), helmValuesObject(s.Helm)} {
+		v, err := util.ParseValues(raw)
+		if err != nil {
+			return nil, fmt.Errorf("parsing helm values: %w", err)
+		}
+		values = util.MergeValues(values, v)
+	}
+	return util.MergeValues(values, pkgValues), nil
+}
+
+func helmValuesObject(h *argov1alpha1.ApplicationSourceHelm) []byte {
+	if h.ValuesObject == nil {
+		return nil
+	}
+	return h.ValuesObject.Raw
+}
+
+// sourceFile returns the path of the file in the source directory that p was copied or rendered from.
+func sourceFile(tmpDir, dir, p string) string {
+	rel, err := filepath.Rel(tmpDir, p)
+	if err != nil {
+		return p
+	}
+	f := filepath.Join(dir, rel)
+	if _, err = os.Stat(f); err != nil {
+		return f + util.TemplateFileExt
+	}
+	return f
+}
+
+// yamlErrorLineNumber returns the line number of a yaml syntax error or 0.
+func yamlErrorLineNumber(err error) int {
+	m := yamlErrorLine.FindStringSubmatch(err.Error())
+	if m == nil {
+		return 0
+	}
+	line, _ := strconv.Atoi(m[1])
+	return line
+}
+
+// lineOf returns the line of the value at path in the mapping node n, or the line of the deepest parent found.
+// Path elements of sequences are indexes.
+func lineOf(n *yaml.Node, path ...string) int {
+	line := n.Line
+	for _, p := range path {
+		var next *yaml.Node
+		switch n.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				if n.Content[i].Value == p {
+					next, line = n.Content[i+1], n.Content[i].Line
+					break
+				}
+			}
+		case yaml.SequenceNode:
+			if i, err := strconv.Atoi(p); err == nil && i < len(n.Content) {
+				next, line = n.Content[i], n.Content[i].Line
+			}
+		}
+		if next == nil {
+			return line
+		}
+		n = next
+	}
+	return line
+}
+
+// path returns a copy of parent with the elements appended.
+func path(parent []string, elems ...string) []string {
+	return append(append([]string{}, parent...), elems...)
+}
@@ -0,0 +1,254 @@
+package lint
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(p), 0700))
+		assert.NoError(t, os.WriteFile(p, []byte(content), 0600))
+	}
+}
+
+const validApp = `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: app
+  namespace: argocd
+spec:
+  project: default
+  destination:
+    server: https://kubernetes.default.svc
+  source:
+    repoURL: cnoe://manifests
+    path: "."
+`
+
+func TestLintValidPackages(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"manifests/cm.yaml.tmpl": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cm\ndata:\n  host: {{ .Host }}\n",
+		"app.yaml":               validApp,
+		"kustomize/app.yaml": `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: kustomize
+spec:
+  destination:
+    name: in-cluster
+  source:
+    repoURL: cnoe://kustomize
+    path: overlay
+`,
+		"kustomize/kustomize/base/cm.yaml":               "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cm\n",
+		"kustomize/kustomize/base/kustomization.yaml":    "resources:\n- cm.yaml\n",
+		"kustomize/kustomize/overlay/kustomization.yaml": "resources:\n- ../base\nnamePrefix: dev-\n",
+		"helm/app.yaml": `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: helm
+spec:
+  destination:
+    server: https://kubernetes.default.svc
+  source:
+    repoURL: cnoe://chart
+    path: "."
+    helm:
+      valuesObject:
+        name: from-values-object
+`,
+		"helm/chart/Chart.yaml":             "apiVersion: v2\nname: chart\nversion: 0.1.0\n",
+		"helm/chart/templates/cm.yaml":      "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ .Values.name }}\n",
+		"helm/idpbuilder-values.yaml":       "replicas: 1\n",
+		"helm/chart/templates/_helpers.tpl": "",
+	})
+
+	problems := Lint(Options{Packages: []string{dir, filepath.Join(dir, "kustomize"), filepath.Join(dir, "helm")}})
+	assert.Empty(t, problems)
+}
+
+func TestLintInvalidPackages(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"app.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm
+---
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: a
+  annotations:
+    cnoe.io/depends-on: b
+spec:
+  project: default
+  source:
+    repoURL: cnoe://missing
+---
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: b
+  annotations:
+    cnoe.io/depends-on: a
+spec:
+  destination:
+    server: https://kubernetes.default.svc
+  source:
+    repoURL: cnoe://manifests
+    path: "."
+`,
+		"broken.yaml":           "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cm\n data: {}\n",
+		"manifests/broken.yaml": "data: [\n",
+	})
+
+	problems := Lint(Options{Packages: []string{dir, filepath.Join(dir, "missing")}})
+	expected := []struct {
+		file    string
+		line    int
+		message string
+	}{
+		{"app.yaml", 2, "unsupported kind ConfigMap of cm"},
+		{"app.yaml", 9, "dependency cycle detected: a -> b -> a"},
+		{"app.yaml", 12, "application a has no destination"},
+		{"app.yaml", 15, "a: invalid source cnoe://missing"},
+		{"broken.yaml", 4, "invalid yaml"},
+		{"manifests/broken.yaml", 0, "failed parsing file as kubernetes manifests file"},
+		{"missing", 0, "invalid package"},
+	}
+	assert.Len(t, problems, len(expected))
+	for i := 0; i < len(expected) && i < len(problems); i++ {
+		assert.Equal(t, filepath.Join(dir, expected[i].file), problems[i].File)
+		assert.Equal(t, expected[i].line, problems[i].Line, problems[i].String())
+		assert.Contains(t, problems[i].Message, expected[i].message)
+	}
+}
+
+func TestLintKustomizeAndHelmErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"app.yaml": `apiVersion: argoproj.io/v1alpha1
+kind: ApplicationSet
+metadata:
+  name: appset
+spec:
+  generators:
+    - git:
+        repoURL: cnoe://missing
+        directories:
+          - path: "*"
+  template:
+    metadata:
+      name: "{{path.basename}}"
+    spec:
+      destination:
+        server: https://kubernetes.default.svc
+      sources:
+        - repoURL: cnoe://kustomize
+          path: "."
+        - repoURL: cnoe://chart
+          path: "."
+`,
+		"kustomize/kustomization.yaml": "resources:\n- missing.yaml\n",
+		"chart/Chart.yaml":             "apiVersion: v2\nname: chart\nversion: 0.1.0\n",
+		"chart/templates/cm.yaml":      "name: {{ .Values.missing.name }}\n",
+	})
+
+	problems := Lint(Options{Packages: []string{dir}})
+	assert.Len(t, problems, 3)
+	for _, p := range problems {
+		assert.Equal(t, filepath.Join(dir, "app.yaml"), p.File)
+	}
+	assert.Equal(t, 6, problems[0].Line)
+	assert.Contains(t, problems[0].Message, "invalid cnoe://missing")
+	assert.Equal(t, 18, problems[1].Line)
+	assert.Contains(t, problems[1].Message, "building kustomization "+filepath.Join(dir, "kustomize"))
+	assert.Equal(t, 20, problems[2].Line)
+	assert.Contains(t, problems[2].Message, "rendering chart")
+}
+
+func TestLintFluxPackages(t *testing.T) {
+	// the example of docs/flux.md
+	doc, err := os.ReadFile("../../docs/flux.md")
+	assert.NoError(t, err)
+	example := strings.SplitN(strings.SplitN(string(doc), "```yaml\n", 2)[1], "```", 2)[0]
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"podinfo.yaml":                      example,
+		"podinfo/deployment.yaml":           "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: podinfo\n",
+		"podinfo/service/svc.yaml":          "apiVersion: v1\nkind: Service\nmetadata:\n  name: podinfo\n",
+		"helm/release.yaml":                 fluxHelmRelease,
+		"helm/charts/app/Chart.yaml":        "apiVersion: v2\nname: app\nversion: 0.1.0\n",
+		"helm/charts/app/templates/cm.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ .Values.name }}\n",
+	})
+	flux := v1alpha1.BuildCustomizationSpec{GitOpsEngine: v1alpha1.GitOpsEngineFlux}
+
+	problems := Lint(Options{Packages: []string{dir, filepath.Join(dir, "helm")}, TemplateData: flux})
+	assert.Empty(t, problems)
+
+	// Flux objects are not packages for Argo CD
+	problems = Lint(Options{Packages: []string{dir}})
+	assert.NotEmpty(t, problems)
+
+	writeFiles(t, dir, map[string]string{"podinfo/bad.yaml": "kind: ConfigMap\n"})
+	problems = Lint(Options{Packages: []string{dir}, TemplateData: flux})
+	assert.Len(t, problems, 1)
+	assert.Equal(t, filepath.Join(dir, "podinfo", "bad.yaml"), problems[0].File)
+
+	writeFiles(t, dir, map[string]string{"podinfo/bad.yaml": validApp})
+	problems = Lint(Options{Packages: []string{dir}, TemplateData: flux})
+	assert.Empty(t, problems)
+	writeFiles(t, dir, map[string]string{"app.yaml": validApp})
+	problems = Lint(Options{Packages: []string{dir}, TemplateData: flux})
+	assert.Len(t, problems, 1)
+	assert.Contains(t, problems[0].Message, "unsupported kind Application of app")
+}
+
+const fluxHelmRelease = `apiVersion: source.toolkit.fluxcd.io/v1
+kind: GitRepository
+metadata:
+  name: charts
+  namespace: flux-system
+spec:
+  interval: 1m
+  url: cnoe://charts
+---
+apiVersion: helm.toolkit.fluxcd.io/v2
+kind: HelmRelease
+metadata:
+  name: app
+  namespace: flux-system
+spec:
+  interval: 1m
+  chart:
+    spec:
+      chart: ./app
+      sourceRef:
+        kind: GitRepository
+        name: charts
+  values:
+    name: app
+`
+
+func TestLineOf(t *testing.T) {
+	doc := &yaml.Node{}
+	assert.NoError(t, yaml.Unmarshal([]byte(validApp), doc))
+	n := doc.Content[0]
+
+	assert.Equal(t, 8, lineOf(n, "spec", "destination"))
+	assert.Equal(t, 11, lineOf(n, "spec", "source", "repoURL"))
+	// the deepest parent found is returned
+	assert.Equal(t, 10, lineOf(n, "spec", "source", "missing"))
+	assert.Equal(t, 6, lineOf(n, "spec", "sources", "0", "repoURL"))
+}
@@ -0,0 +1,106 @@
+package packages
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	argov1alpha1 "github.com/cnoe-io/argocd-api/api/argo/application/v1alpha1"
+)
+
+// cnoeURLPattern matches cnoe:// urls in free-form text such as template patches.
+var cnoeURLPattern = regexp.MustCompile(`cnoe://[^\s"'{}]*`)
+
+// ApplicationSetRewriter replaces cnoe:// urls in ApplicationSet generators and template patches.
+type ApplicationSetRewriter struct {
+	// URL returns the url that replaces a cnoe:// url.
+	URL func(repoURL string) (string, error)
+	// GitGenerator, when set, is called with every git generator before its urls are replaced.
+	GitGenerator func(g map[string]interface{})
+}
+
+// RewriteGenerators replaces the cnoe:// urls in the generators at any depth, including the generators nested in
+// matrix and merge generators, list generator elements and generator templates.
+func (w *ApplicationSetRewriter) RewriteGenerators(generators []argov1alpha1.ApplicationSetGenerator) ([]argov1alpha1.ApplicationSetGenerator, error) {
+	b, err := json.Marshal(generators)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling generators: %w", err)
+	}
+	var in []interface{}
+	if err = json.Unmarshal(b, &in); err != nil {
+		return nil, fmt.Errorf("unmarshalling generators: %w", err)
+	}
+	out, err := w.walk(in)
+	if err != nil {
+		return nil, err
+	}
+	if b, err = json.Marshal(out); err != nil {
+		return nil, fmt.Errorf("marshalling generators: %w", err)
+	}
+	rewritten := []argov1alpha1.ApplicationSetGenerator{}
+	if err = json.Unmarshal(b, &rewritten); err != nil {
+		return nil, fmt.Errorf("unmarshalling generators: %w", err)
+	}
+	return rewritten, nil
+}
+
+func (w *ApplicationSetRewriter) walk(v interface{}) (interface{}, error) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if g, ok := t["git"].(map[string]interface{}); ok && w.GitGenerator != nil {
+			w.GitGenerator(g)
+		}
+		for k := range t {
+			nv, err := w.walk(t[k])
+			if err != nil {
+				return nil, err
+			}
+			t[k] = nv
+		}
+	case []interface{}:
+		for i := range t {
+			nv, err := w.walk(t[i])
+			if err != nil {
+				return nil, err
+			}
+			t[i] = nv
+		}
+	case string:
+		if IsCNOEScheme(t) {
+			return w.URL(t)
+		}
+	}
+	return v, nil
+}
+
+// RewriteText replaces the cnoe:// urls in text, e.g. the templatePatch of an ApplicationSet.
+func (w *ApplicationSetRewriter) RewriteText(text string) (string, error) {
+	var rErr error
+	out := cnoeURLPattern.ReplaceAllStringFunc(text, func(s string) string {
+		u, err := w.URL(s)
+		if err != nil && rErr == nil {
+			rErr = err
+		}
+		return u
+	})
+	return out, rErr
+}
+
+// ApplicationSetCNOEURLs returns the cnoe:// urls of the generators and the template patch of the ApplicationSet.
+// The sources of the template are not included.
+func ApplicationSetCNOEURLs(appSet *argov1alpha1.ApplicationSet) ([]string, error) {
+	var urls []string
+	w := &ApplicationSetRewriter{URL: func(repoURL string) (string, error) {
+		urls = append(urls, repoURL)
+		return repoURL, nil
+	}}
+	if _, err := w.RewriteGenerators(appSet.Spec.Generators); err != nil {
+		return nil, err
+	}
+	if appSet.Spec.TemplatePatch != nil {
+		if _, err := w.RewriteText(*appSet.Spec.TemplatePatch); err != nil {
+			return nil, err
+		}
+	}
+	return urls, nil
+}
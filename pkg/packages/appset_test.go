@@ -0,0 +1,57 @@
+package packages
+
+import (
+	"testing"
+
+	argov1alpha1 "github.com/cnoe-io/argocd-api/api/argo/application/v1alpha1"
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+func TestApplicationSetRewriter(t *testing.T) {
+	w := &ApplicationSetRewriter{
+		URL: func(repoURL string) (string, error) {
+			return "http://gitea/" + repoURL[len(v1alpha1.CNOEURIScheme):], nil
+		},
+	}
+
+	nestedMatrix := `{"generators":[{"git":{"repoURL":"cnoe://apps","directories":[{"path":"*"}]}},{"list":{"elements":[{"url":"cnoe://config"}]}}]}`
+	generators := []argov1alpha1.ApplicationSetGenerator{{
+		Merge: &argov1alpha1.MergeGenerator{
+			MergeKeys: []string{"path"},
+			Generators: []argov1alpha1.ApplicationSetNestedGenerator{
+				{Matrix: &apiextensionsv1.JSON{Raw: []byte(nestedMatrix)}},
+				{List: &argov1alpha1.ListGenerator{Elements: []apiextensionsv1.JSON{{Raw: []byte(`{"repo":"cnoe://values","name":"a"}`)}}}},
+			},
+		},
+	}}
+
+	out, err := w.RewriteGenerators(generators)
+	assert.NoError(t, err)
+	nested, err := argov1alpha1.ToNestedMatrixGenerator(out[0].Merge.Generators[0].Matrix)
+	assert.NoError(t, err)
+	assert.Equal(t, "http://gitea/apps", nested.Generators[0].Git.RepoURL)
+	assert.JSONEq(t, `{"url":"http://gitea/config"}`, string(nested.Generators[1].List.Elements[0].Raw))
+	assert.JSONEq(t, `{"repo":"http://gitea/values","name":"a"}`, string(out[0].Merge.Generators[1].List.Elements[0].Raw))
+	assert.Equal(t, []string{"path"}, out[0].Merge.MergeKeys)
+
+	patch, err := w.RewriteText("spec:\n  source:\n    repoURL: cnoe://apps\n    path: '{{ .path }}'\n")
+	assert.NoError(t, err)
+	assert.Equal(t, "spec:\n  source:\n    repoURL: http://gitea/apps\n    path: '{{ .path }}'\n", patch)
+}
+
+func TestApplicationSetCNOEURLs(t *testing.T) {
+	patch := `spec: {source: {repoURL: "cnoe://patched"}}`
+	appSet := &argov1alpha1.ApplicationSet{Spec: argov1alpha1.ApplicationSetSpec{
+		Generators: []argov1alpha1.ApplicationSetGenerator{
+			{Git: &argov1alpha1.GitGenerator{RepoURL: "cnoe://apps"}},
+			{List: &argov1alpha1.ListGenerator{Elements: []apiextensionsv1.JSON{{Raw: []byte(`{"repo":"https://github.com/org/repo"}`)}}}},
+		},
+		TemplatePatch: &patch,
+	}}
+
+	urls, err := ApplicationSetCNOEURLs(appSet)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"cnoe://apps", "cnoe://patched"}, urls)
+}
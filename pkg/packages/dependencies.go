@@ -0,0 +1,33 @@
+package packages
+
+import (
+	"sort"
+)
+
+// FindDependencyCycle returns a dependency cycle that contains start, e.g. [a b a], or nil if there is none.
+func FindDependencyCycle(graph map[string][]string, start string) []string {
+	visited := map[string]bool{}
+	var path []string
+
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		if name == start && len(path) > 0 {
+			return append(append([]string{}, path...), name)
+		}
+		if visited[name] {
+			return nil
+		}
+		visited[name] = true
+		path = append(path, name)
+		deps := append([]string{}, graph[name]...)
+		sort.Strings(deps)
+		for _, dep := range deps {
+			if cycle := visit(dep); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		return nil
+	}
+	return visit(start)
+}
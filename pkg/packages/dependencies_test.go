@@ -0,0 +1,23 @@
+package packages
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindDependencyCycle(t *testing.T) {
+	graph := map[string][]string{
+		"crossplane-providers": {"crossplane"},
+		"crossplane":           {"cert-manager"},
+		"cert-manager":         nil,
+	}
+	assert.Nil(t, FindDependencyCycle(graph, "crossplane-providers"))
+
+	graph["cert-manager"] = []string{"crossplane-providers"}
+	assert.Equal(t, []string{"crossplane", "cert-manager", "crossplane-providers", "crossplane"}, FindDependencyCycle(graph, "crossplane"))
+
+	// a cycle that does not contain the package does not block it
+	assert.Nil(t, FindDependencyCycle(map[string][]string{"app": {"a"}, "a": {"b"}, "b": {"a"}}, "app"))
+	assert.Equal(t, []string{"app", "app"}, FindDependencyCycle(map[string][]string{"app": {"app"}}, "app"))
+}
@@ -0,0 +1,191 @@
+package packages
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/cnoe-io/idpbuilder/pkg/util"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+)
+
+// ValidateKubernetesYamlFile checks that the file at absPath only contains kubernetes manifests.
+func ValidateKubernetesYamlFile(absPath string) error {
+	if !filepath.IsAbs(absPath) {
+		return fmt.Errorf("given path is not an absolute path %s", absPath)
+	}
+	b, err := os.ReadFile(absPath)
+	if err != nil {
+		return fmt.Errorf("failed reading file: %s, err: %w", absPath, err)
+	}
+	n, err := kio.FromBytes(b)
+	if err != nil {
+		return fmt.Errorf("failed parsing file as kubernetes manifests file: %s, err: %w", absPath, err)
+	}
+
+	for i := range n {
+		obj := n[i]
+		if obj.IsNilOrEmpty() {
+			return fmt.Errorf("given file %s contains an invalid kubernetes manifest", absPath)
+		}
+		if obj.GetKind() == "" || obj.GetApiVersion() == "" {
+			return fmt.Errorf("given file %s contains an invalid kubernetes manifest", absPath)
+		}
+	}
+
+	return nil
+}
+
+const (
+	// PackageValuesSuffix separates a package string from the path to its values file, e.g. ./pkg@values=./team-a.yaml
+	PackageValuesSuffix = "@values="
+	// PackagePrioritySuffix separates a package string from its priority, e.g. ./base@priority=10
+	PackagePrioritySuffix = "@priority="
+)
+
+// PackageLocations are package strings grouped by the type of their location.
+type PackageLocations struct {
+	Remote       []string
+	Files        []string
+	Dirs         []string
+	OCIArtifacts []string
+	HelmCharts   []string
+	Archives     []string
+	// ValuesFiles are the absolute paths of the values files of packages keyed by the package location.
+	ValuesFiles map[string]string
+	// Priorities are the explicit priorities of packages keyed by the package location.
+	Priorities map[string]int
+}
+
+// ParsePackageStrings groups package strings by the type of their location.
+func ParsePackageStrings(pkgStrings []string) (PackageLocations, error) {
+	out := PackageLocations{ValuesFiles: map[string]string{}, Priorities: map[string]int{}}
+	for i := range pkgStrings {
+		loc, options := splitPackageOptions(pkgStrings[i])
+		valuesFile := options[PackageValuesSuffix]
+
+		loc, err := out.add(loc)
+		if err != nil {
+			return PackageLocations{}, err
+		}
+
+		if p, ok := options[PackagePrioritySuffix]; ok {
+			priority, pErr := strconv.Atoi(p)
+			if pErr != nil {
+				return PackageLocations{}, fmt.Errorf("invalid priority %s of package %s: must be an integer", p, loc)
+			}
+			out.Priorities[loc] = priority
+		}
+
+		if valuesFile != "" {
+			absPath, aErr := AbsPath(valuesFile, false)
+			if aErr != nil {
+				return PackageLocations{}, fmt.Errorf("values file of package %s: %w", loc, aErr)
+			}
+			out.ValuesFiles[loc] = absPath
+		}
+	}
+
+	return out, nil
+}
+
+// splitPackageOptions removes the @values= and @priority= suffixes, in any order, from a package string.
+func splitPackageOptions(pkg string) (string, map[string]string) {
+	options := map[string]string{}
+	for {
+		idx, suffix := -1, ""
+		for _, s := range []string{PackageValuesSuffix, PackagePrioritySuffix} {
+			if _, found := options[s]; found {
+				continue
+			}
+			if i := strings.LastIndex(pkg, s); i > idx {
+				idx, suffix = i, s
+			}
+		}
+		if idx == -1 {
+			return pkg, options
+		}
+		pkg, options[suffix] = pkg[:idx], pkg[idx+len(suffix):]
+	}
+}
+
+// add adds the package string to the locations of its type. It returns the location as it was added.
+func (out *PackageLocations) add(loc string) (string, error) {
+	if util.IsOCIPackage(loc) {
+		_, err := util.ParseOCIReference(loc)
+		if err != nil {
+			return "", err
+		}
+		out.OCIArtifacts = append(out.OCIArtifacts, loc)
+		return loc, nil
+	}
+
+	if util.IsHelmPackage(loc) {
+		_, err := util.ParseHelmPackage(loc)
+		if err != nil {
+			return "", err
+		}
+		out.HelmCharts = append(out.HelmCharts, loc)
+		return loc, nil
+	}
+
+	if util.IsArchivePackage(loc) {
+		archive, err := util.ParseArchivePackage(loc)
+		if err != nil {
+			return "", err
+		}
+		if !util.IsRemoteArchive(archive) {
+			// local archives are referenced by their absolute path, keeping the checksum query
+			absPath, aErr := AbsPath(archive.Url, false)
+			if aErr != nil {
+				return "", aErr
+			}
+			loc = absPath + strings.TrimPrefix(loc, archive.Url)
+		}
+		out.Archives = append(out.Archives, loc)
+		return loc, nil
+	}
+
+	_, err := util.NewKustomizeRemote(loc)
+	if err == nil {
+		out.Remote = append(out.Remote, loc)
+		return loc, nil
+	}
+
+	absPath, err := AbsPath(loc, true)
+	if err == nil {
+		out.Dirs = append(out.Dirs, absPath)
+		return absPath, nil
+	}
+
+	absPath, err = AbsPath(loc, false)
+	if err == nil {
+		out.Files = append(out.Files, absPath)
+		return absPath, nil
+	}
+
+	return "", err
+}
+
+// AbsPath returns the absolute path of an existing directory or regular file.
+func AbsPath(path string, isDir bool) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to validate path %s : %w", path, err)
+	}
+	f, err := os.Stat(absPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to validate path %s : %w", absPath, err)
+	}
+
+	if isDir && !f.IsDir() {
+		return "", fmt.Errorf("given path is not a directory. %s", absPath)
+	}
+
+	if !isDir && !f.Mode().IsRegular() {
+		return "", fmt.Errorf("given path is not a file. %s", absPath)
+	}
+	return absPath, nil
+}
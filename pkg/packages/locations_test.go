@@ -0,0 +1,149 @@
+package packages
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateKubernetesYaml(t *testing.T) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("could not get current working directory")
+	}
+
+	cases := map[string]struct {
+		expectErr bool
+		inputPath string
+	}{
+		"invalidPath": {expectErr: true, inputPath: fmt.Sprintf("%s/invalid/path", cwd)},
+		"notAbs":      {expectErr: true, inputPath: fmt.Sprintf("invalid/path")},
+		"valid":       {expectErr: false, inputPath: fmt.Sprintf("%s/test-data/valid.yaml", cwd)},
+		"notYaml":     {expectErr: true, inputPath: fmt.Sprintf("%s/test-data/notyaml.yaml", cwd)},
+		"notk8s":      {expectErr: true, inputPath: fmt.Sprintf("%s/test-data/notk8s.yaml", cwd)},
+	}
+
+	for k := range cases {
+		cErr := ValidateKubernetesYamlFile(cases[k].inputPath)
+		if cases[k].expectErr && cErr == nil {
+			t.Fatalf("%s expected error but did not receive error", k)
+		}
+		if !cases[k].expectErr && cErr != nil {
+			t.Fatalf("%s did not expect error but received error", k)
+		}
+	}
+}
+
+func TestParsePackageStrings(t *testing.T) {
+	cases := map[string]struct {
+		expectErr  bool
+		inputPaths []string
+		remote     int
+		files      int
+		dirs       int
+		oci        int
+		helm       int
+		archives   int
+	}{
+		"allDirs":  {expectErr: false, inputPaths: []string{"test-data", "."}, remote: 0, files: 0, dirs: 2},
+		"allFiles": {expectErr: false, inputPaths: []string{"test-data/valid.yaml"}, remote: 0, files: 1, dirs: 0},
+		"allRemote": {expectErr: false, inputPaths: []string{
+			"https://github.com/kubernetes-sigs/kustomize//examples/multibases/dev/?timeout=120&ref=v3.3.1",
+			"git@github.com:owner/repo//examples",
+		}, remote: 2, files: 0, dirs: 0},
+		"mix": {expectErr: false, inputPaths: []string{
+			"https://github.com/kubernetes-sigs/kustomize//examples/multibases/dev/?timeout=120&ref=v3.3.1",
+			"test-data",
+			"test-data/valid.yaml",
+		}, remote: 1, files: 1, dirs: 1},
+		"oci": {expectErr: false, inputPaths: []string{
+			"oci://ghcr.io/cnoe-io/packages/backstage:v1.0.0",
+			"test-data",
+		}, remote: 0, files: 0, dirs: 1, oci: 1},
+		"helm": {expectErr: false, inputPaths: []string{
+			"helm://charts.bitnami.com/bitnami/nginx@15.0.0?render=true",
+			"helm://charts.bitnami.com/bitnami/nginx",
+		}, remote: 0, files: 0, dirs: 0, helm: 2},
+		"helmWithoutChart": {expectErr: true, inputPaths: []string{
+			"helm://charts.bitnami.com",
+		}, remote: 0, files: 0, dirs: 0},
+		"ociWithoutTag": {expectErr: true, inputPaths: []string{
+			"oci://ghcr.io/cnoe-io/packages/backstage",
+		}, remote: 0, files: 0, dirs: 0},
+		"archive": {expectErr: false, inputPaths: []string{
+			"https://artifacts.example.com/pkg.tgz?sha256=9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+			"https://artifacts.example.com/pkg.tar?sha256=9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+		}, remote: 0, files: 0, dirs: 0, archives: 2},
+		"archiveWithoutChecksum": {expectErr: true, inputPaths: []string{
+			"https://artifacts.example.com/pkg.tar",
+		}, remote: 0, files: 0, dirs: 0},
+		"archiveInvalidChecksum": {expectErr: true, inputPaths: []string{
+			"https://artifacts.example.com/pkg.tgz?sha256=abc",
+		}, remote: 0, files: 0, dirs: 0},
+		"archiveDoesNotExist": {expectErr: true, inputPaths: []string{
+			"does-not-exist.tar.gz",
+		}, remote: 0, files: 0, dirs: 0},
+		"invalidLocalPath": {expectErr: true, inputPaths: []string{
+			"does-not-exist",
+		}, remote: 0, files: 0, dirs: 0},
+		"invalidRemotePath": {expectErr: true, inputPaths: []string{
+			"https://   github.com/kubernetes-sigs/kustomize//examples",
+		}, remote: 0, files: 0, dirs: 0},
+	}
+
+	for k := range cases {
+		c := cases[k]
+		pkgs, err := ParsePackageStrings(c.inputPaths)
+		if cases[k].expectErr {
+			assert.NotNil(t, err)
+		} else {
+			assert.Nil(t, err)
+		}
+		assert.Equal(t, c.remote, len(pkgs.Remote))
+		assert.Equal(t, c.files, len(pkgs.Files))
+		assert.Equal(t, c.dirs, len(pkgs.Dirs))
+		assert.Equal(t, c.oci, len(pkgs.OCIArtifacts))
+		assert.Equal(t, c.helm, len(pkgs.HelmCharts))
+		assert.Equal(t, c.archives, len(pkgs.Archives))
+	}
+}
+
+func TestParsePackageStringsValues(t *testing.T) {
+	cwd, _ := os.Getwd()
+	pkgs, err := ParsePackageStrings([]string{
+		"test-data@values=test-data/valid.yaml",
+		"https://github.com/org/repo//packages/app?ref=v1.0.0@values=test-data/valid.yaml",
+		"git@github.com:org/repo//packages/other",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{fmt.Sprintf("%s/test-data", cwd)}, pkgs.Dirs)
+	assert.Equal(t, []string{"https://github.com/org/repo//packages/app?ref=v1.0.0", "git@github.com:org/repo//packages/other"}, pkgs.Remote)
+	assert.Equal(t, map[string]string{
+		fmt.Sprintf("%s/test-data", cwd):                       fmt.Sprintf("%s/test-data/valid.yaml", cwd),
+		"https://github.com/org/repo//packages/app?ref=v1.0.0": fmt.Sprintf("%s/test-data/valid.yaml", cwd),
+	}, pkgs.ValuesFiles)
+
+	_, err = ParsePackageStrings([]string{"test-data@values=does-not-exist.yaml"})
+	assert.NotNil(t, err)
+}
+
+func TestParsePackageStringsPriorities(t *testing.T) {
+	cwd, _ := os.Getwd()
+	pkgs, err := ParsePackageStrings([]string{
+		"test-data@priority=10@values=test-data/valid.yaml",
+		"https://github.com/org/repo//packages/app?ref=v1.0.0@priority=-1",
+		"helm://charts.example.com/nginx@1.2.3",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int{
+		fmt.Sprintf("%s/test-data", cwd):                       10,
+		"https://github.com/org/repo//packages/app?ref=v1.0.0": -1,
+	}, pkgs.Priorities)
+	assert.Equal(t, fmt.Sprintf("%s/test-data/valid.yaml", cwd), pkgs.ValuesFiles[fmt.Sprintf("%s/test-data", cwd)])
+	assert.Equal(t, []string{"helm://charts.example.com/nginx@1.2.3"}, pkgs.HelmCharts)
+
+	_, err = ParsePackageStrings([]string{"test-data@priority=high"})
+	assert.NotNil(t, err)
+}
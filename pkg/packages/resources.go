@@ -0,0 +1,23 @@
+package packages
+
+import (
+	"slices"
+
+	argocdapp "github.com/cnoe-io/argocd-api/api/argo/application"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const argoCDSecretTypeLabelKey = "argocd.argoproj.io/secret-type"
+
+// argoCDSecretTypes are the types of Argo CD secrets that are applied as package resources.
+var argoCDSecretTypes = []string{"repository", "repo-creds", "cluster"}
+
+// IsPackageResource returns true for the resources that are applied as is, before the applications of the package.
+// These are AppProjects and Argo CD repository and cluster secrets.
+func IsPackageResource(o *unstructured.Unstructured) bool {
+	gvk := o.GroupVersionKind()
+	if gvk.Group == argocdapp.Group && gvk.Kind == argocdapp.AppProjectKind {
+		return true
+	}
+	return gvk.Group == "" && gvk.Kind == "Secret" && slices.Contains(argoCDSecretTypes, o.GetLabels()[argoCDSecretTypeLabelKey])
+}
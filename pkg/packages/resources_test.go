@@ -0,0 +1,25 @@
+package packages
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+func TestIsPackageResource(t *testing.T) {
+	cases := map[string]bool{
+		"apiVersion: argoproj.io/v1alpha1\nkind: AppProject\nmetadata:\n  name: team\n":                                      true,
+		"apiVersion: v1\nkind: Secret\nmetadata:\n  name: repo\n  labels:\n    argocd.argoproj.io/secret-type: repository\n": true,
+		"apiVersion: v1\nkind: Secret\nmetadata:\n  name: cluster\n  labels:\n    argocd.argoproj.io/secret-type: cluster\n": true,
+		"apiVersion: v1\nkind: Secret\nmetadata:\n  name: other\n":                                                           false,
+		"apiVersion: argoproj.io/v1alpha1\nkind: Application\nmetadata:\n  name: app\n":                                      false,
+		"apiVersion: example.com/v1\nkind: AppProject\nmetadata:\n  name: team\n":                                            false,
+	}
+	for doc, expected := range cases {
+		o := &unstructured.Unstructured{}
+		assert.Nil(t, yaml.Unmarshal([]byte(doc), &o.Object))
+		assert.Equal(t, expected, IsPackageResource(o), doc)
+	}
+}
@@ -0,0 +1,95 @@
+package packages
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	argov1alpha1 "github.com/cnoe-io/argocd-api/api/argo/application/v1alpha1"
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
+	"github.com/cnoe-io/idpbuilder/pkg/util"
+)
+
+// IsCNOEScheme returns true if the url refers to a directory of the package with the cnoe:// scheme.
+func IsCNOEScheme(repoURL string) bool {
+	return strings.HasPrefix(repoURL, v1alpha1.CNOEURIScheme)
+}
+
+// GetCNOEAbsPath returns the absolute path of the directory that a cnoe:// url of the package file at fPath refers to.
+func GetCNOEAbsPath(fPath, repoURL string) (string, error) {
+	parentDir := filepath.Dir(fPath)
+	relativePath := strings.TrimPrefix(repoURL, v1alpha1.CNOEURIScheme)
+	absPath, err := filepath.Abs(filepath.Join(parentDir, relativePath))
+	if err != nil {
+		return "", err
+	}
+
+	f, err := os.Stat(absPath)
+	if err != nil {
+		return "", err
+	}
+	if !f.IsDir() {
+		return "", fmt.Errorf("path not a directory: %s", absPath)
+	}
+	return absPath, err
+}
+
+// SplitRefValueFile splits a helm value file of the form $ref/path into the name of the ref source and the path.
+func SplitRefValueFile(valueFile string) (string, string, bool) {
+	if !strings.HasPrefix(valueFile, "$") {
+		return "", "", false
+	}
+	ref, p, _ := strings.Cut(strings.TrimPrefix(valueFile, "$"), "/")
+	return ref, p, true
+}
+
+// ValidateValueFiles checks that the $ref value files of the sources refer to a source of the application.
+// For local packages, value files of cnoe:// ref sources must exist next to the package file.
+func ValidateValueFiles(packageFile string, local bool, sources argov1alpha1.ApplicationSources) error {
+	refs := map[string]*argov1alpha1.ApplicationSource{}
+	for i := range sources {
+		if sources[i].Ref != "" {
+			refs[sources[i].Ref] = &sources[i]
+		}
+	}
+
+	for i := range sources {
+		s := &sources[i]
+		if s.Helm == nil || s.Helm.IgnoreMissingValueFiles {
+			continue
+		}
+		for _, vf := range s.Helm.ValueFiles {
+			ref, p, ok := SplitRefValueFile(vf)
+			// value files with generator parameters are resolved by Argo CD
+			if !ok || strings.Contains(vf, "{{") {
+				continue
+			}
+			refSource, found := refs[ref]
+			if !found {
+				return fmt.Errorf("value file %s refers to unknown source $%s", vf, ref)
+			}
+			if !local || !IsCNOEScheme(refSource.RepoURL) {
+				continue
+			}
+			dir, err := GetCNOEAbsPath(packageFile, refSource.RepoURL)
+			if err != nil {
+				return fmt.Errorf("value file %s refers to source %s: %w", vf, refSource.RepoURL, err)
+			}
+			if !valueFileExists(filepath.Join(dir, p)) {
+				return fmt.Errorf("value file %s not found in %s", vf, dir)
+			}
+		}
+	}
+	return nil
+}
+
+// valueFileExists returns true if the file or its template exists.
+func valueFileExists(p string) bool {
+	for _, f := range []string{p, p + util.TemplateFileExt} {
+		if info, err := os.Stat(f); err == nil && info.Mode().IsRegular() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package printer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cnoe-io/idpbuilder/pkg/printer/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type PackageConflictPrinter struct {
+	Conflicts []types.PackageConflict
+	OutWriter io.Writer
+}
+
+func (pp PackageConflictPrinter) PrintOutput(format string) error {
+	switch format {
+	case "json":
+		return PrintDataAsJson(pp.Conflicts, pp.OutWriter)
+	case "yaml":
+		return PrintDataAsYaml(pp.Conflicts, pp.OutWriter)
+	case "table":
+		return PrintDataAsTable(generatePackageConflictTable(pp.Conflicts), pp.OutWriter)
+	default:
+		return fmt.Errorf("output format %s is not supported", format)
+	}
+}
+
+func generatePackageConflictTable(conflicts []types.PackageConflict) metav1.Table {
+	table := &metav1.Table{}
+	table.ColumnDefinitions = []metav1.TableColumnDefinition{
+		{Name: "App Name", Type: "string"},
+		{Name: "Winner", Type: "string"},
+		{Name: "Losers", Type: "string"},
+	}
+	for _, c := range conflicts {
+		winner := c.Winner
+		if winner == "" {
+			// packages with the same priority are all applied
+			winner = "<none: tied priorities>"
+		}
+		row := metav1.TableRow{
+			Cells: []interface{}{
+				c.AppName,
+				winner,
+				strings.Join(c.Losers, ", "),
+			},
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return *table
+}
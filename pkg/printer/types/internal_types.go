@@ -39,6 +39,12 @@ type Package struct {
 	Status           string
 }
 
+type PackageConflict struct {
+	AppName string   `json:"appName"`
+	Winner  string   `json:"winner,omitempty"`
+	Losers  []string `json:"losers"`
+}
+
 type Secret struct {
 	IsCore    bool
 	Name      string            `json:"name"`
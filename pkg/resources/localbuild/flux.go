@@ -0,0 +1,67 @@
+package localbuild
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	FluxSourceGroup    = "source.toolkit.fluxcd.io"
+	FluxKustomizeGroup = "kustomize.toolkit.fluxcd.io"
+	FluxHelmGroup      = "helm.toolkit.fluxcd.io"
+
+	FluxGitRepositoryKind = "GitRepository"
+	FluxKustomizationKind = "Kustomization"
+	FluxHelmReleaseKind   = "HelmRelease"
+
+	// FluxSourceBranch is the branch the in-cluster repositories are pushed to.
+	FluxSourceBranch = "main"
+	fluxInterval     = "1m"
+)
+
+var (
+	FluxGitRepositoryGVK = schema.GroupVersionKind{Group: FluxSourceGroup, Version: "v1", Kind: FluxGitRepositoryKind}
+	FluxKustomizationGVK = schema.GroupVersionKind{Group: FluxKustomizeGroup, Version: "v1", Kind: FluxKustomizationKind}
+)
+
+// NewFluxGitRepository returns a Flux GitRepository pulling the main branch of the repository at url.
+func NewFluxGitRepository(name, namespace, url string) *unstructured.Unstructured {
+	o := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"interval": fluxInterval,
+			"url":      url,
+			"ref": map[string]interface{}{
+				"branch": FluxSourceBranch,
+			},
+		},
+	}}
+	o.SetGroupVersionKind(FluxGitRepositoryGVK)
+	o.SetName(name)
+	o.SetNamespace(namespace)
+	return o
+}
+
+// NewFluxKustomization returns a Flux Kustomization applying the manifests at path of the GitRepository source.
+func NewFluxKustomization(name, namespace, source, path string) *unstructured.Unstructured {
+	o := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"interval": fluxInterval,
+			"path":     path,
+			"prune":    false,
+			"sourceRef": map[string]interface{}{
+				"kind": FluxGitRepositoryKind,
+				"name": source,
+			},
+		},
+	}}
+	o.SetGroupVersionKind(FluxKustomizationGVK)
+	o.SetName(name)
+	o.SetNamespace(namespace)
+	return o
+}
+
+// IsFluxApplication returns true for the Flux kinds that define a package: Kustomizations and HelmReleases.
+func IsFluxApplication(gvk schema.GroupVersionKind) bool {
+	return (gvk.Group == FluxKustomizeGroup && gvk.Kind == FluxKustomizationKind) ||
+		(gvk.Group == FluxHelmGroup && gvk.Kind == FluxHelmReleaseKind)
+}
@@ -0,0 +1,159 @@
+package scaffold
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"text/template"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	TypeManifests = "manifests"
+	TypeKustomize = "kustomize"
+	TypeHelm      = "helm"
+	TypeAppSet    = "appset"
+)
+
+// Types are the supported package types.
+var Types = []string{TypeManifests, TypeKustomize, TypeHelm, TypeAppSet}
+
+// The templates use [[ ]] as delimiters, so that the generated files can contain package templates and helm templates.
+//
+//go:embed templates
+var templates embed.FS
+
+// file is a file of a package generated from a template.
+type file struct {
+	// path is the path of the file in the package. It is a template.
+	path     string
+	template string
+	// ingress files are only generated when an ingress is requested.
+	ingress bool
+}
+
+// layouts are the files of each package type.
+var layouts = map[string][]file{
+	TypeManifests: {
+		{path: "[[ .Name ]].yaml", template: "application.yaml"},
+		{path: "manifests/deployment.yaml", template: "deployment.yaml"},
+		{path: "manifests/service.yaml", template: "service.yaml"},
+		{path: "manifests/ingress.yaml.tmpl", template: "ingress.yaml.tmpl", ingress: true},
+	},
+	TypeKustomize: {
+		{path: "[[ .Name ]].yaml", template: "application.yaml"},
+		{path: "manifests/kustomization.yaml", template: "kustomization.yaml"},
+		{path: "manifests/deployment.yaml", template: "deployment.yaml"},
+		{path: "manifests/service.yaml", template: "service.yaml"},
+		{path: "manifests/ingress.yaml.tmpl", template: "ingress.yaml.tmpl", ingress: true},
+	},
+	TypeHelm: {
+		{path: "[[ .Name ]].yaml", template: "application.yaml"},
+		{path: "chart/Chart.yaml", template: "chart/Chart.yaml"},
+		{path: "chart/values.yaml", template: "chart/values.yaml"},
+		{path: "chart/templates/deployment.yaml", template: "chart/templates/deployment.yaml"},
+		{path: "chart/templates/service.yaml", template: "chart/templates/service.yaml"},
+		{path: "chart/templates/ingress.yaml", template: "chart/templates/ingress.yaml"},
+	},
+	TypeAppSet: {
+		{path: "[[ .Name ]].yaml", template: "applicationset.yaml"},
+		{path: "apps/[[ .Name ]]/deployment.yaml", template: "deployment.yaml"},
+		{path: "apps/[[ .Name ]]/service.yaml", template: "service.yaml"},
+		{path: "apps/[[ .Name ]]/ingress.yaml.tmpl", template: "ingress.yaml.tmpl", ingress: true},
+	},
+}
+
+// sourceDirs are the directories the cnoe:// urls of each package type refer to.
+var sourceDirs = map[string]string{
+	TypeManifests: "manifests",
+	TypeKustomize: "manifests",
+	TypeHelm:      "chart",
+	TypeAppSet:    "apps",
+}
+
+// Options describe the package to generate.
+type Options struct {
+	Name string
+	Type string
+	// Namespace is the destination namespace of the applications. It defaults to the name of the package, or to the
+	// name of the generated application for ApplicationSets.
+	Namespace string
+	// Ingress adds an ingress under the host of the cluster.
+	Ingress bool
+}
+
+type templateData struct {
+	Options
+	SourceDir string
+}
+
+// Write generates the package in dir/<name> and returns the path to the package directory.
+// The package directory must not exist.
+func Write(dir string, opts Options) (string, error) {
+	if errs := validation.IsDNS1123Label(opts.Name); len(errs) > 0 {
+		return "", fmt.Errorf("invalid package name %s: %v", opts.Name, errs)
+	}
+	layout, ok := layouts[opts.Type]
+	if !ok {
+		return "", fmt.Errorf("invalid package type %s: must be one of %v", opts.Type, Types)
+	}
+	if opts.Namespace == "" {
+		opts.Namespace = opts.Name
+		if opts.Type == TypeAppSet {
+			opts.Namespace = "{{path.basename}}"
+		}
+	}
+
+	pkgDir := filepath.Join(dir, opts.Name)
+	if _, err := os.Stat(pkgDir); err == nil {
+		return "", fmt.Errorf("%s already exists", pkgDir)
+	}
+
+	data := templateData{Options: opts, SourceDir: sourceDirs[opts.Type]}
+	for _, f := range layout {
+		if f.ingress && !opts.Ingress {
+			continue
+		}
+		p, err := render(f.path, data)
+		if err != nil {
+			return "", err
+		}
+		name := f.template
+		// the values of a chart with an ingress are rendered with the host of the cluster
+		if opts.Type == TypeHelm && opts.Ingress && f.template == "chart/values.yaml" {
+			name, p = name+".tmpl", p+".tmpl"
+		}
+		b, err := templates.ReadFile(path.Join("templates", name))
+		if err != nil {
+			return "", fmt.Errorf("reading template %s: %w", name, err)
+		}
+		content, err := render(string(b), data)
+		if err != nil {
+			return "", fmt.Errorf("rendering template %s: %w", name, err)
+		}
+		target := filepath.Join(pkgDir, p)
+		if err = os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return "", fmt.Errorf("creating directory %s: %w", filepath.Dir(target), err)
+		}
+		if err = os.WriteFile(target, []byte(content), 0644); err != nil {
+			return "", fmt.Errorf("writing %s: %w", target, err)
+		}
+	}
+	return pkgDir, nil
+}
+
+func render(text string, data templateData) (string, error) {
+	t, err := template.New("scaffold").Delims("[[", "]]").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var out bytes.Buffer
+	if err = t.Execute(&out, data); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,59 @@
+package scaffold
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
+	"github.com/cnoe-io/idpbuilder/globals"
+	"github.com/cnoe-io/idpbuilder/pkg/lint"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWritePassesLint(t *testing.T) {
+	dir := t.TempDir()
+	var pkgs []string
+	for _, typ := range Types {
+		for _, ingress := range []bool{false, true} {
+			name := typ
+			if ingress {
+				name += "-ingress"
+			}
+			pkgDir, err := Write(dir, Options{Name: name, Type: typ, Ingress: ingress})
+			assert.NoError(t, err)
+			assert.FileExists(t, filepath.Join(pkgDir, name+".yaml"))
+			pkgs = append(pkgs, pkgDir)
+		}
+	}
+
+	for _, pathRouting := range []bool{false, true} {
+		problems := lint.Lint(lint.Options{
+			Packages: pkgs,
+			TemplateData: v1alpha1.BuildCustomizationSpec{
+				Protocol: "https", Host: globals.DefaultHostName, IngressHost: globals.DefaultHostName, Port: "8443", UsePathRouting: pathRouting,
+			},
+		})
+		assert.Empty(t, problems)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	dir := t.TempDir()
+
+	pkgDir, err := Write(dir, Options{Name: "my-app", Type: TypeHelm, Namespace: "team-a", Ingress: true})
+	assert.NoError(t, err)
+	app, err := os.ReadFile(filepath.Join(pkgDir, "my-app.yaml"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(app), "repoURL: cnoe://chart")
+	assert.Contains(t, string(app), "namespace: team-a")
+	assert.FileExists(t, filepath.Join(pkgDir, "chart", "values.yaml.tmpl"))
+	assert.NoFileExists(t, filepath.Join(pkgDir, "chart", "values.yaml"))
+
+	_, err = Write(dir, Options{Name: "my-app", Type: TypeHelm})
+	assert.ErrorContains(t, err, "already exists")
+	_, err = Write(dir, Options{Name: "My_App", Type: TypeHelm})
+	assert.ErrorContains(t, err, "invalid package name")
+	_, err = Write(dir, Options{Name: "other", Type: "jsonnet"})
+	assert.ErrorContains(t, err, "invalid package type")
+}
@@ -0,0 +1,197 @@
+package util
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
+)
+
+const (
+	// QueryStringSHA256 is the expected sha256 checksum of an archive package.
+	QueryStringSHA256 = "sha256"
+
+	archiveDigestExt = ".sha256"
+)
+
+var archiveExtensions = []string{".tar.gz", ".tgz", ".tar"}
+
+// maxExtractedSize is the maximum total size of the files extracted from an archive.
+var maxExtractedSize int64 = 2 << 30
+
+// IsArchivePackage returns true if the package string refers to a tar archive, e.g. https://example.com/pkg.tgz or ./pkg.tar.gz
+func IsArchivePackage(pkg string) bool {
+	p, _, _ := strings.Cut(pkg, "?")
+	p = strings.ToLower(p)
+	for _, ext := range archiveExtensions {
+		if strings.HasSuffix(p, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseArchivePackage returns the archive of a package string. The checksum is read from the sha256 query parameter.
+// It is required for archives downloaded over http or https and optional for local files.
+func ParseArchivePackage(pkg string) (v1alpha1.ArchiveSpec, error) {
+	loc, query, _ := strings.Cut(pkg, "?")
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return v1alpha1.ArchiveSpec{}, fmt.Errorf("parsing query parameters in package %s: %w", pkg, err)
+	}
+	sum := strings.ToLower(values.Get(QueryStringSHA256))
+	if sum != "" {
+		if b, dErr := hex.DecodeString(sum); dErr != nil || len(b) != sha256.Size {
+			return v1alpha1.ArchiveSpec{}, fmt.Errorf("invalid value for %s in package %s: must be a hex encoded sha256 checksum", QueryStringSHA256, pkg)
+		}
+	}
+	archive := v1alpha1.ArchiveSpec{Url: loc, SHA256: sum}
+	if err = checkArchiveChecksum(archive); err != nil {
+		return v1alpha1.ArchiveSpec{}, fmt.Errorf("package %s: %w", pkg, err)
+	}
+	return archive, nil
+}
+
+// checkArchiveChecksum returns an error if a remote archive has no checksum to verify it against.
+func checkArchiveChecksum(archive v1alpha1.ArchiveSpec) error {
+	if IsRemoteArchive(archive) && archive.SHA256 == "" {
+		return fmt.Errorf("the %s query parameter is required for archives downloaded over http or https", QueryStringSHA256)
+	}
+	return nil
+}
+
+// IsRemoteArchive returns true if the archive is downloaded over http or https.
+func IsRemoteArchive(archive v1alpha1.ArchiveSpec) bool {
+	return strings.HasPrefix(archive.Url, "http://") || strings.HasPrefix(archive.Url, "https://")
+}
+
+// ArchiveName returns the file name of the archive without its extension.
+func ArchiveName(archiveUrl string) string {
+	name := path.Base(filepath.ToSlash(archiveUrl))
+	lower := strings.ToLower(name)
+	for _, ext := range archiveExtensions {
+		if strings.HasSuffix(lower, ext) {
+			return name[:len(name)-len(ext)]
+		}
+	}
+	return name
+}
+
+// FetchArchive downloads or reads the archive, verifies its checksum and extracts it to dir. Remote archives must have
+// a checksum.
+// The archive is only extracted again when its content changed. It returns the sha256 checksum of the archive.
+func FetchArchive(ctx context.Context, c *http.Client, archive v1alpha1.ArchiveSpec, dir string) (string, error) {
+	var (
+		b   []byte
+		err error
+	)
+	if err = checkArchiveChecksum(archive); err != nil {
+		return "", fmt.Errorf("fetching archive %s: %w", archive.Url, err)
+	}
+	if IsRemoteArchive(archive) {
+		b, err = httpGet(ctx, c, archive.Url)
+	} else {
+		b, err = os.ReadFile(strings.TrimPrefix(archive.Url, "file://"))
+	}
+	if err != nil {
+		return "", fmt.Errorf("reading archive %s: %w", archive.Url, err)
+	}
+
+	s := sha256.Sum256(b)
+	sum := hex.EncodeToString(s[:])
+	if archive.SHA256 != "" && archive.SHA256 != sum {
+		return "", fmt.Errorf("checksum of archive %s is %s, expected %s", archive.Url, sum, archive.SHA256)
+	}
+
+	digestFile := dir + archiveDigestExt
+	if prev, rErr := os.ReadFile(digestFile); rErr == nil && string(prev) == sum {
+		if _, sErr := os.Stat(dir); sErr == nil {
+			return sum, nil
+		}
+	}
+
+	tmpDir, err := os.MkdirTemp(filepath.Dir(dir), filepath.Base(dir)+"-extract-")
+	if err != nil {
+		return "", fmt.Errorf("creating temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err = extractTar(b, tmpDir); err != nil {
+		return "", fmt.Errorf("extracting archive %s: %w", archive.Url, err)
+	}
+	if err = replaceDir(tmpDir, dir); err != nil {
+		return "", err
+	}
+	if err = os.WriteFile(digestFile, []byte(sum), 0600); err != nil {
+		return "", fmt.Errorf("writing %s: %w", digestFile, err)
+	}
+	return sum, nil
+}
+
+// extractTar extracts directories and regular files of the tar archive to dir. The archive may be gzip compressed.
+// Other entries, e.g. symlinks, are skipped. It fails when the extracted files exceed maxExtractedSize.
+func extractTar(b []byte, dir string) error {
+	var r io.Reader = bytes.NewReader(b)
+	if len(b) > 2 && b[0] == 0x1f && b[1] == 0x8b {
+		gr, err := gzip.NewReader(r)
+		if err != nil {
+			return err
+		}
+		defer gr.Close()
+		r = gr
+	}
+
+	tr := tar.NewReader(r)
+	remaining := maxExtractedSize
+	for {
+		h, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		name := filepath.Clean(filepath.FromSlash(h.Name))
+		if !filepath.IsLocal(name) {
+			return fmt.Errorf("archive contains a path outside of the extraction directory: %s", h.Name)
+		}
+		target := filepath.Join(dir, name)
+
+		switch h.Typeflag {
+		case tar.TypeDir:
+			if err = os.MkdirAll(target, 0750); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err = os.MkdirAll(filepath.Dir(target), 0750); err != nil {
+				return err
+			}
+			f, oErr := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.FileMode(h.Mode)&0750|0600)
+			if oErr != nil {
+				return oErr
+			}
+			n, cErr := io.CopyN(f, tr, remaining+1)
+			f.Close()
+			if cErr != nil && !errors.Is(cErr, io.EOF) {
+				return cErr
+			}
+			if remaining -= n; remaining < 0 {
+				return fmt.Errorf("archive exceeds %d bytes when extracted", maxExtractedSize)
+			}
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package util
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+func testTarGz(t *testing.T, files map[string]string) []byte {
+	buf := &bytes.Buffer{}
+	gw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gw)
+	for name, content := range files {
+		assert.Nil(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, tw.Close())
+	assert.Nil(t, gw.Close())
+	return buf.Bytes()
+}
+
+func checksum(b []byte) string {
+	s := sha256.Sum256(b)
+	return hex.EncodeToString(s[:])
+}
+
+func TestParseArchivePackage(t *testing.T) {
+	sum := checksum([]byte("pkg"))
+	archive, err := ParseArchivePackage("https://artifacts.example.com/pkg.tgz?sha256=" + sum)
+	assert.Nil(t, err)
+	assert.Equal(t, v1alpha1.ArchiveSpec{Url: "https://artifacts.example.com/pkg.tgz", SHA256: sum}, archive)
+	assert.True(t, IsRemoteArchive(archive))
+
+	archive, err = ParseArchivePackage("/tmp/pkg.tar.gz")
+	assert.Nil(t, err)
+	assert.False(t, IsRemoteArchive(archive))
+	assert.Equal(t, "pkg", ArchiveName(archive.Url))
+
+	_, err = ParseArchivePackage("https://artifacts.example.com/pkg.tgz?sha256=xyz")
+	assert.NotNil(t, err)
+
+	_, err = ParseArchivePackage("https://artifacts.example.com/pkg.tgz")
+	assert.ErrorContains(t, err, "sha256 query parameter is required")
+
+	assert.True(t, IsArchivePackage("./pkg.TGZ?sha256=abc"))
+	assert.False(t, IsArchivePackage("https://github.com/org/repo//pkg"))
+}
+
+func TestFetchArchive(t *testing.T) {
+	content := testTarGz(t, map[string]string{
+		"app.yaml":          "kind: Application\n",
+		"manifests/cm.yaml": "kind: ConfigMap\n",
+	})
+	s := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(content)
+	}))
+	defer s.Close()
+
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "pkg")
+	archive := v1alpha1.ArchiveSpec{Url: s.URL + "/pkg.tgz", SHA256: checksum(content)}
+
+	sum, err := FetchArchive(ctx, s.Client(), archive, dir)
+	assert.Nil(t, err)
+	assert.Equal(t, archive.SHA256, sum)
+	b, err := os.ReadFile(filepath.Join(dir, "manifests", "cm.yaml"))
+	assert.Nil(t, err)
+	assert.Equal(t, "kind: ConfigMap\n", string(b))
+
+	// unchanged archives are not extracted again
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "local.yaml"), []byte("kind: ConfigMap\n"), 0600))
+	_, err = FetchArchive(ctx, s.Client(), archive, dir)
+	assert.Nil(t, err)
+	_, err = os.Stat(filepath.Join(dir, "local.yaml"))
+	assert.Nil(t, err)
+
+	archive.SHA256 = checksum([]byte("other"))
+	_, err = FetchArchive(ctx, s.Client(), archive, dir)
+	assert.NotNil(t, err)
+
+	archive.SHA256 = ""
+	_, err = FetchArchive(ctx, s.Client(), archive, dir)
+	assert.ErrorContains(t, err, "sha256 query parameter is required")
+
+	local := filepath.Join(t.TempDir(), "pkg.tar.gz")
+	assert.Nil(t, os.WriteFile(local, testTarGz(t, map[string]string{"other.yaml": "kind: ConfigMap\n"}), 0600))
+	_, err = FetchArchive(ctx, nil, v1alpha1.ArchiveSpec{Url: local}, dir)
+	assert.Nil(t, err)
+	_, err = os.Stat(filepath.Join(dir, "app.yaml"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(dir, "other.yaml"))
+	assert.Nil(t, err)
+
+	assert.Nil(t, os.WriteFile(local, testTarGz(t, map[string]string{"../escape.yaml": "kind: ConfigMap\n"}), 0600))
+	_, err = FetchArchive(ctx, nil, v1alpha1.ArchiveSpec{Url: local}, dir)
+	assert.NotNil(t, err)
+}
+
+func TestFetchArchiveSizeLimits(t *testing.T) {
+	content := testTarGz(t, map[string]string{"app.yaml": strings.Repeat("a", 1024)})
+	s := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(content)
+	}))
+	defer s.Close()
+
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "pkg")
+	archive := v1alpha1.ArchiveSpec{Url: s.URL + "/pkg.tgz", SHA256: checksum(content)}
+
+	defer func(download, extracted int64) {
+		maxDownloadSize, maxExtractedSize = download, extracted
+	}(maxDownloadSize, maxExtractedSize)
+
+	maxDownloadSize = int64(len(content)) - 1
+	_, err := FetchArchive(ctx, s.Client(), archive, dir)
+	assert.ErrorContains(t, err, "response exceeds")
+
+	// the compressed archive is within the download limit, its content is not within the extraction limit
+	maxDownloadSize = int64(len(content))
+	maxExtractedSize = 1023
+	_, err = FetchArchive(ctx, s.Client(), archive, dir)
+	assert.ErrorContains(t, err, "exceeds 1023 bytes when extracted")
+
+	maxExtractedSize = 1024
+	_, err = FetchArchive(ctx, s.Client(), archive, dir)
+	assert.Nil(t, err)
+}
@@ -0,0 +1,159 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cnoe-io/idpbuilder/globals"
+)
+
+const (
+	cacheReposDir = "repos"
+	lockFileExt   = ".lock"
+)
+
+// CacheDir returns the directory used to cache data across CLI runs.
+// It is $XDG_CACHE_HOME/idpbuilder, or the platform's user cache directory when XDG_CACHE_HOME is not set.
+func CacheDir() (string, error) {
+	if d := os.Getenv("XDG_CACHE_HOME"); d != "" {
+		return filepath.Join(d, globals.ProjectName), nil
+	}
+	d, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("determining user cache directory: %w", err)
+	}
+	return filepath.Join(d, globals.ProjectName), nil
+}
+
+// RepoCacheDir returns the directory where repositories are cloned to, creating it if necessary.
+// Repositories are stored in sub directories named by RepoUrlHash.
+func RepoCacheDir() (string, error) {
+	d, err := CacheDir()
+	if err != nil {
+		return "", err
+	}
+	d = filepath.Join(d, cacheReposDir)
+	if err = os.MkdirAll(d, 0700); err != nil {
+		return "", fmt.Errorf("creating cache directory %s: %w", d, err)
+	}
+	return d, nil
+}
+
+// FileLock is an advisory lock on a file shared between processes.
+type FileLock struct {
+	f *os.File
+}
+
+// LockFile acquires an exclusive lock on path, creating the file if it does not exist.
+// It blocks until the lock is available. The modification time of the file is updated on every lock.
+func LockFile(path string) (*FileLock, error) {
+	return lockFile(path, false)
+}
+
+// TryLockFile is like LockFile but returns ErrLocked instead of blocking.
+func TryLockFile(path string) (*FileLock, error) {
+	return lockFile(path, true)
+}
+
+var ErrLocked = errors.New("file is locked by another process")
+
+func lockFile(path string, nonBlocking bool) (*FileLock, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("opening lock file %s: %w", path, err)
+	}
+	if err = lock(f, nonBlocking); err != nil {
+		f.Close()
+		if errors.Is(err, ErrLocked) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("locking %s: %w", path, err)
+	}
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+	return &FileLock{f: f}, nil
+}
+
+func (l *FileLock) Unlock() error {
+	if l == nil || l.f == nil {
+		return nil
+	}
+	defer l.f.Close()
+	return unlock(l.f)
+}
+
+// PruneRepoCache removes cached repositories in dir that were not used within maxAge.
+// Repositories that are currently in use by another process are skipped. It returns the removed directories.
+func PruneRepoCache(dir string, maxAge time.Duration) ([]string, error) {
+	return removeCachedRepos(dir, func(lastUsed time.Time) bool {
+		return time.Since(lastUsed) > maxAge
+	})
+}
+
+// ClearRepoCache removes all cached repositories in dir that are not in use by another process.
+func ClearRepoCache(dir string) ([]string, error) {
+	return removeCachedRepos(dir, func(time.Time) bool { return true })
+}
+
+func removeCachedRepos(dir string, shouldRemove func(lastUsed time.Time) bool) ([]string, error) {
+	ents, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading cache directory %s: %w", dir, err)
+	}
+
+	removed := make([]string, 0, len(ents))
+	for i := range ents {
+		ent := ents[i]
+		if !ent.IsDir() {
+			continue
+		}
+		repoDir := filepath.Join(dir, ent.Name())
+		lockPath := repoDir + lockFileExt
+
+		lastUsed, err := lastUsedTime(repoDir, lockPath)
+		if err != nil {
+			return removed, err
+		}
+		if !shouldRemove(lastUsed) {
+			continue
+		}
+
+		l, err := TryLockFile(lockPath)
+		if err != nil {
+			if errors.Is(err, ErrLocked) {
+				continue
+			}
+			return removed, err
+		}
+		// lock files are kept. removing them could let two processes hold a lock on different files for the same repo.
+		rErr := os.RemoveAll(repoDir)
+		l.Unlock()
+		if rErr != nil {
+			return removed, fmt.Errorf("removing %s: %w", repoDir, rErr)
+		}
+		removed = append(removed, repoDir)
+	}
+	return removed, nil
+}
+
+// the lock file is touched every time the repository is used.
+func lastUsedTime(repoDir, lockPath string) (time.Time, error) {
+	info, err := os.Stat(lockPath)
+	if err == nil {
+		return info.ModTime(), nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return time.Time{}, fmt.Errorf("reading lock file %s: %w", lockPath, err)
+	}
+	info, err = os.Stat(repoDir)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("reading %s: %w", repoDir, err)
+	}
+	return info.ModTime(), nil
+}
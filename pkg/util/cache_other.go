@@ -0,0 +1,14 @@
+//go:build !unix && !windows
+
+package util
+
+import "os"
+
+// files are not locked on platforms without advisory locks. concurrent runs may prune a repository in use.
+func lock(f *os.File, nonBlocking bool) error {
+	return nil
+}
+
+func unlock(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,49 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheDir(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", "/tmp/xdg-cache")
+	d, err := CacheDir()
+	assert.Nil(t, err)
+	assert.Equal(t, "/tmp/xdg-cache/idpbuilder", d)
+}
+
+func TestPruneRepoCache(t *testing.T) {
+	dir := t.TempDir()
+	old := filepath.Join(dir, "old")
+	recent := filepath.Join(dir, "recent")
+	inUse := filepath.Join(dir, "in-use")
+	for _, d := range []string{old, recent, inUse} {
+		assert.Nil(t, os.Mkdir(d, 0700))
+		l, err := LockFile(d + lockFileExt)
+		assert.Nil(t, err)
+		assert.Nil(t, l.Unlock())
+	}
+	lastWeek := time.Now().Add(-7 * 24 * time.Hour)
+	assert.Nil(t, os.Chtimes(old+lockFileExt, lastWeek, lastWeek))
+	assert.Nil(t, os.Chtimes(inUse+lockFileExt, lastWeek, lastWeek))
+
+	l, err := LockFile(inUse + lockFileExt)
+	assert.Nil(t, err)
+	assert.Nil(t, os.Chtimes(inUse+lockFileExt, lastWeek, lastWeek))
+
+	removed, err := PruneRepoCache(dir, 24*time.Hour)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{old}, removed)
+
+	_, err = TryLockFile(inUse + lockFileExt)
+	assert.ErrorIs(t, err, ErrLocked)
+	assert.Nil(t, l.Unlock())
+
+	removed, err = ClearRepoCache(dir)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{recent, inUse}, removed)
+}
@@ -0,0 +1,406 @@
+package util
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+)
+
+// https://github.com/git-lfs/git-lfs/blob/main/docs/spec.md
+// https://github.com/git-lfs/git-lfs/blob/main/docs/api/batch.md
+const (
+	lfsPointerVersion    = "https://git-lfs.github.com/spec/v1"
+	lfsPointerMaxSize    = 1024
+	lfsMediaType         = "application/vnd.git-lfs+json"
+	lfsOperationDownload = "download"
+	lfsOperationUpload   = "upload"
+	lfsTransferBasic     = "basic"
+)
+
+// LFSPointer identifies an object stored in Git LFS.
+type LFSPointer struct {
+	Oid  string `json:"oid"`
+	Size int64  `json:"size"`
+}
+
+// ParseLFSPointer returns the pointer in b. The second return value is false if b is not a LFS pointer file.
+func ParseLFSPointer(b []byte) (LFSPointer, bool) {
+	if len(b) > lfsPointerMaxSize || !bytes.HasPrefix(b, []byte("version ")) {
+		return LFSPointer{}, false
+	}
+
+	values := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		k, v, found := strings.Cut(scanner.Text(), " ")
+		if !found {
+			return LFSPointer{}, false
+		}
+		values[k] = v
+	}
+
+	if values["version"] != lfsPointerVersion {
+		return LFSPointer{}, false
+	}
+	oid, found := strings.CutPrefix(values["oid"], "sha256:")
+	if !found || len(oid) != sha256.Size*2 {
+		return LFSPointer{}, false
+	}
+	if _, err := hex.DecodeString(oid); err != nil {
+		return LFSPointer{}, false
+	}
+	size, err := strconv.ParseInt(values["size"], 10, 64)
+	if err != nil || size < 0 {
+		return LFSPointer{}, false
+	}
+	return LFSPointer{Oid: oid, Size: size}, true
+}
+
+// FindLFSPointers returns LFS pointer files under dir in fs keyed by their path. The .git directory is skipped.
+func FindLFSPointers(fs billy.Filesystem, dir string) (map[string]LFSPointer, error) {
+	out := map[string]LFSPointer{}
+	ents, err := fs.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for i := range ents {
+		ent := ents[i]
+		p := path.Join(dir, ent.Name())
+		if ent.IsDir() {
+			if ent.Name() == git.GitDirName {
+				continue
+			}
+			sub, err := FindLFSPointers(fs, p)
+			if err != nil {
+				return nil, err
+			}
+			for k, v := range sub {
+				out[k] = v
+			}
+			continue
+		}
+		if !ent.Mode().IsRegular() || ent.Size() > lfsPointerMaxSize {
+			continue
+		}
+		b, err := ReadWorktreeFile(fs, p)
+		if err != nil {
+			return nil, err
+		}
+		if ptr, ok := ParseLFSPointer(b); ok {
+			out[p] = ptr
+		}
+	}
+	return out, nil
+}
+
+// LFSObjectPath returns the path of the object in a local object store, using the same layout as git-lfs.
+func LFSObjectPath(objectsDir string, oid string) string {
+	return filepath.Join(objectsDir, oid[0:2], oid[2:4], oid)
+}
+
+// LFSClient transfers objects with the Git LFS batch API using the basic transfer adapter.
+type LFSClient struct {
+	Endpoint   string
+	Auth       *githttp.BasicAuth
+	HTTPClient *http.Client
+}
+
+// NewLFSClient returns a client for the LFS server of the given repository.
+// Only basic auth credentials are used. The LFS server of a repository cloned over ssh is assumed to be at the https
+// url of the repository.
+func NewLFSClient(repoUrl string, auth transport.AuthMethod, insecureSkipTLS bool) (*LFSClient, error) {
+	endpoint, err := lfsEndpoint(repoUrl)
+	if err != nil {
+		return nil, err
+	}
+	c := &LFSClient{
+		Endpoint: endpoint,
+		HTTPClient: &http.Client{
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: insecureSkipTLS},
+			},
+		},
+	}
+	if a, ok := auth.(*githttp.BasicAuth); ok {
+		c.Auth = a
+	}
+	return c, nil
+}
+
+func lfsEndpoint(repoUrl string) (string, error) {
+	ep, err := transport.NewEndpoint(repoUrl)
+	if err != nil {
+		return "", fmt.Errorf("parsing repository url %s: %w", repoUrl, err)
+	}
+
+	host := ep.Host
+	switch ep.Protocol {
+	case "http", "https":
+		if ep.Port != 0 {
+			host = fmt.Sprintf("%s:%d", ep.Host, ep.Port)
+		}
+	case "ssh":
+		ep.Protocol = "https"
+	default:
+		return "", fmt.Errorf("git lfs is not supported for %s repositories", ep.Protocol)
+	}
+
+	p := strings.TrimSuffix(ep.Path, "/")
+	if !strings.HasSuffix(p, ".git") {
+		p = p + ".git"
+	}
+	return fmt.Sprintf("%s://%s/%s/info/lfs", ep.Protocol, host, strings.TrimPrefix(p, "/")), nil
+}
+
+type lfsBatchRequest struct {
+	Operation string       `json:"operation"`
+	Transfers []string     `json:"transfers"`
+	Objects   []LFSPointer `json:"objects"`
+}
+
+type lfsBatchResponse struct {
+	Transfer string           `json:"transfer"`
+	Objects  []lfsBatchObject `json:"objects"`
+}
+
+type lfsBatchObject struct {
+	LFSPointer
+	Actions map[string]lfsAction `json:"actions"`
+	Error   *lfsObjectError      `json:"error"`
+}
+
+type lfsAction struct {
+	Href   string            `json:"href"`
+	Header map[string]string `json:"header"`
+}
+
+type lfsObjectError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Download stores the objects in objectsDir. Objects already present are not downloaded again.
+func (c *LFSClient) Download(ctx context.Context, objects []LFSPointer, objectsDir string) error {
+	missing := make([]LFSPointer, 0, len(objects))
+	for _, o := range objects {
+		if _, err := os.Stat(LFSObjectPath(objectsDir, o.Oid)); err != nil {
+			missing = append(missing, o)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	resp, err := c.batch(ctx, lfsOperationDownload, missing)
+	if err != nil {
+		return err
+	}
+	for _, o := range resp.Objects {
+		if o.Error != nil {
+			return fmt.Errorf("downloading lfs object %s: %d %s", o.Oid, o.Error.Code, o.Error.Message)
+		}
+		a, ok := o.Actions[lfsOperationDownload]
+		if !ok {
+			return fmt.Errorf("downloading lfs object %s: no download action returned", o.Oid)
+		}
+		if err = c.downloadObject(ctx, o.LFSPointer, a, objectsDir); err != nil {
+			return fmt.Errorf("downloading lfs object %s: %w", o.Oid, err)
+		}
+	}
+	return nil
+}
+
+// Upload sends the objects from objectsDir to the server. Objects the server already has are skipped.
+func (c *LFSClient) Upload(ctx context.Context, objects []LFSPointer, objectsDir string) error {
+	if len(objects) == 0 {
+		return nil
+	}
+	resp, err := c.batch(ctx, lfsOperationUpload, objects)
+	if err != nil {
+		return err
+	}
+	for _, o := range resp.Objects {
+		if o.Error != nil {
+			return fmt.Errorf("uploading lfs object %s: %d %s", o.Oid, o.Error.Code, o.Error.Message)
+		}
+		a, ok := o.Actions[lfsOperationUpload]
+		if !ok {
+			continue
+		}
+		if err = c.uploadObject(ctx, o.LFSPointer, a, objectsDir); err != nil {
+			return fmt.Errorf("uploading lfs object %s: %w", o.Oid, err)
+		}
+		if v, ok := o.Actions["verify"]; ok {
+			if err = c.verifyObject(ctx, o.LFSPointer, v); err != nil {
+				return fmt.Errorf("verifying lfs object %s: %w", o.Oid, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *LFSClient) batch(ctx context.Context, operation string, objects []LFSPointer) (lfsBatchResponse, error) {
+	b, err := json.Marshal(lfsBatchRequest{
+		Operation: operation,
+		Transfers: []string{lfsTransferBasic},
+		Objects:   objects,
+	})
+	if err != nil {
+		return lfsBatchResponse{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Endpoint+"/objects/batch", bytes.NewReader(b))
+	if err != nil {
+		return lfsBatchResponse{}, err
+	}
+	req.Header.Set("Accept", lfsMediaType)
+	req.Header.Set("Content-Type", lfsMediaType)
+	if c.Auth != nil {
+		req.SetBasicAuth(c.Auth.Username, c.Auth.Password)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return lfsBatchResponse{}, fmt.Errorf("lfs batch request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return lfsBatchResponse{}, fmt.Errorf("lfs batch request to %s returned %s", c.Endpoint, resp.Status)
+	}
+
+	var out lfsBatchResponse
+	if err = json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return lfsBatchResponse{}, fmt.Errorf("decoding lfs batch response: %w", err)
+	}
+	if out.Transfer != "" && out.Transfer != lfsTransferBasic {
+		return lfsBatchResponse{}, fmt.Errorf("unsupported lfs transfer adapter %s", out.Transfer)
+	}
+	return out, nil
+}
+
+func (c *LFSClient) downloadObject(ctx context.Context, obj LFSPointer, a lfsAction, objectsDir string) error {
+	resp, err := c.do(ctx, http.MethodGet, a, nil, 0)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	dst := LFSObjectPath(objectsDir, obj.Oid)
+	if err = os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dst), obj.Oid+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(tmp, h), resp.Body)
+	if err != nil {
+		return err
+	}
+	if n != obj.Size || hex.EncodeToString(h.Sum(nil)) != obj.Oid {
+		return fmt.Errorf("content does not match size %d and oid", obj.Size)
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
+
+func (c *LFSClient) uploadObject(ctx context.Context, obj LFSPointer, a lfsAction, objectsDir string) error {
+	f, err := os.Open(LFSObjectPath(objectsDir, obj.Oid))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	resp, err := c.do(ctx, http.MethodPut, a, f, obj.Size)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (c *LFSClient) verifyObject(ctx context.Context, obj LFSPointer, a lfsAction) error {
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	if a.Header == nil {
+		a.Header = map[string]string{}
+	}
+	a.Header["Content-Type"] = lfsMediaType
+	resp, err := c.do(ctx, http.MethodPost, a, bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// do sends a request for an action returned by the batch API. Credentials are only added when the action does not
+// carry its own authorization, e.g. when the object is served by the LFS server itself.
+func (c *LFSClient) do(ctx context.Context, method string, a lfsAction, body io.Reader, size int64) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, a.Href, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.ContentLength = size
+	}
+	for k, v := range a.Header {
+		req.Header.Set(k, v)
+	}
+	if c.Auth != nil && req.Header.Get("Authorization") == "" && strings.HasPrefix(a.Href, c.Endpoint) {
+		req.SetBasicAuth(c.Auth.Username, c.Auth.Password)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s %s returned %s", method, a.Href, resp.Status)
+	}
+	return resp, nil
+}
+
+// CopyLFSObject writes the object from objectsDir to name in fs.
+func CopyLFSObject(objectsDir string, obj LFSPointer, fs billy.Filesystem, name string) error {
+	src, err := os.Open(LFSObjectPath(objectsDir, obj.Oid))
+	if err != nil {
+		return fmt.Errorf("opening lfs object %s: %w", obj.Oid, err)
+	}
+	defer src.Close()
+
+	dst, err := fs.Create(name)
+	if err != nil {
+		return fmt.Errorf("creating file %s: %w", name, err)
+	}
+	defer dst.Close()
+	_, err = io.Copy(dst, src)
+	return err
+}
@@ -0,0 +1,124 @@
+package util
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/stretchr/testify/assert"
+)
+
+func lfsPointerFile(content string) (string, LFSPointer) {
+	sum := sha256.Sum256([]byte(content))
+	p := LFSPointer{Oid: hex.EncodeToString(sum[:]), Size: int64(len(content))}
+	return fmt.Sprintf("version %s\noid sha256:%s\nsize %d\n", lfsPointerVersion, p.Oid, p.Size), p
+}
+
+// fakeLFSServer serves the batch API for a single repository at /org/repo.git/info/lfs
+type fakeLFSServer struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeLFSServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	base := "http://" + r.Host + "/org/repo.git/info/lfs"
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/org/repo.git/info/lfs/objects/batch":
+		var req lfsBatchRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		resp := lfsBatchResponse{Transfer: lfsTransferBasic}
+		for _, o := range req.Objects {
+			obj := lfsBatchObject{LFSPointer: o, Actions: map[string]lfsAction{}}
+			_, exists := f.objects[o.Oid]
+			switch {
+			case req.Operation == lfsOperationDownload && exists:
+				obj.Actions[lfsOperationDownload] = lfsAction{Href: base + "/objects/" + o.Oid}
+			case req.Operation == lfsOperationDownload:
+				obj.Error = &lfsObjectError{Code: 404, Message: "not found"}
+			case req.Operation == lfsOperationUpload && !exists:
+				obj.Actions[lfsOperationUpload] = lfsAction{Href: base + "/objects/" + o.Oid}
+			}
+			resp.Objects = append(resp.Objects, obj)
+		}
+		w.Header().Set("Content-Type", lfsMediaType)
+		_ = json.NewEncoder(w).Encode(resp)
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/org/repo.git/info/lfs/objects/"):
+		_, _ = w.Write(f.objects[strings.TrimPrefix(r.URL.Path, "/org/repo.git/info/lfs/objects/")])
+	case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/org/repo.git/info/lfs/objects/"):
+		b, _ := io.ReadAll(r.Body)
+		f.objects[strings.TrimPrefix(r.URL.Path, "/org/repo.git/info/lfs/objects/")] = b
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestParseLFSPointer(t *testing.T) {
+	content, expected := lfsPointerFile("binary content")
+	p, ok := ParseLFSPointer([]byte(content))
+	assert.True(t, ok)
+	assert.Equal(t, expected, p)
+
+	_, ok = ParseLFSPointer([]byte("apiVersion: v1\nkind: ConfigMap\n"))
+	assert.False(t, ok)
+	_, ok = ParseLFSPointer([]byte(strings.Replace(content, "sha256:", "sha1:", 1)))
+	assert.False(t, ok)
+}
+
+func TestLFSEndpoint(t *testing.T) {
+	cases := map[string]string{
+		"https://github.com/org/repo":                       "https://github.com/org/repo.git/info/lfs",
+		"https://gitea.cnoe.localtest.me:8443/org/repo.git": "https://gitea.cnoe.localtest.me:8443/org/repo.git/info/lfs",
+		"git@github.com:org/repo":                           "https://github.com/org/repo.git/info/lfs",
+	}
+	for in, expected := range cases {
+		e, err := lfsEndpoint(in)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, e, in)
+	}
+	_, err := lfsEndpoint("/tmp/repo")
+	assert.NotNil(t, err)
+}
+
+func TestLFSClient(t *testing.T) {
+	_, ptr := lfsPointerFile("binary content")
+	src := &fakeLFSServer{objects: map[string][]byte{ptr.Oid: []byte("binary content")}}
+	srcServer := httptest.NewServer(src)
+	defer srcServer.Close()
+	dst := &fakeLFSServer{objects: map[string][]byte{}}
+	dstServer := httptest.NewServer(dst)
+	defer dstServer.Close()
+
+	objectsDir := t.TempDir()
+	ctx := context.Background()
+
+	c, err := NewLFSClient(srcServer.URL+"/org/repo", nil, false)
+	assert.Nil(t, err)
+	assert.Nil(t, c.Download(ctx, []LFSPointer{ptr}, objectsDir))
+	b, err := os.ReadFile(LFSObjectPath(objectsDir, ptr.Oid))
+	assert.Nil(t, err)
+	assert.Equal(t, "binary content", string(b))
+
+	_, missing := lfsPointerFile("missing")
+	assert.NotNil(t, c.Download(ctx, []LFSPointer{missing}, objectsDir))
+
+	c, err = NewLFSClient(dstServer.URL+"/org/repo.git", &githttp.BasicAuth{Username: "giteaAdmin", Password: "pw"}, false)
+	assert.Nil(t, err)
+	assert.Nil(t, c.Upload(ctx, []LFSPointer{ptr}, objectsDir))
+	assert.Equal(t, "binary content", string(dst.objects[ptr.Oid]))
+}
@@ -0,0 +1,103 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+const (
+	// RefLatest resolves to the highest semver tag that is not a pre-release.
+	RefLatest = "latest"
+	// RefSemverPrefix is followed by a semver constraint, e.g. semver:^1.4, and resolves to the highest matching tag.
+	RefSemverPrefix = "semver:"
+)
+
+// IsDynamicRef returns true if ref needs to be resolved against the tags of the remote repository.
+func IsDynamicRef(ref string) bool {
+	return ref == RefLatest || strings.HasPrefix(ref, RefSemverPrefix)
+}
+
+// ResolveRemoteRef returns remote with a dynamic ref replaced by the tag it resolves to.
+// remote is returned unchanged if its ref is not dynamic.
+func ResolveRemoteRef(ctx context.Context, remote v1alpha1.RemoteRepositorySpec, insecureSkipTLS bool, auth transport.AuthMethod) (v1alpha1.RemoteRepositorySpec, error) {
+	if !IsDynamicRef(remote.Ref) {
+		return remote, nil
+	}
+	tags, err := ListRemoteTags(ctx, remote.Url, insecureSkipTLS, auth)
+	if err != nil {
+		return remote, fmt.Errorf("listing tags of %s: %w", remote.Url, err)
+	}
+	tag, err := SelectTag(tags, remote.Ref)
+	if err != nil {
+		return remote, fmt.Errorf("resolving %s for %s: %w", remote.Ref, remote.Url, err)
+	}
+	remote.Ref = tag
+	return remote, nil
+}
+
+// ListRemoteTags returns the names of the tags in the remote repository without cloning it.
+func ListRemoteTags(ctx context.Context, url string, insecureSkipTLS bool, auth transport.AuthMethod) ([]string, error) {
+	r := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{
+		Name: "origin",
+		URLs: []string{url},
+	})
+	refs, err := r.ListContext(ctx, &git.ListOptions{
+		Auth:            auth,
+		InsecureSkipTLS: insecureSkipTLS,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		if ref.Name().IsTag() {
+			out = append(out, ref.Name().Short())
+		}
+	}
+	return out, nil
+}
+
+// SelectTag returns the highest tag that satisfies the dynamic ref. Tags that are not valid semver are ignored.
+func SelectTag(tags []string, ref string) (string, error) {
+	var constraint *semver.Constraints
+	if ref != RefLatest {
+		c, err := semver.NewConstraint(strings.TrimPrefix(ref, RefSemverPrefix))
+		if err != nil {
+			return "", fmt.Errorf("parsing semver constraint %s: %w", ref, err)
+		}
+		constraint = c
+	}
+
+	var (
+		selected    string
+		selectedVer *semver.Version
+	)
+	for _, tag := range tags {
+		v, err := semver.NewVersion(tag)
+		if err != nil {
+			continue
+		}
+		if constraint == nil && v.Prerelease() != "" {
+			continue
+		}
+		if constraint != nil && !constraint.Check(v) {
+			continue
+		}
+		if selectedVer == nil || v.GreaterThan(selectedVer) {
+			selected, selectedVer = tag, v
+		}
+	}
+	if selectedVer == nil {
+		return "", fmt.Errorf("no tag matches %s", ref)
+	}
+	return selected, nil
+}
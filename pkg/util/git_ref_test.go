@@ -0,0 +1,58 @@
+package util
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectTag(t *testing.T) {
+	tags := []string{"v1.3.0", "v1.4.0", "v1.4.2", "v1.5.0-rc.1", "v2.0.0", "release-3", "v2.1.0-beta.1"}
+	cases := []struct {
+		ref    string
+		expect string
+		err    bool
+	}{
+		{ref: RefLatest, expect: "v2.0.0"},
+		{ref: "semver:^1.4", expect: "v1.4.2"},
+		{ref: "semver:~1.3", expect: "v1.3.0"},
+		{ref: "semver:>=1.5.0-0", expect: "v2.1.0-beta.1"},
+		{ref: "semver:^3", err: true},
+		{ref: "semver:not-a-constraint", err: true},
+	}
+
+	for _, c := range cases {
+		tag, err := SelectTag(tags, c.ref)
+		if c.err {
+			assert.NotNil(t, err, c.ref)
+			continue
+		}
+		assert.Nil(t, err, c.ref)
+		assert.Equal(t, c.expect, tag, c.ref)
+	}
+}
+
+func TestResolveRemoteRef(t *testing.T) {
+	srcDir := t.TempDir()
+	src, err := git.PlainInit(srcDir, false)
+	assert.Nil(t, err)
+	for _, tag := range []string{"v1.0.0", "v1.1.0", "v2.0.0"} {
+		h := commitFile(t, src, srcDir, tag+".yaml")
+		_, err = src.CreateTag(tag, plumbing.NewHash(h), nil)
+		assert.Nil(t, err)
+	}
+
+	spec := v1alpha1.RemoteRepositorySpec{Url: srcDir, Ref: "semver:^1"}
+	resolved, err := ResolveRemoteRef(context.Background(), spec, false, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "v1.1.0", resolved.Ref)
+
+	spec.Ref = "main"
+	resolved, err = ResolveRemoteRef(context.Background(), spec, false, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "main", resolved.Ref)
+}
@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"path/filepath"
 	"strings"
 	"sync"
@@ -17,6 +18,9 @@ import (
 	"github.com/go-git/go-billy/v5/memfs"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"github.com/go-git/go-git/v5/plumbing/format/packfile"
+	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/go-git/go-git/v5/storage/memory"
 )
 
@@ -32,6 +36,27 @@ func (r *RepoMap) LoadOrStore(repoName, dir string) *RepoState {
 type RepoState struct {
 	MU  sync.Mutex
 	Dir string
+
+	fileLock *FileLock
+}
+
+// Lock locks the repository directory for this process and, through a lock file next to the directory,
+// for other idpbuilder processes sharing the same clone cache.
+func (s *RepoState) Lock() error {
+	s.MU.Lock()
+	l, err := LockFile(s.Dir + lockFileExt)
+	if err != nil {
+		s.MU.Unlock()
+		return err
+	}
+	s.fileLock = l
+	return nil
+}
+
+func (s *RepoState) Unlock() {
+	_ = s.fileLock.Unlock()
+	s.fileLock = nil
+	s.MU.Unlock()
 }
 
 func NewRepoLock() *RepoMap {
@@ -108,14 +133,19 @@ func ReadWorktreeFile(wt billy.Filesystem, path string) ([]byte, error) {
 	return b.Bytes(), nil
 }
 
-func CloneRemoteRepoToMemory(ctx context.Context, remote v1alpha1.RemoteRepositorySpec, depth int, insecureSkipTLS bool) (billy.Filesystem, *git.Repository, error) {
+// CloneRemoteRepoToMemory clones the remote repository into memory.
+// When remote.Path is a sub directory, only that directory is checked out.
+func CloneRemoteRepoToMemory(ctx context.Context, remote v1alpha1.RemoteRepositorySpec, depth int, insecureSkipTLS bool, auth transport.AuthMethod) (billy.Filesystem, *git.Repository, error) {
+	dirs := sparseCheckoutDirs(remote)
 	cloneOptions := &git.CloneOptions{
 		URL:               remote.Url,
+		Auth:              auth,
 		Depth:             depth,
 		ShallowSubmodules: true,
 		SingleBranch:      true,
 		Tags:              git.AllTags,
 		InsecureSkipTLS:   insecureSkipTLS,
+		NoCheckout:        len(dirs) > 0,
 	}
 	if remote.CloneSubmodules {
 		cloneOptions.RecurseSubmodules = git.DefaultSubmoduleRecursionDepth
@@ -135,15 +165,47 @@ func CloneRemoteRepoToMemory(ctx context.Context, remote v1alpha1.RemoteReposito
 			return nil, nil, err
 		}
 	}
+
+	if len(dirs) > 0 {
+		head, err := cloned.Head()
+		if err != nil {
+			return nil, nil, fmt.Errorf("getting head: %w", err)
+		}
+		if err = checkoutDirs(cloned, wt, head.Hash(), dirs); err != nil {
+			return nil, nil, err
+		}
+	}
 	return wt, cloned, nil
 }
 
-func CloneRemoteRepoToDir(ctx context.Context, remote v1alpha1.RemoteRepositorySpec, depth int, insecureSkipTLS bool, dir, fallbackUrl string) (billy.Filesystem, *git.Repository, error) {
+// CloneRemoteRepoToDir clones the remote repository into dir. If dir already contains a clone, e.g. from the clone
+// cache, the requested ref is fetched instead. A clone that cannot be updated is removed and cloned again.
+// When remote.Path is a sub directory, only that directory is checked out. See cloneSparseToDir.
+func CloneRemoteRepoToDir(ctx context.Context, remote v1alpha1.RemoteRepositorySpec, depth int, insecureSkipTLS bool, dir, fallbackUrl string, auth transport.AuthMethod) (billy.Filesystem, *git.Repository, error) {
+	if dirs := sparseCheckoutDirs(remote); len(dirs) > 0 && fallbackUrl == "" {
+		return cloneSparseToDir(ctx, remote, depth, insecureSkipTLS, dir, dirs, auth)
+	}
+
 	repo, err := git.PlainOpen(dir)
+	if err == nil {
+		uErr := fetchExistingRepo(ctx, repo, remote, depth, insecureSkipTLS, auth)
+		if uErr != nil {
+			// network and authentication errors would fail a new clone as well, keep the cached clone
+			if ctx.Err() != nil || !isStaleCloneError(uErr) {
+				return nil, nil, fmt.Errorf("fetching repo at %s: %w", dir, uErr)
+			}
+			// the history of the remote changed, e.g. the in-cluster git server was recreated, or the clone is corrupt.
+			if rErr := os.RemoveAll(dir); rErr != nil {
+				return nil, nil, fmt.Errorf("removing stale clone at %s: %w", dir, rErr)
+			}
+			repo, err = nil, git.ErrRepositoryNotExists
+		}
+	}
 	if err != nil {
 		if errors.Is(err, git.ErrRepositoryNotExists) {
 			cloneOptions := &git.CloneOptions{
 				URL:               remote.Url,
+				Auth:              auth,
 				Depth:             depth,
 				ShallowSubmodules: true,
 				Tags:              git.AllTags,
@@ -174,7 +236,7 @@ func CloneRemoteRepoToDir(ctx context.Context, remote v1alpha1.RemoteRepositoryS
 		return nil, nil, fmt.Errorf("getting repo worktree: %w", err)
 	}
 	if remote.Ref != "" {
-		cErr := checkoutCommitOrRef(ctx, wt, remote.Ref)
+		cErr := checkoutCommitOrRef(ctx, wt, remote.Ref, auth)
 		if cErr != nil {
 			return nil, nil, fmt.Errorf("checkout %s: %w", remote.Ref, cErr)
 		}
@@ -231,8 +293,87 @@ func CopyWTFile(srcWT, dstWT billy.Filesystem, srcFile, dstFile string) error {
 	return nil
 }
 
+// fetchExistingRepo brings an existing clone up to date with its origin.
+// When no ref is requested, the worktree is reset to the remote tracking branch of the current branch.
+func fetchExistingRepo(ctx context.Context, repo *git.Repository, remote v1alpha1.RemoteRepositorySpec, depth int, insecureSkipTLS bool, auth transport.AuthMethod) error {
+	err := fetchOrigin(ctx, repo, depth, insecureSkipTLS, auth)
+	if err != nil {
+		return err
+	}
+
+	if remote.Ref != "" {
+		return nil
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return fmt.Errorf("getting head: %w", err)
+	}
+	if !head.Name().IsBranch() {
+		return nil
+	}
+
+	remoteRef, err := repo.Reference(plumbing.NewRemoteReferenceName("origin", head.Name().Short()), true)
+	if err != nil {
+		if errors.Is(err, plumbing.ErrReferenceNotFound) {
+			return nil
+		}
+		return fmt.Errorf("getting remote reference: %w", err)
+	}
+	if remoteRef.Hash() == head.Hash() {
+		// a clone used for sparse checkouts has nothing in its index and needs to be checked out.
+		idx, err := repo.Storer.Index()
+		if err != nil {
+			return fmt.Errorf("reading index: %w", err)
+		}
+		if len(idx.Entries) > 0 {
+			return nil
+		}
+	}
+
+	wt, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("getting repo worktree: %w", err)
+	}
+	return wt.Reset(&git.ResetOptions{Commit: remoteRef.Hash(), Mode: git.HardReset})
+}
+
+// isStaleCloneError returns true for errors of updating an existing clone that cloning again fixes: the history of the
+// remote changed or the clone is corrupt.
+func isStaleCloneError(err error) bool {
+	for _, target := range []error{
+		git.ErrForceNeeded,
+		git.ErrNonFastForwardUpdate,
+		git.ErrRepositoryNotExists,
+		plumbing.ErrObjectNotFound,
+		plumbing.ErrInvalidType,
+		index.ErrMalformedSignature,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	var packErr *packfile.Error
+	return errors.As(err, &packErr)
+}
+
+func fetchOrigin(ctx context.Context, repo *git.Repository, depth int, insecureSkipTLS bool, auth transport.AuthMethod) error {
+	err := repo.FetchContext(ctx, &git.FetchOptions{
+		RemoteName:      "origin",
+		Auth:            auth,
+		Depth:           depth,
+		Tags:            git.AllTags,
+		Force:           true,
+		InsecureSkipTLS: insecureSkipTLS,
+	})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return err
+	}
+	return nil
+}
+
 // ref could be anything. Check if hash, tag, or branch in that order
-func checkoutCommitOrRef(ctx context.Context, wt *git.Worktree, ref string) error {
+func checkoutCommitOrRef(ctx context.Context, wt *git.Worktree, ref string, auth transport.AuthMethod) error {
 	var refName plumbing.ReferenceName
 	opts := &git.CheckoutOptions{
 		Hash: plumbing.NewHash(ref),
@@ -258,6 +399,7 @@ func checkoutCommitOrRef(ctx context.Context, wt *git.Worktree, ref string) erro
 	}
 	pullOpts := &git.PullOptions{
 		RemoteName: "origin",
+		Auth:       auth,
 	}
 
 	if opts.Hash.IsZero() {
@@ -2,15 +2,20 @@ package util
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
 	"github.com/go-git/go-billy/v5"
 	"github.com/go-git/go-billy/v5/memfs"
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-git/go-git/v5/storage/memory"
 	"github.com/stretchr/testify/assert"
 )
@@ -25,7 +30,7 @@ func TestCloneRemoteRepoToDir(t *testing.T) {
 	dir, _ := os.MkdirTemp("", "TestCopyToDir")
 	defer os.RemoveAll(dir)
 	// new clone
-	_, _, err := CloneRemoteRepoToDir(context.Background(), spec, 0, false, dir, "")
+	_, _, err := CloneRemoteRepoToDir(context.Background(), spec, 0, false, dir, "", nil)
 	assert.Nil(t, err)
 	testDir, _ := os.MkdirTemp("", "TestCopyToDir")
 	defer os.RemoveAll(testDir)
@@ -41,7 +46,7 @@ func TestCloneRemoteRepoToDir(t *testing.T) {
 	testDir2, _ := os.MkdirTemp("", "TestCopyToDir")
 	defer os.RemoveAll(testDir2)
 
-	_, _, err = CloneRemoteRepoToDir(context.Background(), spec, 0, false, dir, "")
+	_, _, err = CloneRemoteRepoToDir(context.Background(), spec, 0, false, dir, "", nil)
 	repo, err = git.PlainClone(testDir2, false, &git.CloneOptions{URL: dir})
 	assert.Nil(t, err)
 	ref, err = repo.Head()
@@ -60,7 +65,7 @@ func TestCopyTreeToTree(t *testing.T) {
 	}
 
 	dst := memfs.New()
-	src, _, err := CloneRemoteRepoToMemory(context.Background(), spec, 1, false)
+	src, _, err := CloneRemoteRepoToMemory(context.Background(), spec, 1, false, nil)
 	assert.Nil(t, err)
 
 	err = CopyTreeToTree(src, dst, spec.Path, ".")
@@ -114,3 +119,70 @@ func TestGetWorktreeYamlFiles(t *testing.T) {
 	assert.Equal(t, nil, err)
 	assert.Equal(t, 0, len(paths))
 }
+
+func TestCloneRemoteRepoToDirFetchesExisting(t *testing.T) {
+	srcDir := t.TempDir()
+	src, err := git.PlainInit(srcDir, false)
+	assert.Nil(t, err)
+	commitFile(t, src, srcDir, "a.yaml")
+
+	spec := v1alpha1.RemoteRepositorySpec{
+		Path: ".",
+		Url:  srcDir,
+	}
+	cloneDir := filepath.Join(t.TempDir(), RepoUrlHash(srcDir))
+	wt, _, err := CloneRemoteRepoToDir(context.Background(), spec, 1, false, cloneDir, "", nil)
+	assert.Nil(t, err)
+	_, err = wt.Stat("a.yaml")
+	assert.Nil(t, err)
+
+	hash := commitFile(t, src, srcDir, "b.yaml")
+	wt, repo, err := CloneRemoteRepoToDir(context.Background(), spec, 1, false, cloneDir, "", nil)
+	assert.Nil(t, err)
+	_, err = wt.Stat("b.yaml")
+	assert.Nil(t, err)
+	head, err := repo.Head()
+	assert.Nil(t, err)
+	assert.Equal(t, hash, head.Hash().String())
+}
+
+func TestCloneRemoteRepoToDirUnreachableRemote(t *testing.T) {
+	srcDir := t.TempDir()
+	src, err := git.PlainInit(srcDir, false)
+	assert.Nil(t, err)
+	commitFile(t, src, srcDir, "a.yaml")
+
+	spec := v1alpha1.RemoteRepositorySpec{
+		Path: ".",
+		Url:  srcDir,
+	}
+	cloneDir := filepath.Join(t.TempDir(), RepoUrlHash(srcDir))
+	_, repo, err := CloneRemoteRepoToDir(context.Background(), spec, 1, false, cloneDir, "", nil)
+	assert.Nil(t, err)
+
+	// nothing listens on port 1
+	assert.Nil(t, repo.DeleteRemote("origin"))
+	_, err = repo.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{"http://127.0.0.1:1/repo.git"}})
+	assert.Nil(t, err)
+
+	_, _, err = CloneRemoteRepoToDir(context.Background(), spec, 1, false, cloneDir, "", nil)
+	assert.NotNil(t, err)
+	assert.False(t, isStaleCloneError(err))
+	// the cached clone is kept
+	_, err = os.Stat(filepath.Join(cloneDir, "a.yaml"))
+	assert.Nil(t, err)
+
+	assert.True(t, isStaleCloneError(fmt.Errorf("fetching: %w", plumbing.ErrObjectNotFound)))
+	assert.True(t, isStaleCloneError(git.ErrRepositoryNotExists))
+}
+
+func commitFile(t *testing.T, repo *git.Repository, dir, name string) string {
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, name), []byte("kind: ConfigMap\n"), 0600))
+	wt, err := repo.Worktree()
+	assert.Nil(t, err)
+	_, err = wt.Add(name)
+	assert.Nil(t, err)
+	h, err := wt.Commit(name, &git.CommitOptions{Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()}})
+	assert.Nil(t, err)
+	return h.String()
+}
@@ -0,0 +1,120 @@
+package util
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	GitServerSecretName = "idpbuilder-git-server"
+)
+
+// GitServerConfig describes a git server that is not installed by idpbuilder.
+// Package repositories are pushed to it when Gitea is disabled.
+type GitServerConfig struct {
+	// Provider is the type of the git server: gitea, github or gitlab.
+	Provider string `json:"provider"`
+	// URL is the base URL of the git server used for API calls.
+	URL string `json:"url"`
+	// InternalURL is the base URL of the git server accessible within the cluster. Defaults to URL.
+	InternalURL string `json:"internalUrl,omitempty"`
+	// Organization is the organization or user the repositories are created in. The full path of a group for gitlab.
+	// Repositories are created in the namespace of the token's user for github and gitlab when it is empty.
+	Organization string `json:"organization,omitempty"`
+	// Repository configures the repositories created on the git server.
+	Repository v1alpha1.GitRepositorySettings `json:"repository,omitempty"`
+
+	// Username and Password are used by gitea, Token by github and gitlab.
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	Token    string `json:"token,omitempty"`
+}
+
+// NewGitServerConfig reads the git server config at the given path.
+func NewGitServerConfig(path string) (*GitServerConfig, error) {
+	b, err := os.ReadFile(expandHome(path))
+	if err != nil {
+		return nil, fmt.Errorf("reading git server config %s: %w", path, err)
+	}
+	c := &GitServerConfig{}
+	if err = yaml.UnmarshalStrict(b, c); err != nil {
+		return nil, fmt.Errorf("parsing git server config %s: %w", path, err)
+	}
+	if err = c.validate(); err != nil {
+		return nil, fmt.Errorf("invalid git server config %s: %w", path, err)
+	}
+	return c, nil
+}
+
+func (c *GitServerConfig) validate() error {
+	if !strings.HasPrefix(c.URL, "http://") && !strings.HasPrefix(c.URL, "https://") {
+		return fmt.Errorf("url must be an http or https url")
+	}
+	switch c.Repository.Visibility {
+	case "", "public", "private", "internal":
+	default:
+		return fmt.Errorf("repository visibility must be public, private or internal")
+	}
+	switch c.Provider {
+	case v1alpha1.GitProviderGitea:
+		if c.Organization == "" {
+			return fmt.Errorf("organization must be set for %s", c.Provider)
+		}
+		if c.Username == "" || c.Password == "" {
+			return fmt.Errorf("username and password must be set for %s", c.Provider)
+		}
+	case v1alpha1.GitProviderGitHub, v1alpha1.GitProviderGitLab:
+		if c.Token == "" {
+			return fmt.Errorf("token must be set for %s", c.Provider)
+		}
+	default:
+		return fmt.Errorf("provider must be one of %s, %s, %s", v1alpha1.GitProviderGitea, v1alpha1.GitProviderGitHub, v1alpha1.GitProviderGitLab)
+	}
+	return nil
+}
+
+// Spec returns the git server spec of the Localbuild. The credentials are referenced from SecretObject.
+func (c *GitServerConfig) Spec() *v1alpha1.GitServerSpec {
+	internal := c.InternalURL
+	if internal == "" {
+		internal = c.URL
+	}
+	return &v1alpha1.GitServerSpec{
+		Provider: v1alpha1.Provider{
+			Name:             c.Provider,
+			GitURL:           strings.TrimSuffix(c.URL, "/"),
+			InternalGitURL:   strings.TrimSuffix(internal, "/"),
+			OrganizationName: c.Organization,
+			Repository:       c.Repository,
+		},
+		SecretRef: v1alpha1.SecretReference{
+			Name:      GitServerSecretName,
+			Namespace: corev1.NamespaceDefault,
+		},
+	}
+}
+
+// SecretObject returns the secret with the credentials of the git server.
+func (c *GitServerConfig) SecretObject() corev1.Secret {
+	return corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GitServerSecretName,
+			Namespace: corev1.NamespaceDefault,
+		},
+		StringData: map[string]string{
+			"username": c.Username,
+			"password": c.Password,
+			"token":    c.Token,
+		},
+	}
+}
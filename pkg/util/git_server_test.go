@@ -0,0 +1,88 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewGitServerConfig(t *testing.T) {
+	dir := t.TempDir()
+	cases := map[string]struct {
+		content string
+		err     string
+	}{
+		"gitea": {
+			content: "provider: gitea\nurl: https://git.example.com/\norganization: platform\nusername: admin\npassword: secret\n",
+		},
+		"github": {
+			content: "provider: github\nurl: https://github.com\norganization: platform\ntoken: gh-token\n",
+		},
+		"gitlab": {
+			content: "provider: gitlab\nurl: https://gitlab.example.com\norganization: platform/idp\ntoken: glpat-token\n",
+		},
+		"github user": {
+			content: "provider: github\nurl: https://github.com\ntoken: gh-token\nrepository:\n  visibility: public\n  topics: [idpbuilder]\n",
+		},
+		"gitea without organization": {
+			content: "provider: gitea\nurl: https://git.example.com\nusername: admin\npassword: secret\n",
+			err:     "organization must be set",
+		},
+		"invalid visibility": {
+			content: "provider: github\nurl: https://github.com\ntoken: gh-token\nrepository:\n  visibility: secret\n",
+			err:     "repository visibility must be",
+		},
+		"missing token": {
+			content: "provider: gitlab\nurl: https://gitlab.example.com\norganization: platform\n",
+			err:     "token must be set",
+		},
+		"missing password": {
+			content: "provider: gitea\nurl: https://git.example.com\norganization: platform\nusername: admin\n",
+			err:     "username and password must be set",
+		},
+		"invalid provider": {
+			content: "provider: svn\nurl: https://git.example.com\norganization: platform\n",
+			err:     "provider must be one of",
+		},
+		"invalid url": {
+			content: "provider: github\nurl: git.example.com\norganization: platform\ntoken: t\n",
+			err:     "url must be an http or https url",
+		},
+		"unknown field": {
+			content: "provider: github\nurl: https://github.com\norg: platform\n",
+			err:     "parsing git server config",
+		},
+	}
+
+	for name, c := range cases {
+		p := filepath.Join(dir, name+".yaml")
+		assert.NoError(t, os.WriteFile(p, []byte(c.content), 0600))
+		_, err := NewGitServerConfig(p)
+		if c.err != "" {
+			assert.ErrorContains(t, err, c.err, name)
+		} else {
+			assert.NoError(t, err, name)
+		}
+	}
+
+	c, err := NewGitServerConfig(filepath.Join(dir, "gitea.yaml"))
+	assert.NoError(t, err)
+	spec := c.Spec()
+	assert.Equal(t, v1alpha1.Provider{
+		Name:             v1alpha1.GitProviderGitea,
+		GitURL:           "https://git.example.com",
+		InternalGitURL:   "https://git.example.com",
+		OrganizationName: "platform",
+	}, spec.Provider)
+	s := c.SecretObject()
+	assert.Equal(t, spec.SecretRef.Name, s.Name)
+	assert.Equal(t, spec.SecretRef.Namespace, s.Namespace)
+	assert.Equal(t, "secret", s.StringData["password"])
+
+	c, err = NewGitServerConfig(filepath.Join(dir, "github user.yaml"))
+	assert.NoError(t, err)
+	assert.Equal(t, v1alpha1.GitRepositorySettings{Visibility: "public", Topics: []string{"idpbuilder"}}, c.Spec().Provider.Repository)
+}
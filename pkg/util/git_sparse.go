@@ -0,0 +1,386 @@
+package util
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
+	"github.com/go-git/go-billy/v5"
+	billyutil "github.com/go-git/go-billy/v5/util"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+)
+
+// git executable used for partial clones. go-git does not support object filters.
+var gitExecutable = "git"
+
+// sparseCheckoutDirs returns the directories of the remote repository that need to be checked out.
+// nil means the whole tree.
+func sparseCheckoutDirs(remote v1alpha1.RemoteRepositorySpec) []string {
+	p := strings.Trim(path.Clean("/"+remote.Path), "/")
+	if p == "" || p == "." {
+		return nil
+	}
+	return []string{p}
+}
+
+// cloneSparseToDir makes the given directories of the remote repository available in dir.
+// When the git CLI is available, a blob-less partial clone with sparse checkout is used so that only the objects
+// under dirs are downloaded. Otherwise, the repository is cloned by go-git without checking out and only dirs are
+// written to the worktree.
+func cloneSparseToDir(ctx context.Context, remote v1alpha1.RemoteRepositorySpec, depth int, insecureSkipTLS bool, dir string, dirs []string, auth transport.AuthMethod) (billy.Filesystem, *git.Repository, error) {
+	if canUseGitCLI(remote, auth) {
+		err := partialCloneWithGitCLI(ctx, remote, depth, insecureSkipTLS, dir, dirs, auth)
+		if err == nil {
+			return openWorktree(dir)
+		}
+		if ctx.Err() != nil {
+			return nil, nil, err
+		}
+		// fall back to go-git with a clean directory
+		if rErr := os.RemoveAll(dir); rErr != nil {
+			return nil, nil, fmt.Errorf("removing %s: %w", dir, rErr)
+		}
+	}
+
+	repo, err := git.PlainOpen(dir)
+	if err == nil {
+		fErr := fetchOrigin(ctx, repo, depth, insecureSkipTLS, auth)
+		if fErr != nil {
+			if ctx.Err() != nil {
+				return nil, nil, fmt.Errorf("fetching repo at %s: %w", dir, fErr)
+			}
+			if rErr := os.RemoveAll(dir); rErr != nil {
+				return nil, nil, fmt.Errorf("removing stale clone at %s: %w", dir, rErr)
+			}
+			err = git.ErrRepositoryNotExists
+		}
+	}
+	if err != nil {
+		if !errors.Is(err, git.ErrRepositoryNotExists) {
+			return nil, nil, fmt.Errorf("opening repo at %s %w", dir, err)
+		}
+		repo, err = git.PlainCloneContext(ctx, dir, false, &git.CloneOptions{
+			URL:             remote.Url,
+			Auth:            auth,
+			Depth:           depth,
+			NoCheckout:      true,
+			Tags:            git.AllTags,
+			InsecureSkipTLS: insecureSkipTLS,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("cloning repo: %w", err)
+		}
+	}
+
+	hash, err := resolveRef(repo, remote.Ref)
+	if err != nil {
+		return nil, nil, fmt.Errorf("resolving %s: %w", remote.Ref, err)
+	}
+
+	wt, err := repo.Worktree()
+	if err != nil {
+		return nil, nil, fmt.Errorf("getting repo worktree: %w", err)
+	}
+
+	if remote.CloneSubmodules {
+		hasSubmodules, sErr := dirsHaveSubmodules(repo, hash, dirs)
+		if sErr != nil {
+			return nil, nil, sErr
+		}
+		// go-git can only update submodules of a full checkout
+		if hasSubmodules {
+			if err = checkoutWithSubmodules(ctx, wt, hash, depth, auth); err != nil {
+				return nil, nil, err
+			}
+			return wt.Filesystem, repo, nil
+		}
+	}
+
+	if err = checkoutDirs(repo, wt.Filesystem, hash, dirs); err != nil {
+		return nil, nil, err
+	}
+	return wt.Filesystem, repo, nil
+}
+
+// resolveRef returns the commit for ref. Hashes, tags, and remote branches are checked in that order.
+// When ref is empty, the remote tracking branch of HEAD is used.
+func resolveRef(repo *git.Repository, ref string) (plumbing.Hash, error) {
+	var candidates []string
+	if ref == "" {
+		head, err := repo.Reference(plumbing.HEAD, false)
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+		if head.Type() == plumbing.SymbolicReference && head.Target().IsBranch() {
+			candidates = append(candidates, plumbing.NewRemoteReferenceName("origin", head.Target().Short()).String())
+		}
+		candidates = append(candidates, plumbing.HEAD.String())
+	} else {
+		if plumbing.IsHash(ref) {
+			candidates = append(candidates, ref)
+		}
+		candidates = append(candidates,
+			plumbing.NewTagReferenceName(ref).String(),
+			plumbing.NewRemoteReferenceName("origin", ref).String(),
+			plumbing.NewBranchReferenceName(ref).String(),
+		)
+	}
+
+	var err error
+	for _, c := range candidates {
+		var h *plumbing.Hash
+		h, err = repo.ResolveRevision(plumbing.Revision(c))
+		if err == nil {
+			return *h, nil
+		}
+	}
+	return plumbing.ZeroHash, err
+}
+
+// checkoutDirs replaces dirs in fs with their contents at the given commit. Other paths are left untouched.
+func checkoutDirs(repo *git.Repository, fs billy.Filesystem, hash plumbing.Hash, dirs []string) error {
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return fmt.Errorf("getting commit %s: %w", hash, err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return fmt.Errorf("getting tree of %s: %w", hash, err)
+	}
+
+	for _, d := range dirs {
+		sub, err := tree.Tree(d)
+		if err != nil {
+			return fmt.Errorf("getting %s at %s: %w", d, hash, err)
+		}
+		if err = billyutil.RemoveAll(fs, d); err != nil {
+			return fmt.Errorf("removing %s: %w", d, err)
+		}
+		err = sub.Files().ForEach(func(f *object.File) error {
+			return writeTreeFile(fs, path.Join(d, f.Name), f)
+		})
+		if err != nil {
+			return fmt.Errorf("checking out %s: %w", d, err)
+		}
+	}
+	return nil
+}
+
+func dirsHaveSubmodules(repo *git.Repository, hash plumbing.Hash, dirs []string) (bool, error) {
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return false, fmt.Errorf("getting commit %s: %w", hash, err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return false, fmt.Errorf("getting tree of %s: %w", hash, err)
+	}
+
+	for _, d := range dirs {
+		sub, err := tree.Tree(d)
+		if err != nil {
+			return false, fmt.Errorf("getting %s at %s: %w", d, hash, err)
+		}
+		walker := object.NewTreeWalker(sub, true, nil)
+		for {
+			_, entry, err := walker.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				walker.Close()
+				return false, fmt.Errorf("walking %s: %w", d, err)
+			}
+			if entry.Mode == filemode.Submodule {
+				walker.Close()
+				return true, nil
+			}
+		}
+		walker.Close()
+	}
+	return false, nil
+}
+
+func checkoutWithSubmodules(ctx context.Context, wt *git.Worktree, hash plumbing.Hash, depth int, auth transport.AuthMethod) error {
+	if err := wt.Reset(&git.ResetOptions{Commit: hash, Mode: git.HardReset}); err != nil {
+		return fmt.Errorf("checking out %s: %w", hash, err)
+	}
+	subs, err := wt.Submodules()
+	if err != nil {
+		return fmt.Errorf("getting submodules: %w", err)
+	}
+	err = subs.UpdateContext(ctx, &git.SubmoduleUpdateOptions{
+		Init:              true,
+		Depth:             depth,
+		Auth:              auth,
+		RecurseSubmodules: git.DefaultSubmoduleRecursionDepth,
+	})
+	if err != nil {
+		return fmt.Errorf("updating submodules: %w", err)
+	}
+	return nil
+}
+
+func writeTreeFile(fs billy.Filesystem, name string, f *object.File) error {
+	r, err := f.Reader()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	if f.Mode == filemode.Symlink {
+		target, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		return fs.Symlink(string(target), name)
+	}
+
+	perm, err := f.Mode.ToOSFileMode()
+	if err != nil {
+		return err
+	}
+	out, err := fs.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	_, err = io.Copy(out, r)
+	return err
+}
+
+// the git CLI is only used when it can authenticate the same way go-git would.
+// credentials for ssh, e.g. keys from a git auth config, cannot be passed to it.
+func canUseGitCLI(remote v1alpha1.RemoteRepositorySpec, auth transport.AuthMethod) bool {
+	if _, err := exec.LookPath(gitExecutable); err != nil {
+		return false
+	}
+	ep, err := transport.NewEndpoint(remote.Url)
+	if err != nil {
+		return false
+	}
+	if ep.Protocol != "http" && ep.Protocol != "https" && ep.Protocol != "file" {
+		return false
+	}
+	switch auth.(type) {
+	case nil, *githttp.BasicAuth:
+		return true
+	}
+	return false
+}
+
+// partialCloneWithGitCLI clones or updates a blob-less partial clone in dir with only dirs checked out.
+// Servers that do not support filters send all objects instead.
+func partialCloneWithGitCLI(ctx context.Context, remote v1alpha1.RemoteRepositorySpec, depth int, insecureSkipTLS bool, dir string, dirs []string, auth transport.AuthMethod) error {
+	var config []gitConfig
+	if insecureSkipTLS {
+		config = append(config, gitConfig{key: "http.sslVerify", value: "false"})
+	}
+	if a, ok := auth.(*githttp.BasicAuth); ok {
+		cred := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", a.Username, a.Password)))
+		// scoped to the repository so that the credentials are not sent to submodule hosts
+		config = append(config, gitConfig{
+			key:    fmt.Sprintf("http.%s.extraHeader", remote.Url),
+			value:  fmt.Sprintf("Authorization: Basic %s", cred),
+			secret: cred,
+		})
+	}
+
+	depthArgs := []string{}
+	if depth > 0 {
+		depthArgs = append(depthArgs, fmt.Sprintf("--depth=%d", depth))
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, git.GitDirName)); err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		args := append([]string{"clone", "--filter=blob:none", "--no-checkout", "--sparse"}, depthArgs...)
+		args = append(args, "--", remote.Url, dir)
+		if err = runGit(ctx, "", config, args...); err != nil {
+			return err
+		}
+	}
+
+	patterns := make([]string, 0, len(dirs))
+	for _, d := range dirs {
+		patterns = append(patterns, fmt.Sprintf("/%s/", d))
+	}
+	if err := runGit(ctx, dir, config, append([]string{"sparse-checkout", "set", "--no-cone"}, patterns...)...); err != nil {
+		return err
+	}
+
+	ref := remote.Ref
+	if ref == "" {
+		ref = plumbing.HEAD.String()
+	}
+	args := append([]string{"fetch", "--force"}, depthArgs...)
+	if err := runGit(ctx, dir, config, append(args, "origin", ref)...); err != nil {
+		return err
+	}
+	if err := runGit(ctx, dir, config, "checkout", "--force", "--detach", "FETCH_HEAD"); err != nil {
+		return err
+	}
+
+	if !remote.CloneSubmodules {
+		return nil
+	}
+	args = append([]string{"submodule", "update", "--init", "--recursive", "--force"}, depthArgs...)
+	return runGit(ctx, dir, config, append(append(args, "--"), dirs...)...)
+}
+
+// gitConfig is a configuration variable of a git CLI command.
+type gitConfig struct {
+	key   string
+	value string
+	// secret is redacted from the output of the command, e.g. the credentials in value.
+	secret string
+}
+
+// runGit runs the git CLI. Configuration variables are passed in the environment instead of with -c so that they,
+// including credentials, are not visible in the arguments of the process.
+func runGit(ctx context.Context, dir string, config []gitConfig, args ...string) error {
+	cmd := exec.CommandContext(ctx, gitExecutable, args...)
+	cmd.Dir = dir
+	// LFS objects are handled by the GitRepository controller, see RemoteRepositorySpec.LFS
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_LFS_SKIP_SMUDGE=1", fmt.Sprintf("GIT_CONFIG_COUNT=%d", len(config)))
+	for i, c := range config {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("GIT_CONFIG_KEY_%d=%s", i, c.key), fmt.Sprintf("GIT_CONFIG_VALUE_%d=%s", i, c.value))
+	}
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		msg := strings.TrimSpace(string(out))
+		for _, c := range config {
+			if c.secret != "" {
+				msg = strings.ReplaceAll(msg, c.secret, "<redacted>")
+			}
+		}
+		return fmt.Errorf("running git %s: %s: %w", args[0], msg, err)
+	}
+	return nil
+}
+
+func openWorktree(dir string) (billy.Filesystem, *git.Repository, error) {
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("opening repo at %s %w", dir, err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return nil, nil, fmt.Errorf("getting repo worktree: %w", err)
+	}
+	return wt.Filesystem, repo, nil
+}
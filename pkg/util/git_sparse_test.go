@@ -0,0 +1,125 @@
+package util
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
+	"github.com/go-git/go-git/v5"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSparseCheckoutDirs(t *testing.T) {
+	cases := map[string][]string{
+		"":                 nil,
+		".":                nil,
+		"/":                nil,
+		"examples/basic":   {"examples/basic"},
+		"/examples/basic/": {"examples/basic"},
+		"./a/../b":         {"b"},
+	}
+	for p, expected := range cases {
+		assert.Equal(t, expected, sparseCheckoutDirs(v1alpha1.RemoteRepositorySpec{Path: p}), p)
+	}
+}
+
+func TestCloneRemoteRepoToDirSparse(t *testing.T) {
+	srcDir := t.TempDir()
+	src, err := git.PlainInit(srcDir, false)
+	assert.Nil(t, err)
+	assert.Nil(t, os.MkdirAll(filepath.Join(srcDir, "packages", "app"), 0700))
+	commitFile(t, src, srcDir, "packages/app/a.yaml")
+	commitFile(t, src, srcDir, "other.yaml")
+
+	for name, executable := range map[string]string{"git-cli": gitExecutable, "go-git": "git-not-found"} {
+		t.Run(name, func(t *testing.T) {
+			defer func(e string) { gitExecutable = e }(gitExecutable)
+			gitExecutable = executable
+
+			spec := v1alpha1.RemoteRepositorySpec{
+				CloneSubmodules: true,
+				Path:            "packages/app",
+				Url:             "file://" + srcDir,
+			}
+			cloneDir := filepath.Join(t.TempDir(), RepoUrlHash(srcDir))
+			wt, _, err := CloneRemoteRepoToDir(context.Background(), spec, 1, false, cloneDir, "", nil)
+			assert.Nil(t, err)
+			_, err = wt.Stat("packages/app/a.yaml")
+			assert.Nil(t, err)
+			_, err = wt.Stat("other.yaml")
+			assert.True(t, os.IsNotExist(err))
+
+			config, err := os.ReadFile(filepath.Join(cloneDir, ".git", "config"))
+			assert.Nil(t, err)
+			assert.Equal(t, name == "git-cli", strings.Contains(string(config), "partialclonefilter = blob:none"))
+
+			commitFile(t, src, srcDir, "packages/app/b.yaml")
+			wt, _, err = CloneRemoteRepoToDir(context.Background(), spec, 1, false, cloneDir, "", nil)
+			assert.Nil(t, err)
+			_, err = wt.Stat("packages/app/b.yaml")
+			assert.Nil(t, err)
+		})
+	}
+}
+
+func TestCloneRemoteRepoToMemorySparse(t *testing.T) {
+	srcDir := t.TempDir()
+	src, err := git.PlainInit(srcDir, false)
+	assert.Nil(t, err)
+	assert.Nil(t, os.MkdirAll(filepath.Join(srcDir, "packages", "app"), 0700))
+	commitFile(t, src, srcDir, "packages/app/a.yaml")
+	commitFile(t, src, srcDir, "other.yaml")
+
+	wt, _, err := CloneRemoteRepoToMemory(context.Background(), v1alpha1.RemoteRepositorySpec{Path: "packages/app", Url: srcDir}, 1, false, nil)
+	assert.Nil(t, err)
+	_, err = wt.Stat("packages/app/a.yaml")
+	assert.Nil(t, err)
+	_, err = wt.Stat("other.yaml")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestCloneRemoteRepoToDirAfterSparse(t *testing.T) {
+	defer func(e string) { gitExecutable = e }(gitExecutable)
+	gitExecutable = "git-not-found"
+
+	srcDir := t.TempDir()
+	src, err := git.PlainInit(srcDir, false)
+	assert.Nil(t, err)
+	assert.Nil(t, os.MkdirAll(filepath.Join(srcDir, "packages", "app"), 0700))
+	commitFile(t, src, srcDir, "packages/app/a.yaml")
+	commitFile(t, src, srcDir, "other.yaml")
+
+	cloneDir := filepath.Join(t.TempDir(), RepoUrlHash(srcDir))
+	_, _, err = CloneRemoteRepoToDir(context.Background(), v1alpha1.RemoteRepositorySpec{Path: "packages/app", Url: srcDir}, 1, false, cloneDir, "", nil)
+	assert.Nil(t, err)
+
+	wt, _, err := CloneRemoteRepoToDir(context.Background(), v1alpha1.RemoteRepositorySpec{Path: ".", Url: srcDir}, 1, false, cloneDir, "", nil)
+	assert.Nil(t, err)
+	_, err = wt.Stat("other.yaml")
+	assert.Nil(t, err)
+}
+
+func TestPartialCloneWithGitCLICredentials(t *testing.T) {
+	// the fake git prints its arguments and the configuration it received, then fails
+	dir := t.TempDir()
+	fakeGit := filepath.Join(dir, "git")
+	script := "#!/bin/sh\necho \"args: $*\"\necho \"$GIT_CONFIG_KEY_0: $GIT_CONFIG_VALUE_0\"\nexit 1\n"
+	assert.Nil(t, os.WriteFile(fakeGit, []byte(script), 0700))
+	defer func(e string) { gitExecutable = e }(gitExecutable)
+	gitExecutable = fakeGit
+
+	remote := v1alpha1.RemoteRepositorySpec{Path: "packages/app", Url: "https://git.example.com/org/repo.git"}
+	auth := &githttp.BasicAuth{Username: "user", Password: "secret"}
+	err := partialCloneWithGitCLI(context.Background(), remote, 1, false, filepath.Join(dir, "clone"), []string{"packages/app"}, auth)
+	assert.NotNil(t, err)
+
+	cred := base64.StdEncoding.EncodeToString([]byte("user:secret"))
+	assert.NotContains(t, err.Error(), cred)
+	assert.Contains(t, err.Error(), "http.https://git.example.com/org/repo.git.extraHeader: Authorization: Basic <redacted>")
+	assert.NotContains(t, err.Error(), "extraHeader=")
+}
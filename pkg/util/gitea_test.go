@@ -21,3 +21,16 @@ func TestGiteaBaseUrl(t *testing.T) {
 	s = GiteaBaseUrl(c)
 	assert.Equal(t, "http://cnoe.localtest.me:8080/gitea", s)
 }
+
+func TestGitHTTPBaseUrl(t *testing.T) {
+	c := v1alpha1.BuildCustomizationSpec{
+		Protocol:       "https",
+		Port:           "8443",
+		Host:           "cnoe.localtest.me",
+		UsePathRouting: false,
+	}
+
+	assert.Equal(t, "https://git.cnoe.localtest.me:8443", GitHTTPBaseUrl(c))
+	c.UsePathRouting = true
+	assert.Equal(t, "https://cnoe.localtest.me:8443/git", GitHTTPBaseUrl(c))
+}
@@ -0,0 +1,39 @@
+package util
+
+import (
+	"fmt"
+
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// hardcoded values from what we have in the githttp installation files.
+	GitHTTPNamespace   = "githttp"
+	GitHTTPAdminSecret = "githttp-credential"
+	GitHTTPAdminName   = "gitAdmin"
+	GitHTTPURLTempl    = "%s://%s%s:%s%s"
+	// GitHTTPInternalURL is the url of the githttp service within the cluster.
+	GitHTTPInternalURL = "http://githttp.githttp.svc.cluster.local"
+)
+
+func GitHTTPBaseUrl(config v1alpha1.BuildCustomizationSpec) string {
+	if config.UsePathRouting {
+		return fmt.Sprintf(GitHTTPURLTempl, config.Protocol, "", config.Host, config.Port, "/git")
+	}
+	return fmt.Sprintf(GitHTTPURLTempl, config.Protocol, "git.", config.Host, config.Port, "")
+}
+
+func GitHTTPAdminSecretObject() corev1.Secret {
+	return corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GitHTTPAdminSecret,
+			Namespace: GitHTTPNamespace,
+		},
+	}
+}
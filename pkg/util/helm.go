@@ -0,0 +1,360 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/engine"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// HelmScheme is the scheme of package strings referring to a chart in a Helm chart repository,
+	// e.g. helm://charts.example.com/stable/nginx@1.2.3. The repository is accessed over https.
+	HelmScheme = "helm://"
+
+	// HelmChartConfigMediaType is the config media type of Helm charts stored in OCI registries.
+	HelmChartConfigMediaType = "application/vnd.cncf.helm.config.v1+json"
+	// HelmChartLayerMediaType is the media type of the chart archive layer of Helm charts stored in OCI registries.
+	HelmChartLayerMediaType = "application/vnd.cncf.helm.chart.content.v1.tar+gzip"
+
+	QueryStringRender      = "render"
+	QueryStringNamespace   = "namespace"
+	QueryStringReleaseName = "releaseName"
+
+	// HelmChartDir is the directory of a chart package that contains the chart files.
+	HelmChartDir = "chart"
+	// HelmManifestsDir is the directory of a chart package that contains the rendered manifests.
+	HelmManifestsDir = "manifests"
+
+	helmIndexFile = "index.yaml"
+)
+
+// HelmChartRef describes a chart to install as a package.
+type HelmChartRef struct {
+	// RepoURL is the url of the chart repository. It is empty for charts in OCI registries.
+	RepoURL string
+	Name    string
+	// Version is an exact version or a semver constraint. The latest stable version is used when it is empty.
+	Version string
+	// Render renders the chart templates. Otherwise, the chart is vendored and rendered by Argo CD.
+	Render      bool
+	Namespace   string
+	ReleaseName string
+}
+
+// IsHelmPackage returns true if the package string refers to a chart in a Helm chart repository.
+func IsHelmPackage(pkg string) bool {
+	return strings.HasPrefix(pkg, HelmScheme)
+}
+
+// ParseHelmPackage parses a helm:// package string, e.g. helm://charts.example.com/stable/nginx@1.2.3?render=true
+func ParseHelmPackage(pkg string) (HelmChartRef, error) {
+	u, err := url.Parse(pkg)
+	if err != nil {
+		return HelmChartRef{}, fmt.Errorf("parsing helm package %s: %w", pkg, err)
+	}
+	chartPath, version, _ := strings.Cut(strings.TrimSuffix(u.Path, "/"), "@")
+	repoPath, name := path.Split(chartPath)
+	if u.Host == "" || name == "" {
+		return HelmChartRef{}, fmt.Errorf("helm package %s must be formatted as helm://<repository>/<chart>[@<version>]", pkg)
+	}
+
+	ref := HelmChartRef{
+		RepoURL: (&url.URL{Scheme: "https", User: u.User, Host: u.Host, Path: strings.TrimSuffix(repoPath, "/")}).String(),
+		Name:    name,
+		Version: version,
+	}
+	return ref, ref.parseQuery(u.Query())
+}
+
+// ParseHelmChartOptions reads the chart options from the query of an oci:// package string.
+func ParseHelmChartOptions(pkg, name string) (HelmChartRef, error) {
+	_, query, _ := strings.Cut(pkg, "?")
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return HelmChartRef{}, fmt.Errorf("parsing query parameters in package %s: %w", pkg, err)
+	}
+	ref := HelmChartRef{Name: name}
+	return ref, ref.parseQuery(values)
+}
+
+func (h *HelmChartRef) parseQuery(values url.Values) error {
+	if r := values.Get(QueryStringRender); r != "" {
+		v, err := strconv.ParseBool(r)
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: %w", QueryStringRender, err)
+		}
+		h.Render = v
+	}
+	h.ReleaseName = values.Get(QueryStringReleaseName)
+	if h.ReleaseName == "" {
+		h.ReleaseName = h.Name
+	}
+	h.Namespace = values.Get(QueryStringNamespace)
+	if h.Namespace == "" {
+		h.Namespace = h.ReleaseName
+	}
+	return nil
+}
+
+type helmIndex struct {
+	Entries map[string][]helmChartVersion `json:"entries"`
+}
+
+type helmChartVersion struct {
+	Version string   `json:"version"`
+	URLs    []string `json:"urls"`
+}
+
+// DownloadHelmChart downloads the chart archive from the chart repository. It returns the archive and its version.
+func DownloadHelmChart(ctx context.Context, c *http.Client, ref HelmChartRef) ([]byte, string, error) {
+	b, err := httpGet(ctx, c, ref.RepoURL+"/"+helmIndexFile)
+	if err != nil {
+		return nil, "", err
+	}
+	index := helmIndex{}
+	if err = yaml.Unmarshal(b, &index); err != nil {
+		return nil, "", fmt.Errorf("parsing index of %s: %w", ref.RepoURL, err)
+	}
+
+	versions := index.Entries[ref.Name]
+	all := make([]string, 0, len(versions))
+	for i := range versions {
+		all = append(all, versions[i].Version)
+	}
+	constraint := RefLatest
+	if ref.Version != "" {
+		constraint = RefSemverPrefix + ref.Version
+	}
+	version, err := SelectTag(all, constraint)
+	if err != nil {
+		return nil, "", fmt.Errorf("finding chart %s in %s: %w", ref.Name, ref.RepoURL, err)
+	}
+
+	for i := range versions {
+		if versions[i].Version != version || len(versions[i].URLs) == 0 {
+			continue
+		}
+		chartUrl, uErr := resolveChartUrl(ref.RepoURL, versions[i].URLs[0])
+		if uErr != nil {
+			return nil, "", uErr
+		}
+		archive, gErr := httpGet(ctx, c, chartUrl)
+		return archive, version, gErr
+	}
+	return nil, "", fmt.Errorf("chart %s version %s in %s has no download url", ref.Name, version, ref.RepoURL)
+}
+
+// chart urls in the index can be relative to the repository url.
+func resolveChartUrl(repoUrl, chartUrl string) (string, error) {
+	base, err := url.Parse(repoUrl + "/")
+	if err != nil {
+		return "", fmt.Errorf("parsing repository url %s: %w", repoUrl, err)
+	}
+	u, err := base.Parse(chartUrl)
+	if err != nil {
+		return "", fmt.Errorf("parsing chart url %s: %w", chartUrl, err)
+	}
+	return u.String(), nil
+}
+
+// maxDownloadSize is the maximum size of a chart, chart repository index or archive downloaded over http.
+var maxDownloadSize int64 = 512 << 20
+
+func httpGet(ctx context.Context, c *http.Client, u string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request for %s: %w", u, err)
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("downloading %s: %w", u, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("downloading %s: got status code %d", u, resp.StatusCode)
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxDownloadSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", u, err)
+	}
+	if int64(len(b)) > maxDownloadSize {
+		return nil, fmt.Errorf("downloading %s: response exceeds %d bytes", u, maxDownloadSize)
+	}
+	return b, nil
+}
+
+// WriteHelmChartPackage writes the chart archive to dir as a package, replacing the previous content of dir.
+// The chart files are written to the chart directory, or when Render is set, the rendered manifests are written to the
+// manifests directory. An Argo CD Application pointing to that directory with a cnoe:// url is written next to it.
+func WriteHelmChartPackage(archive []byte, ref HelmChartRef, dir string) error {
+	files, err := loader.LoadArchiveFiles(bytes.NewReader(archive))
+	if err != nil {
+		return fmt.Errorf("reading chart archive: %w", err)
+	}
+
+	tmpDir, err := os.MkdirTemp(filepath.Dir(dir), filepath.Base(dir)+"-chart-")
+	if err != nil {
+		return fmt.Errorf("creating temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	srcDir := HelmChartDir
+	if ref.Render {
+		srcDir = HelmManifestsDir
+		manifests, rErr := renderHelmChart(files, ref)
+		if rErr != nil {
+			return rErr
+		}
+		for name, content := range manifests {
+			if wErr := writeFile(filepath.Join(tmpDir, HelmManifestsDir, name), []byte(content)); wErr != nil {
+				return wErr
+			}
+		}
+	} else {
+		for i := range files {
+			if wErr := writeFile(filepath.Join(tmpDir, HelmChartDir, files[i].Name), files[i].Data); wErr != nil {
+				return wErr
+			}
+		}
+	}
+
+	app, err := helmChartApplication(ref, srcDir)
+	if err != nil {
+		return err
+	}
+	if err = writeFile(filepath.Join(tmpDir, ref.ReleaseName+".yaml"), app); err != nil {
+		return err
+	}
+	return replaceDir(tmpDir, dir)
+}
+
+// renderHelmChart renders the chart with its default values like helm template does.
+// It returns the manifests keyed by their path relative to the chart directory.
+func renderHelmChart(files []*loader.BufferedFile, ref HelmChartRef) (map[string]string, error) {
+	chrt, err := loader.LoadFiles(files)
+	if err != nil {
+		return nil, fmt.Errorf("loading chart %s: %w", ref.Name, err)
+	}
+	return renderChart(chrt, ref, map[string]interface{}{})
+}
+
+// RenderHelmChartDir renders the chart in dir with the given values like helm template does.
+// It returns the manifests keyed by their path relative to the chart directory.
+func RenderHelmChartDir(dir string, ref HelmChartRef, values map[string]interface{}) (map[string]string, error) {
+	chrt, err := loader.LoadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("loading chart %s: %w", dir, err)
+	}
+	if values == nil {
+		values = map[string]interface{}{}
+	}
+	return renderChart(chrt, ref, values)
+}
+
+func renderChart(chrt *chart.Chart, ref HelmChartRef, userValues map[string]interface{}) (map[string]string, error) {
+	if ref.Name == "" {
+		ref.Name = chrt.Name()
+	}
+	if ref.ReleaseName == "" {
+		ref.ReleaseName = chrt.Name()
+	}
+	if err := chartutil.ProcessDependencies(chrt, userValues); err != nil {
+		return nil, fmt.Errorf("processing dependencies of chart %s: %w", ref.Name, err)
+	}
+	values, err := chartutil.ToRenderValues(chrt, userValues, chartutil.ReleaseOptions{
+		Name:      ref.ReleaseName,
+		Namespace: ref.Namespace,
+		Revision:  1,
+		IsInstall: true,
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("computing values of chart %s: %w", ref.Name, err)
+	}
+	rendered, err := engine.Render(chrt, values)
+	if err != nil {
+		return nil, fmt.Errorf("rendering chart %s: %w", ref.Name, err)
+	}
+
+	out := make(map[string]string, len(rendered))
+	for name, content := range rendered {
+		if !IsYamlFile(name) || strings.TrimSpace(content) == "" {
+			continue
+		}
+		out[strings.TrimPrefix(name, chrt.Name()+"/")] = content
+	}
+	for _, crd := range chrt.CRDObjects() {
+		out[strings.TrimPrefix(crd.Filename, chrt.Name()+"/")] = string(crd.File.Data)
+	}
+	return out, nil
+}
+
+func helmChartApplication(ref HelmChartRef, srcDir string) ([]byte, error) {
+	source := map[string]interface{}{
+		"repoURL":        "cnoe://" + srcDir,
+		"path":           ".",
+		"targetRevision": "HEAD",
+	}
+	if !ref.Render {
+		source["helm"] = map[string]interface{}{"releaseName": ref.ReleaseName}
+	}
+	app := map[string]interface{}{
+		"apiVersion": "argoproj.io/v1alpha1",
+		"kind":       "Application",
+		"metadata": map[string]interface{}{
+			"name":      ref.ReleaseName,
+			"namespace": "argocd",
+		},
+		"spec": map[string]interface{}{
+			"project": "default",
+			"destination": map[string]interface{}{
+				"server":    "https://kubernetes.default.svc",
+				"namespace": ref.Namespace,
+			},
+			"source": source,
+			"syncPolicy": map[string]interface{}{
+				"automated":   map[string]interface{}{"selfHeal": true},
+				"syncOptions": []string{"CreateNamespace=true"},
+			},
+		},
+	}
+	b, err := yaml.Marshal(app)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling application for chart %s: %w", ref.Name, err)
+	}
+	return b, nil
+}
+
+func writeFile(name string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0750); err != nil {
+		return fmt.Errorf("creating directory for %s: %w", name, err)
+	}
+	if err := os.WriteFile(name, data, 0640); err != nil {
+		return fmt.Errorf("writing %s: %w", name, err)
+	}
+	return nil
+}
+
+// replaceDir replaces dir with src. src must be on the same file system as dir.
+func replaceDir(src, dir string) error {
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("removing %s: %w", dir, err)
+	}
+	if err := os.Rename(src, dir); err != nil {
+		return fmt.Errorf("moving %s to %s: %w", src, dir, err)
+	}
+	return nil
+}
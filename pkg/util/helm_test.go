@@ -0,0 +1,131 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"sigs.k8s.io/yaml"
+)
+
+func TestParseHelmPackage(t *testing.T) {
+	ref, err := ParseHelmPackage("helm://charts.example.com/stable/nginx@^1.2?render=true&namespace=web")
+	assert.Nil(t, err)
+	assert.Equal(t, HelmChartRef{
+		RepoURL:     "https://charts.example.com/stable",
+		Name:        "nginx",
+		Version:     "^1.2",
+		Render:      true,
+		Namespace:   "web",
+		ReleaseName: "nginx",
+	}, ref)
+
+	ref, err = ParseHelmPackage("helm://charts.example.com/nginx")
+	assert.Nil(t, err)
+	assert.Equal(t, "https://charts.example.com", ref.RepoURL)
+	assert.Equal(t, "", ref.Version)
+	assert.Equal(t, "nginx", ref.Namespace)
+
+	_, err = ParseHelmPackage("helm://charts.example.com")
+	assert.NotNil(t, err)
+	_, err = ParseHelmPackage("helm://charts.example.com/nginx?render=maybe")
+	assert.NotNil(t, err)
+}
+
+func testChartArchive(t *testing.T, version string) []byte {
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "app", Version: version},
+		Values:   map[string]interface{}{"replicas": 2},
+		Raw:      []*chart.File{{Name: chartutil.ValuesfileName, Data: []byte("replicas: 2\n")}},
+		Templates: []*chart.File{
+			{Name: "templates/cm.yaml", Data: []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ .Release.Name }}\ndata:\n  replicas: \"{{ .Values.replicas }}\"\n")},
+			{Name: "templates/NOTES.txt", Data: []byte("installed")},
+		},
+	}
+	dir := t.TempDir()
+	p, err := chartutil.Save(c, dir)
+	assert.Nil(t, err)
+	b, err := os.ReadFile(p)
+	assert.Nil(t, err)
+	return b
+}
+
+func TestDownloadHelmChart(t *testing.T) {
+	archives := map[string][]byte{
+		"1.2.0": testChartArchive(t, "1.2.0"),
+		"1.3.0": testChartArchive(t, "1.3.0"),
+		"2.0.0": testChartArchive(t, "2.0.0"),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/charts/index.yaml", func(w http.ResponseWriter, r *http.Request) {
+		index := helmIndex{Entries: map[string][]helmChartVersion{"app": {}}}
+		for v := range archives {
+			index.Entries["app"] = append(index.Entries["app"], helmChartVersion{Version: v, URLs: []string{fmt.Sprintf("app-%s.tgz", v)}})
+		}
+		b, _ := yaml.Marshal(index)
+		w.Write(b)
+	})
+	for v := range archives {
+		b := archives[v]
+		mux.HandleFunc(fmt.Sprintf("/charts/app-%s.tgz", v), func(w http.ResponseWriter, r *http.Request) {
+			w.Write(b)
+		})
+	}
+	s := httptest.NewTLSServer(mux)
+	defer s.Close()
+
+	ref := HelmChartRef{RepoURL: s.URL + "/charts", Name: "app", Version: "^1.2"}
+	b, version, err := DownloadHelmChart(context.Background(), s.Client(), ref)
+	assert.Nil(t, err)
+	assert.Equal(t, "1.3.0", version)
+	assert.Equal(t, archives["1.3.0"], b)
+
+	ref.Version = ""
+	_, version, err = DownloadHelmChart(context.Background(), s.Client(), ref)
+	assert.Nil(t, err)
+	assert.Equal(t, "2.0.0", version)
+
+	ref.Name = "missing"
+	_, _, err = DownloadHelmChart(context.Background(), s.Client(), ref)
+	assert.NotNil(t, err)
+}
+
+func TestWriteHelmChartPackage(t *testing.T) {
+	archive := testChartArchive(t, "1.0.0")
+	dir := filepath.Join(t.TempDir(), "pkg")
+
+	ref := HelmChartRef{Name: "app", ReleaseName: "my-app", Namespace: "apps", Render: true}
+	assert.Nil(t, WriteHelmChartPackage(archive, ref, dir))
+	b, err := os.ReadFile(filepath.Join(dir, HelmManifestsDir, "templates", "cm.yaml"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(b), "name: my-app")
+	assert.Contains(t, string(b), "replicas: \"2\"")
+	_, err = os.Stat(filepath.Join(dir, HelmManifestsDir, "templates", "NOTES.txt"))
+	assert.True(t, os.IsNotExist(err))
+
+	app := map[string]interface{}{}
+	b, err = os.ReadFile(filepath.Join(dir, "my-app.yaml"))
+	assert.Nil(t, err)
+	assert.Nil(t, yaml.Unmarshal(b, &app))
+	spec := app["spec"].(map[string]interface{})
+	assert.Equal(t, "cnoe://manifests", spec["source"].(map[string]interface{})["repoURL"])
+	assert.Equal(t, "apps", spec["destination"].(map[string]interface{})["namespace"])
+
+	ref.Render = false
+	assert.Nil(t, WriteHelmChartPackage(archive, ref, dir))
+	_, err = os.Stat(filepath.Join(dir, HelmChartDir, "Chart.yaml"))
+	assert.Nil(t, err)
+	_, err = os.Stat(filepath.Join(dir, HelmManifestsDir))
+	assert.True(t, os.IsNotExist(err))
+	b, err = os.ReadFile(filepath.Join(dir, "my-app.yaml"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(b), "repoURL: cnoe://chart")
+	assert.Contains(t, string(b), "releaseName: my-app")
+}
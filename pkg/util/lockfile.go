@@ -0,0 +1,137 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
+	"sigs.k8s.io/yaml"
+)
+
+// RefLockFile pins dynamic refs of package urls, e.g. semver:^1.4, to the tags they resolved to.
+// It makes runs reproducible, e.g. in CI, because the pinned tag is used instead of resolving the ref again.
+type RefLockFile struct {
+	Refs []LockedRef `json:"refs"`
+}
+
+// LockedRef is a dynamic ref of a repository and the tag it resolved to.
+type LockedRef struct {
+	Url      string `json:"url"`
+	Ref      string `json:"ref"`
+	Resolved string `json:"resolved"`
+}
+
+// ReadRefLockFile reads the lock file at path. An empty lock file is returned if the file does not exist.
+func ReadRefLockFile(path string) (*RefLockFile, error) {
+	l := &RefLockFile{}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return l, nil
+		}
+		return nil, fmt.Errorf("reading lock file %s: %w", path, err)
+	}
+	err = yaml.UnmarshalStrict(b, l)
+	if err != nil {
+		return nil, fmt.Errorf("parsing lock file %s: %w", path, err)
+	}
+	return l, nil
+}
+
+// Write writes the lock file to path with entries sorted by url and ref.
+func (l *RefLockFile) Write(path string) error {
+	sort.Slice(l.Refs, func(i, j int) bool {
+		if l.Refs[i].Url == l.Refs[j].Url {
+			return l.Refs[i].Ref < l.Refs[j].Ref
+		}
+		return l.Refs[i].Url < l.Refs[j].Url
+	})
+	b, err := yaml.Marshal(l)
+	if err != nil {
+		return fmt.Errorf("marshalling lock file: %w", err)
+	}
+	err = os.WriteFile(path, b, 0644)
+	if err != nil {
+		return fmt.Errorf("writing lock file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Lookup returns the tag pinned for the dynamic ref of the repository.
+func (l *RefLockFile) Lookup(repoUrl, ref string) (string, bool) {
+	for i := range l.Refs {
+		if l.Refs[i].Url == repoUrl && l.Refs[i].Ref == ref {
+			return l.Refs[i].Resolved, true
+		}
+	}
+	return "", false
+}
+
+// Set pins the dynamic ref of the repository to the given tag.
+func (l *RefLockFile) Set(repoUrl, ref, resolved string) {
+	for i := range l.Refs {
+		if l.Refs[i].Url == repoUrl && l.Refs[i].Ref == ref {
+			l.Refs[i].Resolved = resolved
+			return
+		}
+	}
+	l.Refs = append(l.Refs, LockedRef{Url: repoUrl, Ref: ref, Resolved: resolved})
+}
+
+// PinPackageUrls returns pkgUrls with dynamic refs replaced by tags. Tags pinned in the lock file are used unless update
+// is true, in which case refs are resolved against the remote and the lock file is updated.
+func PinPackageUrls(ctx context.Context, pkgUrls []string, l *RefLockFile, update bool, gitAuth *GitAuthConfig) ([]string, error) {
+	out := make([]string, 0, len(pkgUrls))
+	for _, pkgUrl := range pkgUrls {
+		remote, err := NewKustomizeRemote(pkgUrl)
+		if err != nil {
+			return nil, fmt.Errorf("parsing url, %s: %w", pkgUrl, err)
+		}
+		if !IsDynamicRef(remote.Ref) {
+			out = append(out, pkgUrl)
+			continue
+		}
+
+		repoUrl := remote.CloneUrl()
+		// passwords in urls must not end up in the lock file
+		noPass := *remote
+		noPass.Password = ""
+		tag, ok := l.Lookup(noPass.CloneUrl(), remote.Ref)
+		if !ok || update {
+			auth, aErr := gitAuth.AuthMethod(repoUrl)
+			if aErr != nil {
+				return nil, fmt.Errorf("getting credentials for %s: %w", pkgUrl, aErr)
+			}
+			rs, rErr := ResolveRemoteRef(ctx, v1alpha1.RemoteRepositorySpec{Url: repoUrl, Ref: remote.Ref}, false, auth)
+			if rErr != nil {
+				return nil, rErr
+			}
+			tag = rs.Ref
+			l.Set(noPass.CloneUrl(), remote.Ref, tag)
+		}
+
+		pinned, err := setUrlRef(pkgUrl, tag)
+		if err != nil {
+			return nil, fmt.Errorf("pinning ref of %s: %w", pkgUrl, err)
+		}
+		out = append(out, pinned)
+	}
+	return out, nil
+}
+
+// setUrlRef replaces the ref and version query parameters of the package url with ref.
+func setUrlRef(pkgUrl, ref string) (string, error) {
+	base, query, _ := strings.Cut(pkgUrl, "?")
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return "", err
+	}
+	values.Del(QueryStringVersion)
+	values.Set(QueryStringRef, ref)
+	return fmt.Sprintf("%s?%s", base, values.Encode()), nil
+}
@@ -0,0 +1,48 @@
+package util
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPinPackageUrls(t *testing.T) {
+	srcDir := t.TempDir()
+	src, err := git.PlainInit(srcDir, false)
+	assert.Nil(t, err)
+	h := commitFile(t, src, srcDir, "a.yaml")
+	_, err = src.CreateTag("v1.0.0", plumbing.NewHash(h), nil)
+	assert.Nil(t, err)
+
+	pkgUrl := "file://" + srcDir + "//pkg?ref=semver:^1&submodules=false"
+	static := "https://github.com/cnoe-io/stacks//ref-implementation?ref=main"
+	lockPath := filepath.Join(t.TempDir(), "idpbuilder.lock")
+
+	l, err := ReadRefLockFile(lockPath)
+	assert.Nil(t, err)
+	pinned, err := PinPackageUrls(context.Background(), []string{pkgUrl, static}, l, false, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"file://" + srcDir + "//pkg?ref=v1.0.0&submodules=false", static}, pinned)
+	assert.Nil(t, l.Write(lockPath))
+
+	// a newer tag is ignored until the lock file is updated
+	h = commitFile(t, src, srcDir, "b.yaml")
+	_, err = src.CreateTag("v1.1.0", plumbing.NewHash(h), nil)
+	assert.Nil(t, err)
+
+	l, err = ReadRefLockFile(lockPath)
+	assert.Nil(t, err)
+	assert.Equal(t, []LockedRef{{Url: "file://" + srcDir, Ref: "semver:^1", Resolved: "v1.0.0"}}, l.Refs)
+	pinned, err = PinPackageUrls(context.Background(), []string{pkgUrl}, l, false, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"file://" + srcDir + "//pkg?ref=v1.0.0&submodules=false"}, pinned)
+
+	pinned, err = PinPackageUrls(context.Background(), []string{pkgUrl}, l, true, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"file://" + srcDir + "//pkg?ref=v1.1.0&submodules=false"}, pinned)
+	assert.Equal(t, "v1.1.0", l.Refs[0].Resolved)
+}
@@ -0,0 +1,143 @@
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/file"
+	"oras.land/oras-go/v2/registry"
+	"oras.land/oras-go/v2/registry/remote"
+	"oras.land/oras-go/v2/registry/remote/auth"
+	"oras.land/oras-go/v2/registry/remote/credentials"
+	"oras.land/oras-go/v2/registry/remote/retry"
+)
+
+const OCIScheme = "oci://"
+
+// IsOCIPackage returns true if the package string refers to an OCI artifact, e.g. oci://ghcr.io/org/pkg:v1.0.0
+func IsOCIPackage(pkg string) bool {
+	return strings.HasPrefix(pkg, OCIScheme)
+}
+
+// ParseOCIReference parses an oci:// package reference. The reference must have a tag or digest.
+// Query parameters, e.g. the chart options of Helm charts, are ignored.
+func ParseOCIReference(ref string) (registry.Reference, error) {
+	name, _, _ := strings.Cut(strings.TrimPrefix(ref, OCIScheme), "?")
+	r, err := registry.ParseReference(name)
+	if err != nil {
+		return registry.Reference{}, fmt.Errorf("parsing oci reference %s: %w", ref, err)
+	}
+	if r.Reference == "" {
+		return registry.Reference{}, fmt.Errorf("oci reference %s must have a tag or digest", ref)
+	}
+	return r, nil
+}
+
+// NewOCIRepository returns the remote repository of an oci:// package reference and the tag or digest to pull.
+// Credentials are read from the docker style config file at registryConfig, or from the docker config when it is empty.
+func NewOCIRepository(ref, registryConfig string) (*remote.Repository, string, error) {
+	r, err := ParseOCIReference(ref)
+	if err != nil {
+		return nil, "", err
+	}
+	repo, err := remote.NewRepository(r.String())
+	if err != nil {
+		return nil, "", fmt.Errorf("creating oci repository %s: %w", ref, err)
+	}
+
+	var store credentials.Store
+	if registryConfig == "" {
+		store, err = credentials.NewStoreFromDocker(credentials.StoreOptions{})
+	} else {
+		store, err = credentials.NewStore(registryConfig, credentials.StoreOptions{})
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("reading registry credentials: %w", err)
+	}
+
+	repo.PlainHTTP = isLoopbackHost(repo.Reference.Registry)
+	repo.Client = &auth.Client{
+		Client:     retry.DefaultClient,
+		Cache:      auth.NewCache(),
+		Credential: credentials.Credential(store),
+	}
+	return repo, repo.Reference.Reference, nil
+}
+
+// PullOCIArtifact pulls the artifact tagged reference from src and writes its files to dir, replacing the previous
+// content of dir. Only layers with a title annotation, as created by oras push, are written. Layers that are
+// directories are extracted. It returns the digest of the artifact manifest.
+func PullOCIArtifact(ctx context.Context, src oras.ReadOnlyTarget, reference, dir string) (string, error) {
+	tmpDir, err := os.MkdirTemp(filepath.Dir(dir), filepath.Base(dir)+"-pull-")
+	if err != nil {
+		return "", fmt.Errorf("creating temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	dst, err := file.New(tmpDir)
+	if err != nil {
+		return "", fmt.Errorf("creating file store: %w", err)
+	}
+	defer dst.Close()
+
+	desc, err := oras.Copy(ctx, src, reference, dst, reference, oras.DefaultCopyOptions)
+	if err != nil {
+		return "", fmt.Errorf("pulling %s: %w", reference, err)
+	}
+
+	if err = replaceDir(tmpDir, dir); err != nil {
+		return "", err
+	}
+	return desc.Digest.String(), nil
+}
+
+// FetchOCIHelmChart returns the chart archive if the artifact described by desc is a Helm chart.
+// It returns false if the artifact is not a Helm chart.
+func FetchOCIHelmChart(ctx context.Context, src oras.ReadOnlyTarget, desc ocispec.Descriptor) ([]byte, bool, error) {
+	if desc.MediaType != ocispec.MediaTypeImageManifest {
+		return nil, false, nil
+	}
+	b, err := content.FetchAll(ctx, src, desc)
+	if err != nil {
+		return nil, false, fmt.Errorf("fetching manifest %s: %w", desc.Digest, err)
+	}
+	manifest := ocispec.Manifest{}
+	if err = json.Unmarshal(b, &manifest); err != nil {
+		return nil, false, fmt.Errorf("parsing manifest %s: %w", desc.Digest, err)
+	}
+	if manifest.Config.MediaType != HelmChartConfigMediaType {
+		return nil, false, nil
+	}
+	for _, l := range manifest.Layers {
+		if l.MediaType != HelmChartLayerMediaType {
+			continue
+		}
+		archive, fErr := content.FetchAll(ctx, src, l)
+		if fErr != nil {
+			return nil, true, fmt.Errorf("fetching chart layer %s: %w", l.Digest, fErr)
+		}
+		return archive, true, nil
+	}
+	return nil, true, fmt.Errorf("helm chart %s has no chart layer", desc.Digest)
+}
+
+// registries on the local machine, e.g. one started with docker run registry, are usually served over plain http.
+func isLoopbackHost(hostPort string) bool {
+	host, _, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		host = hostPort
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,93 @@
+package util
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content/file"
+	"oras.land/oras-go/v2/content/memory"
+)
+
+func TestNewOCIRepository(t *testing.T) {
+	repo, tag, err := NewOCIRepository("oci://localhost:5000/packages/app:v1.0.0", filepath.Join(t.TempDir(), "config.json"))
+	assert.Nil(t, err)
+	assert.Equal(t, "v1.0.0", tag)
+	assert.Equal(t, "localhost:5000", repo.Reference.Registry)
+	assert.True(t, repo.PlainHTTP)
+
+	repo, _, err = NewOCIRepository("oci://ghcr.io/org/app:v1.0.0", filepath.Join(t.TempDir(), "config.json"))
+	assert.Nil(t, err)
+	assert.False(t, repo.PlainHTTP)
+
+	_, _, err = NewOCIRepository("oci://ghcr.io/org/app", filepath.Join(t.TempDir(), "config.json"))
+	assert.NotNil(t, err)
+}
+
+func TestPullOCIArtifact(t *testing.T) {
+	ctx := context.Background()
+	srcDir := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(srcDir, "app.yaml"), []byte("kind: Application\n"), 0600))
+	assert.Nil(t, os.MkdirAll(filepath.Join(srcDir, "manifests"), 0700))
+	assert.Nil(t, os.WriteFile(filepath.Join(srcDir, "manifests", "cm.yaml"), []byte("kind: ConfigMap\n"), 0600))
+
+	src, err := file.New(srcDir)
+	assert.Nil(t, err)
+	defer src.Close()
+	var layers []ocispec.Descriptor
+	for _, name := range []string{"app.yaml", "manifests"} {
+		desc, aErr := src.Add(ctx, name, "", filepath.Join(srcDir, name))
+		assert.Nil(t, aErr)
+		layers = append(layers, desc)
+	}
+	manifest, err := oras.PackManifest(ctx, src, oras.PackManifestVersion1_1, "application/vnd.cnoe.package", oras.PackManifestOptions{Layers: layers})
+	assert.Nil(t, err)
+	assert.Nil(t, src.Tag(ctx, manifest, "v1"))
+
+	dir := filepath.Join(t.TempDir(), "pkg")
+	assert.Nil(t, os.MkdirAll(dir, 0700))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "stale.yaml"), []byte("kind: ConfigMap\n"), 0600))
+
+	digest, err := PullOCIArtifact(ctx, src, "v1", dir)
+	assert.Nil(t, err)
+	assert.Equal(t, manifest.Digest.String(), digest)
+
+	b, err := os.ReadFile(filepath.Join(dir, "manifests", "cm.yaml"))
+	assert.Nil(t, err)
+	assert.Equal(t, "kind: ConfigMap\n", string(b))
+	_, err = os.Stat(filepath.Join(dir, "app.yaml"))
+	assert.Nil(t, err)
+	_, err = os.Stat(filepath.Join(dir, "stale.yaml"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestFetchOCIHelmChart(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+	archive := testChartArchive(t, "1.0.0")
+
+	config, err := oras.PushBytes(ctx, store, HelmChartConfigMediaType, []byte(`{"name":"app","version":"1.0.0"}`))
+	assert.Nil(t, err)
+	layer, err := oras.PushBytes(ctx, store, HelmChartLayerMediaType, archive)
+	assert.Nil(t, err)
+	chartManifest, err := oras.PackManifest(ctx, store, oras.PackManifestVersion1_1, "", oras.PackManifestOptions{
+		ConfigDescriptor: &config,
+		Layers:           []ocispec.Descriptor{layer},
+	})
+	assert.Nil(t, err)
+
+	b, isChart, err := FetchOCIHelmChart(ctx, store, chartManifest)
+	assert.Nil(t, err)
+	assert.True(t, isChart)
+	assert.Equal(t, archive, b)
+
+	other, err := oras.PackManifest(ctx, store, oras.PackManifestVersion1_1, "application/vnd.cnoe.package", oras.PackManifestOptions{Layers: []ocispec.Descriptor{layer}})
+	assert.Nil(t, err)
+	_, isChart, err = FetchOCIHelmChart(ctx, store, other)
+	assert.Nil(t, err)
+	assert.False(t, isChart)
+}
@@ -0,0 +1,75 @@
+package util
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
+	"github.com/cnoe-io/idpbuilder/pkg/util/files"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// TemplateFileExt is the suffix of package files that are rendered as go templates before they are committed.
+// The rendered file is written without the suffix, e.g. ingress.yaml.tmpl is written as ingress.yaml.
+const TemplateFileExt = ".tmpl"
+
+// PackageTemplateData is the data available to templated package files,
+// e.g. {{ .Host }}, {{ .Port }}, {{ .ArgoCDURL }} or {{ .Values.replicas }}
+type PackageTemplateData struct {
+	v1alpha1.BuildCustomizationSpec
+	GiteaURL  string
+	ArgoCDURL string
+	// Values are the values supplied by the user.
+	Values map[string]interface{}
+}
+
+func NewPackageTemplateData(config v1alpha1.BuildCustomizationSpec, values *apiextensionsv1.JSON) (PackageTemplateData, error) {
+	v, err := JSONToValues(values)
+	if err != nil {
+		return PackageTemplateData{}, err
+	}
+	return PackageTemplateData{
+		BuildCustomizationSpec: config,
+		GiteaURL:               GiteaBaseUrl(config),
+		ArgoCDURL:              ArgocdBaseUrl(config),
+		Values:                 v,
+	}, nil
+}
+
+// RenderPackageTemplates renders the files with the template suffix in dir and replaces them with the rendered files.
+func RenderPackageTemplates(dir string, data PackageTemplateData) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || !strings.HasSuffix(d.Name(), TemplateFileExt) {
+			return nil
+		}
+
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", path, err)
+		}
+		rendered, err := files.ApplyTemplate(b, data)
+		if err != nil {
+			return fmt.Errorf("rendering %s: %w", path, err)
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if err = os.WriteFile(strings.TrimSuffix(path, TemplateFileExt), rendered, info.Mode().Perm()); err != nil {
+			return fmt.Errorf("writing rendered %s: %w", path, err)
+		}
+		return os.Remove(path)
+	})
+}
@@ -0,0 +1,37 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+func TestRenderPackageTemplates(t *testing.T) {
+	dir := t.TempDir()
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "manifests"), 0750))
+	tmpl := "host: {{ .Host }}:{{ .Port }}\nargocd: {{ .ArgoCDURL }}\nreplicas: {{ .Values.replicas }}\n"
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "manifests", "ingress.yaml.tmpl"), []byte(tmpl), 0640))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "plain.yaml"), []byte("host: {{ .Host }}\n"), 0640))
+
+	config := v1alpha1.BuildCustomizationSpec{Protocol: "https", Host: "idp.example.com", Port: "443"}
+	data, err := NewPackageTemplateData(config, &apiextensionsv1.JSON{Raw: []byte(`{"replicas":3}`)})
+	assert.Nil(t, err)
+	assert.Nil(t, RenderPackageTemplates(dir, data))
+
+	b, err := os.ReadFile(filepath.Join(dir, "manifests", "ingress.yaml"))
+	assert.Nil(t, err)
+	assert.Equal(t, "host: idp.example.com:443\nargocd: https://argocd.idp.example.com:443\nreplicas: 3\n", string(b))
+	_, err = os.Stat(filepath.Join(dir, "manifests", "ingress.yaml.tmpl"))
+	assert.True(t, os.IsNotExist(err))
+
+	b, err = os.ReadFile(filepath.Join(dir, "plain.yaml"))
+	assert.Nil(t, err)
+	assert.Equal(t, "host: {{ .Host }}\n", string(b))
+
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "broken.yaml.tmpl"), []byte("{{ .Missing "), 0640))
+	assert.NotNil(t, RenderPackageTemplates(dir, data))
+}
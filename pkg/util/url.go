@@ -6,6 +6,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
 )
 
 // constants from remote target parameters supported by Kustomize
@@ -15,6 +17,11 @@ const (
 	QueryStringVersion    = "version"
 	QueryStringTimeout    = "timeout"
 	QueryStringSubmodules = "submodules"
+	// QueryStringLFS is specific to idpbuilder. It sets how Git LFS objects are replicated. See RemoteRepositorySpec.
+	QueryStringLFS = "lfs"
+	// QueryStringReplicate is specific to idpbuilder. When false, Argo CD pulls the package from the remote repository
+	// instead of the in-cluster git server. See CustomPackageSpec.
+	QueryStringReplicate = "replicate"
 
 	RepoUrlDelimiter = "//"
 	SCPDelimiter     = ":"
@@ -42,6 +49,8 @@ type KustomizeRemote struct {
 	Ref        string
 	Submodules bool
 	Timeout    time.Duration
+	LFS        string
+	Replicate  bool
 }
 
 func (g *KustomizeRemote) CloneUrl() string {
@@ -114,7 +123,28 @@ func (g *KustomizeRemote) parseQuery() error {
 		}
 	}
 
+	lfs := values.Get(QueryStringLFS)
+	switch strings.ToLower(lfs) {
+	case "":
+	case strings.ToLower(v1alpha1.LFSModePush):
+		g.LFS = v1alpha1.LFSModePush
+	case strings.ToLower(v1alpha1.LFSModeMaterialize):
+		g.LFS = v1alpha1.LFSModeMaterialize
+	default:
+		return fmt.Errorf("invalid value for %s: %s. must be one of %s or %s", QueryStringLFS, lfs, v1alpha1.LFSModePush, v1alpha1.LFSModeMaterialize)
+	}
+
+	replicate := true
+	if r := values.Get(QueryStringReplicate); r != "" {
+		v, pErr := strconv.ParseBool(r)
+		if pErr != nil {
+			return fmt.Errorf("invalid value for %s: %s", QueryStringReplicate, r)
+		}
+		replicate = v
+	}
+
 	g.Ref = version
+	g.Replicate = replicate
 	g.Submodules = cloneSubmodules
 	g.Timeout = duration
 
@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -15,7 +16,10 @@ func TestURLParse(t *testing.T) {
 		ref       string
 		submodule bool
 		timeout   time.Duration
-		err       bool
+		lfs       string
+		// the package is replicated unless noReplicate is set
+		noReplicate bool
+		err         bool
 	}
 
 	type testCase struct {
@@ -60,6 +64,38 @@ func TestURLParse(t *testing.T) {
 				timeout:   1 * time.Second,
 			},
 		},
+		{
+			input: "https://github.com/org/repo//packages/app?lfs=materialize",
+			expect: expect{
+				cloneUrl:  "https://github.com/org/repo",
+				path:      "packages/app",
+				submodule: true,
+				timeout:   defaultTimeout,
+				lfs:       v1alpha1.LFSModeMaterialize,
+			},
+		},
+		{
+			input: "https://github.com/org/repo//packages/app?lfs=always",
+			expect: expect{
+				err: true,
+			},
+		},
+		{
+			input: "https://github.com/org/repo//packages/app?replicate=false",
+			expect: expect{
+				cloneUrl:    "https://github.com/org/repo",
+				path:        "packages/app",
+				submodule:   true,
+				timeout:     defaultTimeout,
+				noReplicate: true,
+			},
+		},
+		{
+			input: "https://github.com/org/repo//packages/app?replicate=never",
+			expect: expect{
+				err: true,
+			},
+		},
 	}
 
 	for i := range cases {
@@ -78,5 +114,7 @@ func TestURLParse(t *testing.T) {
 		assert.Equal(t, c.expect.timeout, r.Timeout)
 		assert.Equal(t, c.expect.ref, r.Ref)
 		assert.Equal(t, c.expect.submodule, r.Submodules)
+		assert.Equal(t, c.expect.lfs, r.LFS)
+		assert.Equal(t, !c.expect.noReplicate, r.Replicate)
 	}
 }
@@ -1,10 +1,14 @@
 package util
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"crypto/rand"
 	"crypto/tls"
+	"errors"
 	"fmt"
+	"io"
 	"math"
 	"math/big"
 	mathrand "math/rand"
@@ -17,6 +21,7 @@ import (
 
 	"github.com/cnoe-io/idpbuilder/api/v1alpha1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/kind/pkg/cluster"
 )
@@ -141,6 +146,35 @@ func IsYamlFile(input string) bool {
 	return extension == ".yaml" || extension == ".yml"
 }
 
+// SplitYamlDocuments returns the documents of a multi-document yaml file. Documents without content are skipped.
+func SplitYamlDocuments(b []byte) ([][]byte, error) {
+	r := k8syaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(b)))
+	var docs [][]byte
+	for {
+		doc, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return docs, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading yaml document: %w", err)
+		}
+		if isEmptyYamlDocument(doc) {
+			continue
+		}
+		docs = append(docs, doc)
+	}
+}
+
+func isEmptyYamlDocument(doc []byte) bool {
+	for _, line := range strings.Split(string(doc), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && line != "---" && !strings.HasPrefix(line, "#") {
+			return false
+		}
+	}
+	return true
+}
+
 func GetHttpClient() *http.Client {
 	tr := &http.Transport{
 		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
@@ -169,6 +203,17 @@ func DetectKindNodeProvider() (cluster.ProviderOption, error) {
 	}
 }
 
+// GetPackageDependencies returns the application names listed in the depends-on annotation.
+func GetPackageDependencies(annotations map[string]string) []string {
+	var out []string
+	for _, d := range strings.Split(annotations[v1alpha1.PackageDependsOnAnnotation], ",") {
+		if d = strings.TrimSpace(d); d != "" {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
 func SetPackageLabels(obj client.Object) {
 	labels := obj.GetLabels()
 	if labels == nil {
@@ -178,7 +223,7 @@ func SetPackageLabels(obj client.Object) {
 	labels[v1alpha1.PackageNameLabelKey] = obj.GetName()
 
 	switch n := obj.GetName(); n {
-	case v1alpha1.ArgoCDPackageName, v1alpha1.GiteaPackageName, v1alpha1.IngressNginxPackageName:
+	case v1alpha1.ArgoCDPackageName, v1alpha1.GiteaPackageName, v1alpha1.IngressNginxPackageName, v1alpha1.GatewayPackageName, v1alpha1.FluxPackageName, v1alpha1.GitHTTPPackageName:
 		labels[v1alpha1.PackageTypeLabelKey] = v1alpha1.PackageTypeLabelCore
 	default:
 		labels[v1alpha1.PackageTypeLabelKey] = v1alpha1.PackageTypeLabelCustom
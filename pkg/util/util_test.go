@@ -61,6 +61,13 @@ func (m *MockObject) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+func TestGetPackageDependencies(t *testing.T) {
+	assert.Nil(t, GetPackageDependencies(nil))
+	assert.Equal(t, []string{"cert-manager", "crossplane"}, GetPackageDependencies(map[string]string{
+		v1alpha1.PackageDependsOnAnnotation: " cert-manager, ,crossplane",
+	}))
+}
+
 func TestSetPackageLabels(t *testing.T) {
 	testCases := []struct {
 		name           string
@@ -134,3 +141,15 @@ func TestSetPackageLabels(t *testing.T) {
 		})
 	}
 }
+
+func TestSplitYamlDocuments(t *testing.T) {
+	docs, err := SplitYamlDocuments([]byte("# header\n---\nkind: Application\nmetadata:\n  name: a\n---\n# comment only\n---\nkind: AppProject\nmetadata:\n  name: p\n"))
+	assert.Nil(t, err)
+	assert.Len(t, docs, 2)
+	assert.Contains(t, string(docs[0]), "name: a")
+	assert.Contains(t, string(docs[1]), "kind: AppProject")
+
+	docs, err = SplitYamlDocuments([]byte("kind: Application\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, [][]byte{[]byte("kind: Application\n")}, docs)
+}
@@ -0,0 +1,79 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// PackageValuesFile is the file in the root of a package that contains the default values of the package.
+const PackageValuesFile = "idpbuilder-values.yaml"
+
+// ParseValues parses a YAML or JSON values document.
+func ParseValues(b []byte) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	if err := yaml.Unmarshal(b, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// ReadValuesFiles reads and merges values files. Values of later files take precedence.
+func ReadValuesFiles(paths ...string) (map[string]interface{}, error) {
+	out := map[string]interface{}{}
+	for _, p := range paths {
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return nil, fmt.Errorf("reading values file %s: %w", p, err)
+		}
+		values, err := ParseValues(b)
+		if err != nil {
+			return nil, fmt.Errorf("parsing values file %s: %w", p, err)
+		}
+		out = MergeValues(out, values)
+	}
+	return out, nil
+}
+
+// MergeValues merges src into dst and returns dst. Maps are merged recursively, other values in src replace those in dst.
+func MergeValues(dst, src map[string]interface{}) map[string]interface{} {
+	if dst == nil {
+		dst = map[string]interface{}{}
+	}
+	for k, v := range src {
+		srcMap, srcIsMap := v.(map[string]interface{})
+		dstMap, dstIsMap := dst[k].(map[string]interface{})
+		if srcIsMap && dstIsMap {
+			dst[k] = MergeValues(dstMap, srcMap)
+			continue
+		}
+		dst[k] = v
+	}
+	return dst
+}
+
+// ValuesToJSON returns nil when values is empty.
+func ValuesToJSON(values map[string]interface{}) (*apiextensionsv1.JSON, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal(values)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling values: %w", err)
+	}
+	return &apiextensionsv1.JSON{Raw: b}, nil
+}
+
+func JSONToValues(values *apiextensionsv1.JSON) (map[string]interface{}, error) {
+	out := map[string]interface{}{}
+	if values == nil || len(values.Raw) == 0 {
+		return out, nil
+	}
+	if err := json.Unmarshal(values.Raw, &out); err != nil {
+		return nil, fmt.Errorf("parsing values: %w", err)
+	}
+	return out, nil
+}
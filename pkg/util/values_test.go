@@ -0,0 +1,52 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeValues(t *testing.T) {
+	dst := map[string]interface{}{
+		"replicas": 1,
+		"image":    map[string]interface{}{"repository": "nginx", "tag": "1.25"},
+		"ports":    []interface{}{80},
+	}
+	src := map[string]interface{}{
+		"image": map[string]interface{}{"tag": "1.27"},
+		"ports": []interface{}{8080},
+	}
+	assert.Equal(t, map[string]interface{}{
+		"replicas": 1,
+		"image":    map[string]interface{}{"repository": "nginx", "tag": "1.27"},
+		"ports":    []interface{}{8080},
+	}, MergeValues(dst, src))
+	assert.Equal(t, src, MergeValues(nil, src))
+}
+
+func TestReadValuesFiles(t *testing.T) {
+	dir := t.TempDir()
+	global := filepath.Join(dir, "global.yaml")
+	team := filepath.Join(dir, "team.yaml")
+	assert.Nil(t, os.WriteFile(global, []byte("team: platform\nreplicas: 1\n"), 0600))
+	assert.Nil(t, os.WriteFile(team, []byte("team: a\n"), 0600))
+
+	values, err := ReadValuesFiles(global, team)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"team": "a", "replicas": float64(1)}, values)
+
+	j, err := ValuesToJSON(values)
+	assert.Nil(t, err)
+	roundTrip, err := JSONToValues(j)
+	assert.Nil(t, err)
+	assert.Equal(t, values, roundTrip)
+
+	j, err = ValuesToJSON(map[string]interface{}{})
+	assert.Nil(t, err)
+	assert.Nil(t, j)
+
+	_, err = ReadValuesFiles(filepath.Join(dir, "missing.yaml"))
+	assert.NotNil(t, err)
+}